@@ -1,12 +1,16 @@
 package controllers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"go-api/internal/http/response_models"
 	"go-api/internal/repositories"
-	pushnotificationservice "go-api/internal/services/push_notification"
+	dropnotificationservice "go-api/internal/services/drop_notification"
 	reportservice "go-api/internal/services/report"
 	"go-api/internal/storage/postgres"
+	"go-api/pkg/converters"
+	"go-api/pkg/drop_type_apis"
+	"go-api/pkg/errors2"
 	"go-api/pkg/model"
 	"net/http"
 	"strconv"
@@ -537,29 +541,34 @@ func AdminManageReport(c *gin.Context) {
 // AdminScheduleDrop godoc
 //
 // @Summary		Schedule drop
-// @Description	Schedule drop by admin user
+// @Description	Schedule a drop for a future time by admin user
 // @Tags			admin
 // @Accept			json
 // @Produce		json
 // @Security BearerAuth
 // @Param		params	body model.ScheduleDropParam true "Send drop data"
 // @Success		201 {object} response_models.GetDropNotificationResponse
-// @Failure		422 "Invalid drop data"
+// @Failure		422 {object} errors2.MultiFieldsError
 // @Failure		500
 // @Router			/admin/drops/schedule [post]
 func AdminScheduleDrop(c *gin.Context) {
-	sqlDB := postgres.Connect()
-
-	dnr := postgres.NewDropNotifRepo(sqlDB)
-
 	var scheduleDropParam model.ScheduleDropParam
 	if err := c.ShouldBindJSON(&scheduleDropParam); err != nil {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 		return
 	}
 
-	dropNotifModel, err := dnr.Create(scheduleDropParam.Type)
+	dropNotificationService := dropnotificationservice.DropNotificationService{
+		Repo: repositories.Setup(),
+	}
+
+	dropNotifModel, err := dropNotificationService.ScheduleDrop(scheduleDropParam)
 	if err != nil {
+		var multiFieldsErr errors2.MultiFieldsError
+		if errors.As(err, &multiFieldsErr) {
+			c.JSON(http.StatusUnprocessableEntity, multiFieldsErr)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -567,6 +576,147 @@ func AdminScheduleDrop(c *gin.Context) {
 	c.JSON(http.StatusCreated, response_models.FormatGetDropNotificationResponse(dropNotifModel))
 }
 
+// AdminGetDropTypes godoc
+//
+// @Summary		Get drop types
+// @Description	Get the drop types enabled in this environment by admin user
+// @Tags			admin
+// @Accept			json
+// @Produce		json
+// @Security BearerAuth
+// @Success		200 {object} []response_models.GetDropTypeResponse
+// @Router			/admin/drops/types [get]
+func AdminGetDropTypes(c *gin.Context) {
+	providers := drop_type_apis.GetProviders()
+
+	dropTypesResponse := make([]response_models.GetDropTypeResponse, 0, len(providers))
+	for _, provider := range providers {
+		dropTypesResponse = append(dropTypesResponse, response_models.FormatGetDropTypeResponse(provider))
+	}
+
+	c.JSON(http.StatusOK, dropTypesResponse)
+}
+
+// AdminGetScheduledDrops godoc
+//
+// @Summary		Get scheduled drops
+// @Description	Get upcoming drops that have not been fired yet by admin user
+// @Tags			admin
+// @Accept			json
+// @Produce		json
+// @Security BearerAuth
+// @Success		200 {object} []response_models.GetDropNotificationResponse
+// @Failure		500
+// @Router			/admin/drops/scheduled [get]
+func AdminGetScheduledDrops(c *gin.Context) {
+	dropNotificationService := dropnotificationservice.DropNotificationService{
+		Repo: repositories.Setup(),
+	}
+
+	dropNotifications, err := dropNotificationService.GetUpcomingDrops()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	dropNotificationsResponse := make([]response_models.GetDropNotificationResponse, 0, len(dropNotifications))
+	for _, dropNotifModel := range dropNotifications {
+		dropNotificationsResponse = append(dropNotificationsResponse, response_models.FormatGetDropNotificationResponse(dropNotifModel))
+	}
+
+	c.JSON(http.StatusOK, dropNotificationsResponse)
+}
+
+// AdminRescheduleDrop godoc
+//
+// @Summary		Reschedule drop
+// @Description	Change the time or the type of an upcoming drop by admin user
+// @Tags			admin
+// @Accept			json
+// @Produce		json
+// @Security BearerAuth
+// @Param			id path string true "Drop notification ID"
+// @Param		params	body model.RescheduleDropParam true "Reschedule drop data"
+// @Success		200 {object} response_models.GetDropNotificationResponse
+// @Failure		400 "Invalid drop notification ID"
+// @Failure		404 "Scheduled drop not found"
+// @Failure		422 {object} errors2.MultiFieldsError
+// @Failure		500
+// @Router			/admin/drops/scheduled/{id} [patch]
+func AdminRescheduleDrop(c *gin.Context) {
+	dropNotificationId, err := converters.StringToUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid drop notification ID"})
+		return
+	}
+
+	var rescheduleDropParam model.RescheduleDropParam
+	if err := c.ShouldBindJSON(&rescheduleDropParam); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	dropNotificationService := dropnotificationservice.DropNotificationService{
+		Repo: repositories.Setup(),
+	}
+
+	dropNotifModel, err := dropNotificationService.RescheduleDrop(dropNotificationId, rescheduleDropParam)
+	if err != nil {
+		var multiFieldsErr errors2.MultiFieldsError
+		if errors.As(err, &multiFieldsErr) {
+			c.JSON(http.StatusUnprocessableEntity, multiFieldsErr)
+			return
+		}
+		var notFoundErr errors2.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			c.JSON(http.StatusNotFound, gin.H{"error": notFoundErr.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response_models.FormatGetDropNotificationResponse(dropNotifModel))
+}
+
+// AdminCancelScheduledDrop godoc
+//
+// @Summary		Cancel scheduled drop
+// @Description	Cancel an upcoming drop by admin user
+// @Tags			admin
+// @Accept			json
+// @Produce		json
+// @Security BearerAuth
+// @Param			id path string true "Drop notification ID"
+// @Success		204
+// @Failure		400 "Invalid drop notification ID"
+// @Failure		404 "Scheduled drop not found"
+// @Failure		500
+// @Router			/admin/drops/scheduled/{id} [delete]
+func AdminCancelScheduledDrop(c *gin.Context) {
+	dropNotificationId, err := converters.StringToUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid drop notification ID"})
+		return
+	}
+
+	dropNotificationService := dropnotificationservice.DropNotificationService{
+		Repo: repositories.Setup(),
+	}
+
+	if err := dropNotificationService.CancelDrop(dropNotificationId); err != nil {
+		var notFoundErr errors2.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			c.JSON(http.StatusNotFound, gin.H{"error": notFoundErr.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
 // AdminSendDropNow godoc
 //
 // @Summary		Send drop now
@@ -575,9 +725,9 @@ func AdminScheduleDrop(c *gin.Context) {
 // @Accept			json
 // @Produce		json
 // @Security BearerAuth
-// @Param			params	body model.ScheduleDropParam true "Send drop data"
+// @Param			params	body model.ScheduleDropParam true "Send drop data, the response window defaults to DROP_RESPONSE_WINDOW_MINUTES when omitted"
 // @Success		201 {object} response_models.GetDropNotificationResponse
-// @Failure		422 "Invalid drop data"
+// @Failure		422 "Invalid drop data or negative response window"
 // @Failure		500
 // @Router			/admin/drops/send-now [post]
 func AdminSendDropNow(c *gin.Context) {
@@ -592,18 +742,61 @@ func AdminSendDropNow(c *gin.Context) {
 	}
 
 	dropType := strings.ToLower(scheduleDropParam.Type)
-	dropNotifModel, err := dnr.Create(dropType)
+	if !drop_type_apis.IsValidDropType(dropType) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid drop type"})
+		return
+	}
+
+	kind := strings.ToLower(scheduleDropParam.Kind)
+	if !drop_type_apis.IsValidDropKind(dropType, kind) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid kind for this drop type"})
+		return
+	}
+
+	responseWindow := scheduleDropParam.ResponseWindow
+	if responseWindow < 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Response window must be a positive number of minutes"})
+		return
+	}
+	if responseWindow == 0 {
+		responseWindow = dropnotificationservice.DefaultResponseWindow()
+	}
+
+	dropNotifModel, err := dnr.Create(dropType, kind, responseWindow)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	RefreshHasUserDroppedToday()
+	BroadcastDropNotification(dropNotifModel)
 
-	pushNotificationService := pushnotificationservice.PushNotificationService{
-		Repo: repositories.Setup(),
+	c.JSON(http.StatusCreated, response_models.FormatGetDropNotificationResponse(dropNotifModel))
+}
+
+// AdminGetLoginLockouts godoc
+//
+// @Summary		Get login lockouts
+// @Description	Get the latest accounts and IP addresses locked out after too many failed logins by admin user
+// @Tags			admin
+// @Accept			json
+// @Produce		json
+// @Security BearerAuth
+// @Param			limit query int false "Number of lockouts, 50 by default"
+// @Success		200 {object} []response_models.GetLoginLockoutResponse
+// @Failure		500
+// @Router			/admin/login-lockouts [get]
+func AdminGetLoginLockouts(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	lockouts, err := LoginThrottle.GetLockouts(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	pushNotificationService.SendNotificationsToAllUser(scheduleDropParam.Type)
+	lockoutsResponse := make([]response_models.GetLoginLockoutResponse, 0, len(lockouts))
+	for _, lockout := range lockouts {
+		lockoutsResponse = append(lockoutsResponse, response_models.FormatGetLoginLockoutResponse(lockout))
+	}
 
-	c.JSON(http.StatusCreated, response_models.FormatGetDropNotificationResponse(dropNotifModel))
+	c.JSON(http.StatusOK, lockoutsResponse)
 }
@@ -1,15 +1,29 @@
 package controllers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
+	"go-api/internal/http/response_models"
 	"go-api/internal/repositories"
 	"go-api/internal/services/account"
-	"go-api/pkg/jwt_helper"
+	loginthrottle "go-api/internal/services/login_throttle"
+	"go-api/pkg/converters"
+	"go-api/pkg/errors2"
+	"go-api/pkg/mailer"
 	"go-api/pkg/model"
+	accountiface "go-api/pkg/services/account"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 )
 
+// Mailer sends the account emails, main replaces it with the configured one
+var Mailer mailer.Mailer = &mailer.LogMailer{}
+
+// LoginThrottle tracks the failed logins, main replaces it once the configured store is available
+var LoginThrottle = &loginthrottle.LoginThrottle{Store: loginthrottle.NewMemoryStore()}
+
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
@@ -41,15 +55,12 @@ func RefreshToken(c *gin.Context) {
 		return
 	}
 
-	token, err := jwt_helper.VerifyToken(refreshToken.RefreshToken)
+	tokenInfo, err := acc.LoginFromRefreshToken(refreshToken.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
-
-	tokenInfo, err := acc.LoginFromRefreshToken(token.Raw)
-	if err != nil {
-		if err.Error() == "refresh token not found" {
+		if errors.Is(err, account.ErrRefreshTokenNotFound) ||
+			errors.Is(err, account.ErrRefreshTokenExpired) ||
+			errors.Is(err, account.ErrRefreshTokenReused) ||
+			errors.Is(err, account.ErrSessionRevoked) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
@@ -68,13 +79,18 @@ func RefreshToken(c *gin.Context) {
 //	@Accept			json
 //	@Produce		json
 //	@Param			login body		model.LoginParam	true	"Login object"
-//	@Success		200	{object} account.TokenInfo
+//	@Success		200	{object} account.TokenInfo "Tokens of the new session, or twoFactorToken to send to /auth/2fa when twoFactorRequired is set"
+//	@Failure		403 "Email not verified"
 //	@Failure		422 "Invalid email or password"
+//	@Failure		429 "Too many failed attempts"
 //	@Failure		500
 //	@Router			/auth [post]
 func Login(c *gin.Context) {
 	acc := &account.AccountService{
-		Repo: repositories.Setup(),
+		Repo:     repositories.Setup(),
+		Device:   getDeviceInfo(c),
+		Mailer:   Mailer,
+		Throttle: LoginThrottle,
 	}
 	var loginParam model.LoginParam
 
@@ -84,15 +100,38 @@ func Login(c *gin.Context) {
 	}
 
 	tokenInfo, err := acc.Login(loginParam.Email, loginParam.Password, loginParam.FcmToken)
+	if handleTooManyAttempts(c, err) {
+		return
+	}
+	if errors.Is(err, account.ErrEmailNotVerified) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Email not verified"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid email or password"})
 		return
 	}
-	log.Println("Info: Login success with token " + tokenInfo.JWTToken)
+	if tokenInfo.TwoFactorRequired {
+		log.Println("Info: Login waiting for the second factor")
+	} else {
+		log.Println("Info: Login success with token " + tokenInfo.JWTToken)
+	}
 
 	c.JSON(http.StatusOK, tokenInfo)
 }
 
+// handleTooManyAttempts answers 429 with a Retry-After header when the login is throttled
+func handleTooManyAttempts(c *gin.Context, err error) bool {
+	var tooManyAttemptsErr errors2.TooManyAttemptsError
+	if !errors.As(err, &tooManyAttemptsErr) {
+		return false
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(tooManyAttemptsErr.RetryAfter.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": tooManyAttemptsErr.Error()})
+	return true
+}
+
 type FirebaseToken struct {
 	IDToken string `json:"id_token"`
 }
@@ -123,7 +162,8 @@ func FirebaseLogin(c *gin.Context) {
 	}
 
 	acc := &account.AccountService{
-		Repo: repositories.Setup(),
+		Repo:   repositories.Setup(),
+		Device: getDeviceInfo(c),
 	}
 
 	tokenInfo, err := acc.LoginWithFirebase(token.IDToken, c)
@@ -135,3 +175,364 @@ func FirebaseLogin(c *gin.Context) {
 
 	c.JSON(http.StatusOK, tokenInfo)
 }
+
+// GetSessions godoc
+//
+//	@Summary		Get sessions
+//	@Description	get the devices the current user is logged in on
+//	@Tags			auth
+//	@Produce		json
+//	@Security BearerAuth
+//	@Success		200	{object} []response_models.GetSessionResponse
+//	@Failure		401
+//	@Failure		500
+//	@Router			/auth/sessions [get]
+func GetSessions(c *gin.Context) {
+	uintCurrentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	acc := &account.AccountService{
+		Repo: repositories.Setup(),
+	}
+
+	sessions, err := acc.GetSessions(uintCurrentUserId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	currentSessionId := c.GetUint("sessionId")
+	sessionsResponse := make([]response_models.GetSessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		sessionsResponse = append(sessionsResponse, response_models.FormatGetSessionResponse(session, currentSessionId))
+	}
+
+	c.JSON(http.StatusOK, sessionsResponse)
+}
+
+// RevokeSession godoc
+//
+//	@Summary		Log out a session
+//	@Description	revoke one of the current user's sessions, its refresh token can't be used anymore
+//	@Tags			auth
+//	@Produce		json
+//	@Security BearerAuth
+//	@Param			id path int true "Session ID"
+//	@Success		204
+//	@Failure		400
+//	@Failure		401
+//	@Failure		404
+//	@Failure		500
+//	@Router			/auth/sessions/{id} [delete]
+func RevokeSession(c *gin.Context) {
+	uintCurrentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	sessionId, err := converters.StringToUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	revokeSession(c, uintCurrentUserId, sessionId)
+}
+
+// Logout godoc
+//
+//	@Summary		Logout
+//	@Description	revoke the session of the access token
+//	@Tags			auth
+//	@Produce		json
+//	@Security BearerAuth
+//	@Success		204
+//	@Failure		401
+//	@Failure		404
+//	@Failure		500
+//	@Router			/auth/logout [post]
+func Logout(c *gin.Context) {
+	uintCurrentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	revokeSession(c, uintCurrentUserId, c.GetUint("sessionId"))
+}
+
+// RevokeAllSessions godoc
+//
+//	@Summary		Log out everywhere
+//	@Description	revoke every session of the current user
+//	@Tags			auth
+//	@Produce		json
+//	@Security BearerAuth
+//	@Success		204
+//	@Failure		401
+//	@Failure		500
+//	@Router			/auth/sessions [delete]
+func RevokeAllSessions(c *gin.Context) {
+	uintCurrentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	acc := &account.AccountService{
+		Repo: repositories.Setup(),
+	}
+
+	if err := acc.RevokeAllSessions(uintCurrentUserId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func revokeSession(c *gin.Context, userId uint, sessionId uint) {
+	acc := &account.AccountService{
+		Repo: repositories.Setup(),
+	}
+
+	if err := acc.RevokeSession(userId, sessionId); err != nil {
+		var notFoundErr errors2.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			c.JSON(http.StatusNotFound, gin.H{"error": notFoundErr.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func getCurrentUserId(c *gin.Context) (uint, bool) {
+	currentUserId, exists := c.Get("userId")
+
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return 0, false
+	}
+	uintCurrentUserId, ok := currentUserId.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return 0, false
+	}
+
+	return uintCurrentUserId, true
+}
+
+// getDeviceInfo names the session with the X-Device-Name header sent by the apps, or the user agent
+func getDeviceInfo(c *gin.Context) accountiface.DeviceInfo {
+	name := c.GetHeader("X-Device-Name")
+	if name == "" {
+		name = c.Request.UserAgent()
+	}
+
+	return accountiface.DeviceInfo{Name: name, IPAddress: c.ClientIP()}
+}
+
+// VerifyEmail godoc
+//
+//	@Summary		Verify email
+//	@Description	activate the account with the token sent by email at signup
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			token body		model.EmailVerificationParam	true	"Verification token"
+//	@Success		204
+//	@Failure		400
+//	@Failure		422 "Invalid or expired token"
+//	@Failure		500
+//	@Router			/auth/verify-email [post]
+func VerifyEmail(c *gin.Context) {
+	var verificationParam model.EmailVerificationParam
+
+	if err := c.ShouldBindJSON(&verificationParam); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	acc := &account.AccountService{
+		Repo: repositories.Setup(),
+	}
+
+	if err := acc.VerifyEmail(verificationParam.Token); err != nil {
+		handleAccountEmailError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ResendEmailVerification godoc
+//
+//	@Summary		Resend verification email
+//	@Description	send a new verification link, the answer is the same whether the email is known or not, or when too many emails were asked for
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			email body		model.EmailParam	true	"Email of the account"
+//	@Success		204
+//	@Failure		400
+//	@Failure		500
+//	@Router			/auth/verify-email/resend [post]
+func ResendEmailVerification(c *gin.Context) {
+	var emailParam model.EmailParam
+
+	if err := c.ShouldBindJSON(&emailParam); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	acc := &account.AccountService{
+		Repo:     repositories.Setup(),
+		Device:   getDeviceInfo(c),
+		Mailer:   Mailer,
+		Throttle: LoginThrottle,
+	}
+
+	if err := acc.ResendEmailVerification(emailParam.Email); err != nil {
+		log.Printf("Error: Error sending verification email: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Email not sent"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RequestPasswordReset godoc
+//
+//	@Summary		Request password reset
+//	@Description	email a password reset link, the answer is the same whether the email is known or not, or when too many emails were asked for
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			email body		model.EmailParam	true	"Email of the account"
+//	@Success		204
+//	@Failure		400
+//	@Failure		500
+//	@Router			/auth/password-reset [post]
+func RequestPasswordReset(c *gin.Context) {
+	var emailParam model.EmailParam
+
+	if err := c.ShouldBindJSON(&emailParam); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	acc := &account.AccountService{
+		Repo:     repositories.Setup(),
+		Device:   getDeviceInfo(c),
+		Mailer:   Mailer,
+		Throttle: LoginThrottle,
+	}
+
+	if err := acc.RequestPasswordReset(emailParam.Email); err != nil {
+		log.Printf("Error: Error sending password reset email: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Email not sent"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ResetPassword godoc
+//
+//	@Summary		Reset password
+//	@Description	set a new password with the token sent by email, every session of the user is logged out
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			reset body		model.PasswordResetParam	true	"Reset token and new password"
+//	@Success		204
+//	@Failure		400
+//	@Failure		422
+//	@Failure		500
+//	@Router			/auth/password-reset/confirm [post]
+func ResetPassword(c *gin.Context) {
+	var resetParam model.PasswordResetParam
+
+	if err := c.ShouldBindJSON(&resetParam); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	acc := &account.AccountService{
+		Repo: repositories.Setup(),
+	}
+
+	if err := acc.ResetPassword(resetParam.Token, resetParam.Password); err != nil {
+		handleAccountEmailError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ChangePassword godoc
+//
+//	@Summary		Change password
+//	@Description	replace the password of the current user, their other sessions are logged out
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Security BearerAuth
+//	@Param			password body		model.PasswordChangeParam	true	"Current and new passwords"
+//	@Success		204
+//	@Failure		400
+//	@Failure		401
+//	@Failure		403
+//	@Failure		422
+//	@Failure		500
+//	@Router			/auth/password [put]
+func ChangePassword(c *gin.Context) {
+	uintCurrentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	var changeParam model.PasswordChangeParam
+
+	if err := c.ShouldBindJSON(&changeParam); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	acc := &account.AccountService{
+		Repo: repositories.Setup(),
+	}
+
+	if err := acc.ChangePassword(uintCurrentUserId, c.GetUint("sessionId"), changeParam.CurrentPassword, changeParam.NewPassword); err != nil {
+		handleAccountEmailError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func handleAccountEmailError(c *gin.Context, err error) {
+	if errors.Is(err, account.ErrInvalidEmailToken) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	var multiFieldsErr errors2.MultiFieldsError
+	if errors.As(err, &multiFieldsErr) {
+		c.JSON(http.StatusUnprocessableEntity, multiFieldsErr)
+		return
+	}
+	var notAllowedErr errors2.NotAllowedError
+	if errors.As(err, &notAllowedErr) {
+		c.JSON(http.StatusForbidden, gin.H{"error": notAllowedErr.Reason})
+		return
+	}
+	var notFoundErr errors2.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		c.JSON(http.StatusNotFound, gin.H{"error": notFoundErr.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,276 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go-api/internal/http/response_models"
+	"go-api/internal/repositories"
+	blockservice "go-api/internal/services/block"
+	"go-api/pkg/converters"
+	"go-api/pkg/errors2"
+	"log"
+	"net/http"
+)
+
+// BlockUser godoc
+//
+// @Summary		Block user
+// @Description	Block a user: follows between both users are removed, and they can no longer follow, comment or like each other's drops
+// @Tags			user
+// @Accept			json
+// @Produce		json
+// @Param			id path int true "User ID"
+// @Security BearerAuth
+// @Success		201	{object} response_models.GetRestrictedUserResponse
+// @Failure		400
+// @Failure		401
+// @Failure		403
+// @Failure		404
+// @Failure		500
+// @Router			/users/{id}/block [post]
+func BlockUser(c *gin.Context) {
+	currentUserId, targetUserId, ok := getRestrictionTarget(c)
+	if !ok {
+		return
+	}
+
+	bs := &blockservice.BlockService{
+		Repo: repositories.Setup(),
+	}
+
+	block, err := bs.BlockUser(currentUserId, targetUserId)
+
+	if err != nil {
+		handleRestrictionError(c, err)
+		return
+	}
+
+	log.Printf("Info: User %d blocked user %d\n", currentUserId, targetUserId)
+	c.JSON(http.StatusCreated, response_models.FormatGetBlockResponse(block))
+}
+
+// UnblockUser godoc
+//
+// @Summary		Unblock user
+// @Description	Unblock a user, previous follows are not restored
+// @Tags			user
+// @Accept			json
+// @Produce		json
+// @Param			id path int true "User ID"
+// @Security BearerAuth
+// @Success		204 No Content
+// @Failure		400
+// @Failure		401
+// @Failure		500
+// @Router			/users/{id}/block [delete]
+func UnblockUser(c *gin.Context) {
+	currentUserId, targetUserId, ok := getRestrictionTarget(c)
+	if !ok {
+		return
+	}
+
+	bs := &blockservice.BlockService{
+		Repo: repositories.Setup(),
+	}
+
+	if err := bs.UnblockUser(currentUserId, targetUserId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetBlockedUsers godoc
+//
+// @Summary		Get blocked users
+// @Description	Get the users blocked by the current user
+// @Tags			user
+// @Accept			json
+// @Produce		json
+// @Security BearerAuth
+// @Success		200	{object} []response_models.GetRestrictedUserResponse
+// @Failure		401
+// @Failure		500
+// @Router			/users/blocked [get]
+func GetBlockedUsers(c *gin.Context) {
+	currentUserId, exists := c.Get("userId")
+
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	uintCurrentUserId, ok := currentUserId.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	bs := &blockservice.BlockService{
+		Repo: repositories.Setup(),
+	}
+
+	blocks, err := bs.GetBlockedUsers(uintCurrentUserId)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	blocksResponse := make([]response_models.GetRestrictedUserResponse, 0, len(blocks))
+	for _, block := range blocks {
+		blocksResponse = append(blocksResponse, response_models.FormatGetBlockResponse(block))
+	}
+
+	c.JSON(http.StatusOK, blocksResponse)
+}
+
+// MuteUser godoc
+//
+// @Summary		Mute user
+// @Description	Mute a user: their drops, comments and responses are hidden from the current user, follows are kept
+// @Tags			user
+// @Accept			json
+// @Produce		json
+// @Param			id path int true "User ID"
+// @Security BearerAuth
+// @Success		201	{object} response_models.GetRestrictedUserResponse
+// @Failure		400
+// @Failure		401
+// @Failure		403
+// @Failure		404
+// @Failure		500
+// @Router			/users/{id}/mute [post]
+func MuteUser(c *gin.Context) {
+	currentUserId, targetUserId, ok := getRestrictionTarget(c)
+	if !ok {
+		return
+	}
+
+	bs := &blockservice.BlockService{
+		Repo: repositories.Setup(),
+	}
+
+	mute, err := bs.MuteUser(currentUserId, targetUserId)
+
+	if err != nil {
+		handleRestrictionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response_models.FormatGetMuteResponse(mute))
+}
+
+// UnmuteUser godoc
+//
+// @Summary		Unmute user
+// @Description	Unmute a user
+// @Tags			user
+// @Accept			json
+// @Produce		json
+// @Param			id path int true "User ID"
+// @Security BearerAuth
+// @Success		204 No Content
+// @Failure		400
+// @Failure		401
+// @Failure		500
+// @Router			/users/{id}/mute [delete]
+func UnmuteUser(c *gin.Context) {
+	currentUserId, targetUserId, ok := getRestrictionTarget(c)
+	if !ok {
+		return
+	}
+
+	bs := &blockservice.BlockService{
+		Repo: repositories.Setup(),
+	}
+
+	if err := bs.UnmuteUser(currentUserId, targetUserId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetMutedUsers godoc
+//
+// @Summary		Get muted users
+// @Description	Get the users muted by the current user
+// @Tags			user
+// @Accept			json
+// @Produce		json
+// @Security BearerAuth
+// @Success		200	{object} []response_models.GetRestrictedUserResponse
+// @Failure		401
+// @Failure		500
+// @Router			/users/muted [get]
+func GetMutedUsers(c *gin.Context) {
+	currentUserId, exists := c.Get("userId")
+
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	uintCurrentUserId, ok := currentUserId.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	bs := &blockservice.BlockService{
+		Repo: repositories.Setup(),
+	}
+
+	mutes, err := bs.GetMutedUsers(uintCurrentUserId)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	mutesResponse := make([]response_models.GetRestrictedUserResponse, 0, len(mutes))
+	for _, mute := range mutes {
+		mutesResponse = append(mutesResponse, response_models.FormatGetMuteResponse(mute))
+	}
+
+	c.JSON(http.StatusOK, mutesResponse)
+}
+
+// getRestrictionTarget reads the current user and the user of the path, writing the error response when one is missing
+func getRestrictionTarget(c *gin.Context) (uint, uint, bool) {
+	currentUserId, exists := c.Get("userId")
+
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return 0, 0, false
+	}
+	uintCurrentUserId, ok := currentUserId.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return 0, 0, false
+	}
+
+	targetUserId, err := converters.StringToUint(c.Param("id"))
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return 0, 0, false
+	}
+
+	return uintCurrentUserId, targetUserId, true
+}
+
+func handleRestrictionError(c *gin.Context, err error) {
+	var notAllowedErr errors2.NotAllowedError
+	if errors.As(err, &notAllowedErr) {
+		c.JSON(http.StatusForbidden, gin.H{"error": notAllowedErr.Reason})
+		return
+	}
+	var notFoundErr errors2.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		c.JSON(http.StatusNotFound, gin.H{"error": notFoundErr.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
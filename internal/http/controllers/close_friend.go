@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-api/internal/http/response_models"
+	"go-api/internal/repositories"
+	closefriendservice "go-api/internal/services/close_friend"
+	"go-api/pkg/converters"
+	"go-api/pkg/model"
+	"net/http"
+)
+
+// GetCloseFriends godoc
+//
+// @Summary		Get close friends
+// @Description	Get the close friends of the current user, who can see the drops shared with close friends only
+// @Tags			user
+// @Accept			json
+// @Produce		json
+// @Security BearerAuth
+// @Success		200	{object} []response_models.GetCloseFriendResponse
+// @Failure		401
+// @Failure		500
+// @Router			/users/close-friends [get]
+func GetCloseFriends(c *gin.Context) {
+	currentUserId, exists := c.Get("userId")
+
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	uintCurrentUserId, ok := currentUserId.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	cfs := &closefriendservice.CloseFriendService{
+		Repo: repositories.Setup(),
+	}
+
+	closeFriends, err := cfs.GetCloseFriends(uintCurrentUserId)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	closeFriendsResponse := make([]response_models.GetCloseFriendResponse, 0, len(closeFriends))
+	for _, closeFriend := range closeFriends {
+		closeFriendsResponse = append(closeFriendsResponse, response_models.FormatGetCloseFriendResponse(closeFriend))
+	}
+
+	c.JSON(http.StatusOK, closeFriendsResponse)
+}
+
+// AddCloseFriend godoc
+//
+// @Summary		Add close friend
+// @Description	Add one of the current user's followers to their close friends
+// @Tags			user
+// @Accept			json
+// @Produce		json
+// @Security BearerAuth
+// @Param			closeFriend	body		model.CloseFriendCreationParam	true	"Close friend creation object"
+// @Success		201	{object} response_models.GetCloseFriendResponse
+// @Failure		400
+// @Failure		401
+// @Failure		403
+// @Failure		404
+// @Failure		500
+// @Router			/users/close-friends [post]
+func AddCloseFriend(c *gin.Context) {
+	currentUserId, exists := c.Get("userId")
+
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	uintCurrentUserId, ok := currentUserId.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var closeFriendCreationParam model.CloseFriendCreationParam
+
+	if err := c.ShouldBindJSON(&closeFriendCreationParam); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cfs := &closefriendservice.CloseFriendService{
+		Repo: repositories.Setup(),
+	}
+
+	closeFriend, err := cfs.AddCloseFriend(uintCurrentUserId, closeFriendCreationParam.UserID)
+
+	if err != nil {
+		handleRestrictionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response_models.FormatGetCloseFriendResponse(closeFriend))
+}
+
+// RemoveCloseFriend godoc
+//
+// @Summary		Remove close friend
+// @Description	Remove a user from the current user's close friends
+// @Tags			user
+// @Accept			json
+// @Produce		json
+// @Security BearerAuth
+// @Param			id path int true "User ID"
+// @Success		204 No Content
+// @Failure		400
+// @Failure		401
+// @Failure		500
+// @Router			/users/close-friends/{id} [delete]
+func RemoveCloseFriend(c *gin.Context) {
+	currentUserId, exists := c.Get("userId")
+
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	uintCurrentUserId, ok := currentUserId.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	friendId, err := converters.StringToUint(c.Param("id"))
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	cfs := &closefriendservice.CloseFriendService{
+		Repo: repositories.Setup(),
+	}
+
+	if err := cfs.RemoveCloseFriend(uintCurrentUserId, friendId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
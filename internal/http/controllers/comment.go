@@ -1,11 +1,13 @@
 package controllers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"go-api/internal/http/response_models"
 	"go-api/internal/repositories"
 	commentservice "go-api/internal/services/comment"
 	pushnotificationservice "go-api/internal/services/push_notification"
+	"go-api/pkg/errors2"
 	"go-api/pkg/model"
 	"log"
 	"net/http"
@@ -26,6 +28,8 @@ import (
 //	@Param			comment	body		model.CommentCreationParam	true	"Comment creation object"
 //	@Success		201	{object} response_models.GetCommentResponse
 //	@Failure		401
+//	@Failure		403
+//	@Failure		404
 //	@Failure		422 {object} errors2.MultiFieldsError
 //	@Router			/drops/{id}/comments [post]
 func CommentDrop(c *gin.Context) {
@@ -69,6 +73,16 @@ func CommentDrop(c *gin.Context) {
 	comment, err := cs.CommentDrop(uint(dropIdUint), uintCurrentUserId, commentCreationParam)
 
 	if err != nil {
+		var notAllowedErr errors2.NotAllowedError
+		if errors.As(err, &notAllowedErr) {
+			c.JSON(http.StatusForbidden, gin.H{"error": notAllowedErr.Reason})
+			return
+		}
+		var notFoundErr errors2.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			c.JSON(http.StatusNotFound, gin.H{"error": notFoundErr.Error()})
+			return
+		}
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 		return
 	}
@@ -97,17 +111,7 @@ func CommentDrop(c *gin.Context) {
 		}
 	}
 
-	followers, err := cs.Repo.FollowRepository.GetFollowers(drop.GetCreatedById())
-
-	if err != nil {
-		return
-	}
-
-	for _, follower := range followers {
-		_ = NewDropAvailable(follower.GetFollowerID(), drop)
-	}
-
-	_ = NewDropAvailable(drop.GetCreatedById(), drop)
+	go NewDropAvailableToFollowers(drop, true)
 }
 
 // DeleteComment godoc
@@ -179,15 +183,5 @@ func DeleteComment(c *gin.Context) {
 		return
 	}
 
-	followers, err := cs.Repo.FollowRepository.GetFollowers(drop.GetCreatedById())
-
-	if err != nil {
-		return
-	}
-
-	for _, follower := range followers {
-		_ = NewDropAvailable(follower.GetFollowerID(), drop)
-	}
-
-	_ = NewDropAvailable(drop.GetCreatedById(), drop)
+	go NewDropAvailableToFollowers(drop, true)
 }
@@ -1,10 +1,12 @@
 package controllers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"go-api/internal/http/response_models"
 	"go-api/internal/repositories"
 	commentresponseservice "go-api/internal/services/comment_response"
+	"go-api/pkg/errors2"
 	"go-api/pkg/model"
 	"log"
 	"net/http"
@@ -25,6 +27,8 @@ import (
 //	@Param			response	body		model.CommentCreationParam	true	"Comment creation object"
 //	@Success		201	{object} response_models.GetCommentResponseResponse
 //	@Failure		401
+//	@Failure		403
+//	@Failure		404
 //	@Failure		422 {object} errors2.MultiFieldsError
 //	@Router			/comments/{id}/responses [post]
 func RespondToComment(c *gin.Context) {
@@ -69,6 +73,16 @@ func RespondToComment(c *gin.Context) {
 	commentResponse, err := cs.RespondToComment(uint(commentIdUint), uintCurrentUserId, commentResponseCreationParam)
 
 	if err != nil {
+		var notAllowedErr errors2.NotAllowedError
+		if errors.As(err, &notAllowedErr) {
+			c.JSON(http.StatusForbidden, gin.H{"error": notAllowedErr.Reason})
+			return
+		}
+		var notFoundErr errors2.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			c.JSON(http.StatusNotFound, gin.H{"error": notFoundErr.Error()})
+			return
+		}
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 		return
 	}
@@ -90,18 +104,7 @@ func RespondToComment(c *gin.Context) {
 		return
 	}
 
-	followers, err := cs.Repo.FollowRepository.GetFollowers(drop.GetCreatedById())
-
-	if err != nil {
-		log.Printf("Error getting followers: %v", err)
-		return
-	}
-
-	for _, follower := range followers {
-		_ = NewDropAvailable(follower.GetFollowerID(), drop)
-	}
-
-	_ = NewDropAvailable(drop.GetCreatedById(), drop)
+	go NewDropAvailableToFollowers(drop, true)
 }
 
 // DeleteCommentResponse godoc
@@ -176,16 +179,5 @@ func DeleteCommentResponse(c *gin.Context) {
 
 	drop := comment.GetDrop()
 
-	followers, err := cs.Repo.FollowRepository.GetFollowers(drop.GetCreatedById())
-
-	if err != nil {
-		log.Printf("Error getting followers: %v", err)
-		return
-	}
-
-	for _, follower := range followers {
-		_ = NewDropAvailable(follower.GetFollowerID(), drop)
-	}
-
-	_ = NewDropAvailable(drop.GetCreatedById(), drop)
+	go NewDropAvailableToFollowers(drop, true)
 }
@@ -1,20 +1,24 @@
 package controllers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/gorilla/websocket"
 	"go-api/internal/http/response_models"
+	"go-api/internal/realtime"
 	"go-api/internal/repositories"
 	dropservice "go-api/internal/services/drop"
+	pushnotificationservice "go-api/internal/services/push_notification"
+	"go-api/internal/services/visibility"
 	"go-api/internal/storage/postgres"
 	"go-api/pkg/converters"
 	"go-api/pkg/drop_type_apis"
+	"go-api/pkg/errors2"
 	"go-api/pkg/model"
 	"log"
 	"net/http"
 	"strconv"
-	"sync"
 )
 
 // CreateDrop godoc
@@ -31,6 +35,7 @@ import (
 //	@Success		201	{object} response_models.GetDropResponse
 //	@Failure		401
 //	@Failure		422
+//	@Failure		429 "Too many lookups on the provider"
 //	@Router			/drops [post]
 func CreateDrop(c *gin.Context) {
 	var dropCreationParam model.DropCreationParam
@@ -59,6 +64,11 @@ func CreateDrop(c *gin.Context) {
 	createdDrop, err := ds.CreateDrop(uintCurrentUserId, dropCreationParam)
 
 	if err != nil {
+		var rateLimitedErr errors2.RateLimitedError
+		if errors.As(err, &rateLimitedErr) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 		return
 	}
@@ -67,30 +77,12 @@ func CreateDrop(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, response)
 
-	mu.Lock()
-	wsConn, ok := hasUserDroppedTodayConnections[strconv.Itoa(int(uintCurrentUserId))]
-	mu.Unlock()
-	if ok {
-		err := wsConn.conn.WriteJSON(response_models.HasUserDroppedTodayResponse{Status: true})
-		if err != nil {
-			log.Printf("Error: Error sending message to user %d: %v", uintCurrentUserId, err)
-		}
-	}
-
-	sqlDB := postgres.Connect()
-
-	fr := postgres.NewFollowRepo(sqlDB)
-
-	userFollowers, err := fr.GetFollowers(uintCurrentUserId)
-
+	err = RealtimeHub.Publish(realtime.ChannelHasDropped, uintCurrentUserId, response_models.HasUserDroppedTodayResponse{Status: true})
 	if err != nil {
-		return
+		log.Printf("Error: Error sending message to user %d: %v", uintCurrentUserId, err)
 	}
 
-	log.Printf("Info: Sending new drop to followers %v\n", userFollowers)
-	for _, follower := range userFollowers {
-		_ = NewDropAvailable(follower.GetFollowerID(), createdDrop)
-	}
+	go NewDropAvailableToFollowers(createdDrop, false)
 
 	drops, err := ds.GetUserFeed(uintCurrentUserId)
 
@@ -140,6 +132,16 @@ func GetOneDrop(c *gin.Context) {
 	drop, err := ds.GetDropById(dropId, uintCurrentUserId)
 
 	if err != nil {
+		var notFoundErr errors2.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			c.JSON(http.StatusNotFound, gin.H{"error": notFoundErr.Error()})
+			return
+		}
+		var notAllowedErr errors2.NotAllowedError
+		if errors.As(err, &notAllowedErr) {
+			c.JSON(http.StatusForbidden, gin.H{"error": notAllowedErr.Reason})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -165,6 +167,7 @@ func GetOneDrop(c *gin.Context) {
 //	@Produce		json
 //	@Param			id path int true "User ID"
 //	@Success		200	{object} []response_models.GetDropResponse
+//	@Failure		404
 //	@Failure		500
 //	@Router			/users/:id/drops [get]
 func DropsByUserId(c *gin.Context) {
@@ -210,6 +213,11 @@ func DropsByUserId(c *gin.Context) {
 	drops, err := ds.GetDropsByUserId(userId, currentUser)
 
 	if err != nil {
+		var notFoundErr errors2.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			c.JSON(http.StatusNotFound, gin.H{"error": notFoundErr.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -269,6 +277,136 @@ func GetCurrentUserFeed(c *gin.Context) {
 	c.JSON(200, drops)
 }
 
+// GetCurrentUserFeedHistory godoc
+//
+//	@Summary		Get feed history
+//	@Description	Get the drops of followed users across past drop notifications, newest first
+//	@Tags			drop
+//	@Accept			json
+//	@Produce		json
+//	@Security BearerAuth
+//	@Param			cursor query string false "NextCursor of the previous page"
+//	@Param			limit query int false "Page size, 20 by default and 50 at most"
+//	@Param			type query string false "Drop type"
+//	@Param			userId query int false "Only the drops of this followed user"
+//	@Success		200	{object} response_models.GetDropFeedPageResponse
+//	@Failure		401
+//	@Failure		403
+//	@Failure		422 {object} errors2.MultiFieldsError
+//	@Failure		500
+//	@Router			/users/my-feed/history [get]
+func GetCurrentUserFeedHistory(c *gin.Context) {
+	currentUserId, exists := c.Get("userId")
+
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	uintCurrentUserId, ok := currentUserId.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var feedHistoryParam model.FeedHistoryParam
+	if err := c.ShouldBindQuery(&feedHistoryParam); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	ds := &dropservice.DropService{
+		Repo: repositories.Setup(),
+	}
+
+	drops, nextCursor, err := ds.GetUserFeedHistory(uintCurrentUserId, feedHistoryParam)
+
+	if err != nil {
+		var multiFieldsErr errors2.MultiFieldsError
+		if errors.As(err, &multiFieldsErr) {
+			c.JSON(http.StatusUnprocessableEntity, multiFieldsErr)
+			return
+		}
+		var notAllowedErr errors2.NotAllowedError
+		if errors.As(err, &notAllowedErr) {
+			c.JSON(http.StatusForbidden, gin.H{"error": notAllowedErr.Reason})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := response_models.GetDropFeedPageResponse{
+		Drops:      []response_models.GetDropResponse{},
+		NextCursor: nextCursor,
+	}
+	for _, drop := range drops {
+		isCurrentUserLiking, err := ds.IsCurrentUserLiking(drop.GetID(), uintCurrentUserId)
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		response.Drops = append(response.Drops, response_models.FormatGetDropResponse(drop, isCurrentUserLiking))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetDiscoverFeed godoc
+//
+//	@Summary		Get discover feed
+//	@Description	Get the best ranked drops of the current notification from public users the current user does not follow, drops are only suggested once
+//	@Tags			drop
+//	@Accept			json
+//	@Produce		json
+//	@Security BearerAuth
+//	@Param			limit query int false "Number of drops, 20 by default and 50 at most"
+//	@Success		200	{object} []response_models.GetDropResponse
+//	@Failure		401
+//	@Failure		500
+//	@Router			/drops/discover [get]
+func GetDiscoverFeed(c *gin.Context) {
+	currentUserId, exists := c.Get("userId")
+
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	uintCurrentUserId, ok := currentUserId.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+	ds := &dropservice.DropService{
+		Repo: repositories.Setup(),
+	}
+
+	drops, err := ds.GetDiscoverFeed(uintCurrentUserId, limit)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	dropsResponse := []response_models.GetDropResponse{}
+	for _, drop := range drops {
+		isCurrentUserLiking, err := ds.IsCurrentUserLiking(drop.GetID(), uintCurrentUserId)
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		dropsResponse = append(dropsResponse, response_models.FormatGetDropResponse(drop, isCurrentUserLiking))
+	}
+
+	c.JSON(http.StatusOK, dropsResponse)
+}
+
 // Define the upgrader
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
@@ -276,15 +414,6 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-// Create a struct to manage WebSocket connections
-type WebSocketConnection struct {
-	conn *websocket.Conn
-}
-
-// Global map to store connections per user
-var userFeedConnections = make(map[string]*WebSocketConnection)
-var mu sync.Mutex
-
 func GetCurrentUserFeedWS(c *gin.Context) {
 	currentUserId, exists := c.Get("userId")
 
@@ -305,29 +434,38 @@ func GetCurrentUserFeedWS(c *gin.Context) {
 		return
 	}
 
-	wsConn := &WebSocketConnection{conn: conn}
+	client := RealtimeHub.Register(realtime.ChannelFeed, uintCurrentUserId, conn)
+	defer client.Run()
+
+	dropResponses, err := getUserFeedResponses(uintCurrentUserId)
+	if err != nil {
+		log.Printf("Error: Error getting user feed: %v", err)
+		return
+	}
 
-	mu.Lock()
-	userFeedConnections[strconv.Itoa(int(uintCurrentUserId))] = wsConn
-	mu.Unlock()
+	err = client.Send(dropResponses)
+	if err != nil {
+		log.Printf("Error: Error sending message to user %d: %v", uintCurrentUserId, err)
+	}
+}
 
-	log.Printf("Info: New user connected to drop feed: %v\n", uintCurrentUserId)
+// getUserFeedResponses is the initial state of the feed channel
+func getUserFeedResponses(userID uint) ([]response_models.GetDropResponse, error) {
 	ds := &dropservice.DropService{
 		Repo: repositories.Setup(),
 	}
 
-	/*hasDropped, err := ds.HasUserDroppedToday(uintCurrentUserId)
+	/*hasDropped, err := ds.HasUserDroppedToday(userID)
 
 	if err != nil {
 		log.Printf("Error checking if user has dropped today: %v", err)
 		return
 	}*/
 
-	availableDrops, err := ds.GetUserFeed(uintCurrentUserId)
+	availableDrops, err := ds.GetUserFeed(userID)
 
 	if err != nil {
-		log.Printf("Error: Error getting user feed: %v", err)
-		return
+		return nil, err
 	}
 
 	var dropResponses []response_models.GetDropResponse
@@ -335,66 +473,95 @@ func GetCurrentUserFeedWS(c *gin.Context) {
 		/*if !hasDropped {
 			//TODO ne pas envoyer la pic, le content et la description ( donc fair eun interface pour les 2 types de drop et déclarer une var au dessus de ce type là )
 		}*/
-		isCurrentUserLiking, err := ds.IsCurrentUserLiking(drop.GetID(), uintCurrentUserId)
+		isCurrentUserLiking, err := ds.IsCurrentUserLiking(drop.GetID(), userID)
 
 		if err != nil {
-			return
+			return nil, err
 		}
 
 		dropResponse := response_models.FormatGetDropResponse(drop, isCurrentUserLiking)
 		dropResponses = append(dropResponses, dropResponse)
 	}
 
-	err = wsConn.conn.WriteJSON(dropResponses)
+	return dropResponses, nil
+}
+
+// NewDropAvailable sends the drop to the feed stream of the user when they can see it
+func NewDropAvailable(userID uint, newDrop model.DropModel) error {
+	return NewDropAvailableToUsers([]uint{userID}, newDrop)
+}
+
+// NewDropAvailableToFollowers sends the drop to the followers of its author, and to the author when includeAuthor is set.
+// It is meant to run in its own goroutine so the fan-out stays off the request path.
+func NewDropAvailableToFollowers(newDrop model.DropModel, includeAuthor bool) {
+	fr := postgres.NewFollowRepo(postgres.Connect())
+
+	followers, err := fr.GetFollowers(newDrop.GetCreatedById())
 	if err != nil {
-		log.Printf("Error: Error sending message to user %d: %v", uintCurrentUserId, err)
+		log.Printf("Error: Error getting followers of user %d: %v", newDrop.GetCreatedById(), err)
 		return
 	}
 
-	defer func() {
-		mu.Lock()
-		delete(userFeedConnections, strconv.Itoa(int(uintCurrentUserId)))
-		mu.Unlock()
-		err := conn.Close()
-		if err != nil {
-			log.Printf("Error: Error closing WebSocket connection: %v", err)
-		}
-	}()
+	userIds := make([]uint, 0, len(followers)+1)
+	if includeAuthor {
+		userIds = append(userIds, newDrop.GetCreatedById())
+	}
+	for _, follower := range followers {
+		userIds = append(userIds, follower.GetFollowerID())
+	}
 
-	for {
-		_, _, err := conn.ReadMessage()
-		if err != nil {
-			break
-		}
+	log.Printf("Info: Sending drop %d to %d users\n", newDrop.GetID(), len(userIds))
+	if err := NewDropAvailableToUsers(userIds, newDrop); err != nil {
+		log.Printf("Error: Error sending drop %d: %v", newDrop.GetID(), err)
 	}
 }
 
-func NewDropAvailable(userID uint, newDrop model.DropModel) error {
+// NewDropAvailableToUsers sends the drop to the feed stream of the users who can see it.
+// Feed access, visibility, blocks and likes are loaded once for all the users.
+func NewDropAvailableToUsers(userIDs []uint, newDrop model.DropModel) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
 	ds := &dropservice.DropService{
 		Repo: repositories.Setup(),
 	}
-	mu.Lock()
-	wsConn, ok := userFeedConnections[strconv.Itoa(int(userID))]
-	mu.Unlock()
 
-	if !ok {
-		return nil
+	userIDs, err := ds.FilterCanSeeFeed(userIDs)
+	if err != nil {
+		return err
 	}
 
-	isCurrentUserLiking, err := ds.IsCurrentUserLiking(newDrop.GetID(), userID)
+	vs := &visibility.VisibilityService{Repo: ds.Repo}
+	visibleDrops, err := vs.VisibleDropByViewer(userIDs, newDrop)
 	if err != nil {
 		return err
 	}
 
-	dropResponse := response_models.FormatGetDropResponse(newDrop, isCurrentUserLiking)
+	if len(visibleDrops) == 0 {
+		return nil
+	}
 
-	log.Printf("Info: Sending drop to user %d\n", userID)
-	err = wsConn.conn.WriteJSON(dropResponse)
+	isLiking, err := ds.GetLikingUserIds(newDrop.GetID(), userIDs)
 	if err != nil {
-		log.Printf("Error: Error sending message to user %d: %v", userID, err)
 		return err
 	}
 
+	for _, userID := range userIDs {
+		visibleDrop, ok := visibleDrops[userID]
+		if !ok {
+			continue
+		}
+
+		// Feed events are always a list of drops, like the ones sent by NewDropsAvailable
+		dropResponses := []response_models.GetDropResponse{response_models.FormatGetDropResponse(visibleDrop, isLiking[userID])}
+
+		err = RealtimeHub.Publish(realtime.ChannelFeed, userID, dropResponses)
+		if err != nil {
+			log.Printf("Error: Error sending message to user %d: %v", userID, err)
+		}
+	}
+
 	return nil
 }
 
@@ -402,13 +569,6 @@ func NewDropsAvailable(userID uint, newDrops []model.DropModel) error {
 	ds := &dropservice.DropService{
 		Repo: repositories.Setup(),
 	}
-	mu.Lock()
-	wsConn, ok := userFeedConnections[strconv.Itoa(int(userID))]
-	mu.Unlock()
-
-	if !ok {
-		return nil
-	}
 
 	var dropResponses []response_models.GetDropResponse
 	for _, drop := range newDrops {
@@ -422,7 +582,7 @@ func NewDropsAvailable(userID uint, newDrops []model.DropModel) error {
 		dropResponses = append(dropResponses, dropResponse)
 	}
 
-	return wsConn.conn.WriteJSON(dropResponses)
+	return RealtimeHub.Publish(realtime.ChannelFeed, userID, dropResponses)
 }
 
 // DeleteDrop godoc
@@ -565,8 +725,6 @@ func PatchDrop(c *gin.Context) {
 	}
 }
 
-var hasUserDroppedTodayConnections = make(map[string]*WebSocketConnection)
-
 func HasUserDroppedTodayWS(c *gin.Context) {
 	currentUserId, exists := c.Get("userId")
 
@@ -587,62 +745,53 @@ func HasUserDroppedTodayWS(c *gin.Context) {
 		return
 	}
 
-	wsConn := &WebSocketConnection{conn: conn}
-
-	mu.Lock()
-	hasUserDroppedTodayConnections[strconv.Itoa(int(uintCurrentUserId))] = wsConn
-	mu.Unlock()
-
-	ds := &dropservice.DropService{
-		Repo: repositories.Setup(),
-	}
+	client := RealtimeHub.Register(realtime.ChannelHasDropped, uintCurrentUserId, conn)
+	defer client.Run()
 
-	hasDropped, err := ds.HasUserDroppedToday(uintCurrentUserId)
+	hasDroppedResponse, err := getHasUserDroppedTodayResponse(uintCurrentUserId)
 
 	if err != nil {
 		return
 	}
 
-	err = wsConn.conn.WriteJSON(response_models.HasUserDroppedTodayResponse{Status: hasDropped})
+	err = client.Send(hasDroppedResponse)
 
 	if err != nil {
 		log.Printf("Error: Error sending message to user %d: %v", uintCurrentUserId, err)
-		return
 	}
+}
 
-	defer func() {
-		mu.Lock()
-		delete(hasUserDroppedTodayConnections, strconv.Itoa(int(uintCurrentUserId)))
-		mu.Unlock()
-		err := conn.Close()
-		if err != nil {
-			log.Printf("Error: Error closing WebSocket connection: %v", err)
-		}
-	}()
-
-	for {
-		_, _, err := conn.ReadMessage()
-		if err != nil {
-			break
-		}
+func getHasUserDroppedTodayResponse(userID uint) (response_models.HasUserDroppedTodayResponse, error) {
+	ds := &dropservice.DropService{
+		Repo: repositories.Setup(),
 	}
+
+	hasDropped, err := ds.HasUserDroppedToday(userID)
+
+	return response_models.HasUserDroppedTodayResponse{Status: hasDropped}, err
 }
 
 func RefreshHasUserDroppedToday() {
-	mu.Lock()
-	for userId := range hasUserDroppedTodayConnections {
-		err := hasUserDroppedTodayConnections[userId].conn.WriteJSON(response_models.HasUserDroppedTodayResponse{Status: false})
-		if err != nil {
-			log.Printf("Error: Error sending message to user %s: %v", userId, err)
-			err = hasUserDroppedTodayConnections[userId].conn.Close()
-			if err != nil {
-				log.Printf("Error: Error closing WebSocket connection: %v", err)
-			}
-			delete(hasUserDroppedTodayConnections, userId)
-			continue
-		}
+	err := RealtimeHub.Broadcast(realtime.ChannelHasDropped, response_models.HasUserDroppedTodayResponse{Status: false})
+	if err != nil {
+		log.Printf("Error: Error broadcasting new drop: %v", err)
 	}
-	mu.Unlock()
+}
+
+// BroadcastDropNotification tells every connected client that a new drop started and sends the push notifications
+func BroadcastDropNotification(dropNotification model.DropNotificationModel) {
+	RefreshHasUserDroppedToday()
+
+	pushNotificationService := pushnotificationservice.PushNotificationService{
+		Repo: repositories.Setup(),
+	}
+
+	dropType := dropNotification.GetType()
+	if dropNotification.GetKind() != "" {
+		dropType += " (" + dropNotification.GetKind() + ")"
+	}
+
+	pushNotificationService.SendNotificationsToAllUser(dropType)
 }
 
 // SearchContentForCurrentDrop godoc
@@ -660,7 +809,9 @@ func RefreshHasUserDroppedToday() {
 //	@Success		200 {object} []drop_type_apis.ApiSearchResponse
 //	@Failure		400
 //	@Failure		401
+//	@Failure		429 "Too many searches on the provider"
 //	@Failure		500
+//	@Failure		502 "Provider search failed"
 //	@Router			/contents/search [get]
 func SearchContentForCurrentDrop(c *gin.Context) {
 
@@ -712,17 +863,22 @@ func SearchContentForCurrentDrop(c *gin.Context) {
 		return
 	}
 
-	apiService := drop_type_apis.Factory(lastDropNotif.GetType())
+	results, err := drop_type_apis.SearchContent(lastDropNotif.GetType(), lastDropNotif.GetKind(), search)
 
-	if nil == apiService {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid drop type"})
+	if err != nil {
+		var rateLimitedErr errors2.RateLimitedError
+		if errors.As(err, &rateLimitedErr) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": rateLimitedErr.Error()})
+			return
+		}
+		if errors.Is(err, drop_type_apis.ErrUnavailableDropType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
 		return
 	}
 
-	apiService.Init()
-
-	results := apiService.Search(search)
-
 	c.JSON(http.StatusOK, results)
 
 }
@@ -4,8 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
-	"github.com/gorilla/websocket"
 	"go-api/internal/http/response_models"
+	"go-api/internal/realtime"
 	"go-api/internal/repositories"
 	"go-api/internal/services/follow"
 	pushnotificationservice "go-api/internal/services/push_notification"
@@ -15,8 +15,6 @@ import (
 	"go-api/pkg/model"
 	"log"
 	"net/http"
-	"strconv"
-	"sync"
 )
 
 // FollowUser godoc
@@ -33,6 +31,7 @@ import (
 // @Success		201	{object} postgres.Follow
 // @Failure		422
 // @Failure		401
+// @Failure		403
 // @Failure		500
 // @Router			/follows [post]
 func FollowUser(c *gin.Context) {
@@ -88,9 +87,18 @@ func FollowUser(c *gin.Context) {
 		return
 	}
 
-	createdFollow, err := followRepo.Create(uintCurrentUserId, followCreationParam.UserToFollowID, !requestedUser.IsPrivateUser())
+	fs := &follow.FollowService{
+		Repo: repositories.Setup(),
+	}
+
+	createdFollow, err := fs.FollowUser(uintCurrentUserId, followCreationParam.UserToFollowID, requestedUser.IsPrivateUser())
 
 	if err != nil {
+		var notAllowedErr errors2.NotAllowedError
+		if errors.As(err, &notAllowedErr) {
+			c.JSON(http.StatusForbidden, gin.H{"error": notAllowedErr.Reason})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -104,12 +112,12 @@ func FollowUser(c *gin.Context) {
 	log.Printf("Info: User %d is Following user %d\n", uintCurrentUserId, followCreationParam.UserToFollowID)
 
 	if createdFollow.GetStatus() == new(postgres.FollowPendingStatus).ToInt() {
-		log.Printf("Info: Sending pending follows for user %d\n", followCreationParam.UserToFollowID)
-		err = SendPendingFollowsWS(followCreationParam.UserToFollowID, postgres.NewFollowRepo(sqlDB))
+		pendingFollow, err := followRepo.GetFollowByID(createdFollow.GetID())
 		if err != nil {
-			log.Printf("Error: Error sending message to user %d: %v", followCreationParam.UserToFollowID, err)
+			log.Printf("Error: Error getting follow request %d: %v", createdFollow.GetID(), err)
 			return
 		}
+		PublishFollowRequestTransition(pendingFollow, model.FollowRequestSent)
 
 		if requestedUser.GetFCMToken() != "" {
 			pns := pushnotificationservice.PushNotificationService{Repo: repositories.Setup()}
@@ -158,19 +166,6 @@ func FollowUser(c *gin.Context) {
 
 }
 
-var pendingFollowUpgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
-}
-
-type PendingFollowWebSocketConnection struct {
-	conn *websocket.Conn
-}
-
-var userPendingFollowConnections = make(map[string]*PendingFollowWebSocketConnection)
-var muPendingFollow sync.Mutex
-
 func GetMyPendingRequestsWS(c *gin.Context) {
 	currentUserId, exists := c.Get("userId")
 
@@ -184,42 +179,26 @@ func GetMyPendingRequestsWS(c *gin.Context) {
 		return
 	}
 
-	conn, err := pendingFollowUpgrader.Upgrade(c.Writer, c.Request, nil)
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upgrade WebSocket"})
 		return
 	}
 
-	wsConn := &PendingFollowWebSocketConnection{conn: conn}
-
-	muPendingFollow.Lock()
-	userPendingFollowConnections[strconv.Itoa(int(uintCurrentUserId))] = wsConn
-	log.Printf("Info: New user connected to pending follows: %d\n", uintCurrentUserId)
-	muPendingFollow.Unlock()
+	client := RealtimeHub.Register(realtime.ChannelPendingFollows, uintCurrentUserId, conn)
+	defer client.Run()
 
 	sqlDB := postgres.Connect()
 
-	err = SendPendingFollowsWS(uintCurrentUserId, postgres.NewFollowRepo(sqlDB))
+	pendingFollowResponses, err := getPendingFollowResponses(uintCurrentUserId, postgres.NewFollowRepo(sqlDB))
 	if err != nil {
-		log.Printf("Error sending message to user %d: %v", uintCurrentUserId, err)
+		log.Printf("Error: Error getting pending follows of user %d: %v", uintCurrentUserId, err)
 		return
 	}
 
-	defer func() {
-		muPendingFollow.Lock()
-		delete(userPendingFollowConnections, strconv.Itoa(int(uintCurrentUserId)))
-		muPendingFollow.Unlock()
-		err := conn.Close()
-		if err != nil {
-			log.Printf("Error: Error closing WebSocket connection: %v", err)
-		}
-	}()
-
-	for {
-		_, _, err := conn.ReadMessage()
-		if err != nil {
-			break
-		}
+	err = client.Send(pendingFollowResponses)
+	if err != nil {
+		log.Printf("Error sending message to user %d: %v", uintCurrentUserId, err)
 	}
 }
 
@@ -288,18 +267,16 @@ func AcceptRequest(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusCreated, gin.H{"message": "Follow request accepted"})
-	err = SendPendingFollowsWS(uintCurrentUserId, followRepo)
-	if err != nil {
-		log.Printf("Error: Error sending message to user %d: %v", uintCurrentUserId, err)
-	}
 
 	acceptedFollow, err := followRepo.GetFollowByID(followId)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Printf("Error: Error getting follow %d: %v", followId, err)
 		return
 	}
 
+	PublishFollowRequestTransition(acceptedFollow, model.FollowRequestAccepted)
+
 	dnr := postgres.NewDropNotifRepo(sqlDB)
 
 	lastNotification, err := dnr.GetCurrentDropNotification()
@@ -398,10 +375,7 @@ func RejectRequest(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	err = SendPendingFollowsWS(uintCurrentUserId, followRepo)
-	if err != nil {
-		log.Printf("Error sending message to user %d: %v", uintCurrentUserId, err)
-	}
+	PublishFollowRequestTransition(myFollow, model.FollowRequestRejected)
 
 	c.JSON(http.StatusCreated, gin.H{"message": "Follow request refused"})
 }
@@ -535,19 +509,33 @@ func GetUserFollowers(c *gin.Context) {
 }
 
 func SendPendingFollowsWS(userID uint, followRepo model.FollowRepository) error {
-	fmt.Printf("Sending pending follows for user %d\n", userID)
-	muPendingFollow.Lock()
-	wsConn, ok := userPendingFollowConnections[strconv.Itoa(int(userID))]
-	muPendingFollow.Unlock()
-	if !ok {
-		fmt.Printf("Connections are: %v\n", userPendingFollowConnections)
-		fmt.Printf("User %d not found in userPendingFollowConnections\n", userID)
-		return nil
+	pendingFollowResponses, err := getPendingFollowResponses(userID, followRepo)
+	if err != nil {
+		return err
+	}
+
+	return RealtimeHub.Publish(realtime.ChannelPendingFollows, userID, pendingFollowResponses)
+}
+
+// PublishFollowRequestTransition tells both users what happened to a follow request and refreshes the pending requests of the followed one
+func PublishFollowRequestTransition(follow model.FollowModel, transition model.FollowRequestTransition) {
+	events := []response_models.FollowRequestEventResponse{response_models.FormatFollowRequestEventResponse(follow, transition)}
+
+	for _, userID := range []uint{follow.GetFollowerID(), follow.GetFollowedID()} {
+		if err := RealtimeHub.Publish(realtime.ChannelFollowRequests, userID, events); err != nil {
+			log.Printf("Error: Error sending follow request event to user %d: %v", userID, err)
+		}
 	}
 
+	if err := SendPendingFollowsWS(follow.GetFollowedID(), postgres.NewFollowRepo(postgres.Connect())); err != nil {
+		log.Printf("Error: Error sending message to user %d: %v", follow.GetFollowedID(), err)
+	}
+}
+
+func getPendingFollowResponses(userID uint, followRepo model.FollowRepository) ([]response_models.GetOnePendingFollowResponse, error) {
 	pendingRequests, err := followRepo.GetPendingRequests(userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var pendingFollowResponses []response_models.GetOnePendingFollowResponse
@@ -555,7 +543,7 @@ func SendPendingFollowsWS(userID uint, followRepo model.FollowRepository) error
 		pendingFollowResponses = append(pendingFollowResponses, response_models.FormatGetOnePendingFollowResponse(pendingFollow))
 	}
 
-	return wsConn.conn.WriteJSON(pendingFollowResponses)
+	return pendingFollowResponses, nil
 }
 
 // DeleteFollow godoc
@@ -620,3 +608,210 @@ func DeleteFollow(c *gin.Context) {
 
 	c.JSON(http.StatusOK, nil)
 }
+
+// GetMySentRequests godoc
+//
+// @Summary		Get sent follow requests
+// @Description	Get the follow requests sent by the current user and still waiting for an answer
+// @Tags			follow
+// @Accept			json
+// @Produce		json
+// @Security BearerAuth
+//
+// @Success		200	{object} []response_models.GetOneFollowResponse
+// @Failure		401
+// @Failure		500
+// @Router			/follows/sent [get]
+func GetMySentRequests(c *gin.Context) {
+	currentUserId, exists := c.Get("userId")
+
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	uintCurrentUserId, ok := currentUserId.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	fs := &follow.FollowService{
+		Repo: repositories.Setup(),
+	}
+
+	sentRequests, err := fs.GetSentRequests(uintCurrentUserId)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sentRequestsResponse := make([]response_models.GetOneFollowResponse, 0, len(sentRequests))
+	for _, sentRequest := range sentRequests {
+		sentRequestsResponse = append(sentRequestsResponse, response_models.FormatGetOneFollowResponse(sentRequest))
+	}
+
+	c.JSON(http.StatusOK, sentRequestsResponse)
+}
+
+// CancelRequest godoc
+//
+// @Summary		Cancel follow request
+// @Description	Cancel a follow request sent by the current user
+// @Tags			follow
+// @Accept			json
+// @Produce		json
+// @Param			id path int true "Follow ID"
+// @Security BearerAuth
+//
+// @Success		204
+// @Failure		400
+// @Failure		401
+// @Failure		403
+// @Failure		404
+// @Failure		500
+// @Router			/follows/sent/{id} [delete]
+func CancelRequest(c *gin.Context) {
+	currentUserId, exists := c.Get("userId")
+
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	uintCurrentUserId, ok := currentUserId.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	followId, err := converters.StringToUint(c.Param("id"))
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid follow ID"})
+		return
+	}
+
+	fs := &follow.FollowService{
+		Repo:                repositories.Setup(),
+		OnRequestTransition: PublishFollowRequestTransition,
+	}
+
+	if _, err := fs.CancelRequest(uintCurrentUserId, followId); err != nil {
+		handleRestrictionError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetFollowRequestEventsWS streams what happens to the follow requests the current user sent or received
+func GetFollowRequestEventsWS(c *gin.Context) {
+	currentUserId, exists := c.Get("userId")
+
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	uintCurrentUserId, ok := currentUserId.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upgrade WebSocket"})
+		return
+	}
+
+	client := RealtimeHub.Register(realtime.ChannelFollowRequests, uintCurrentUserId, conn)
+	client.Run()
+}
+
+// RemoveFollower godoc
+//
+// @Summary		Remove follower
+// @Description	Remove a user from the current user's followers, they have to follow again to see their drops
+// @Tags			user
+// @Accept			json
+// @Produce		json
+// @Security BearerAuth
+// @Param			id path int true "User ID"
+// @Success		204 No Content
+// @Failure		400
+// @Failure		401
+// @Failure		404
+// @Failure		500
+// @Router			/users/followers/{id} [delete]
+func RemoveFollower(c *gin.Context) {
+	currentUserId, followerId, ok := getRestrictionTarget(c)
+	if !ok {
+		return
+	}
+
+	fs := &follow.FollowService{
+		Repo: repositories.Setup(),
+	}
+
+	if err := fs.RemoveFollower(currentUserId, followerId); err != nil {
+		handleRestrictionError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveFollowers godoc
+//
+// @Summary		Remove followers
+// @Description	Remove several users from the current user's followers, the users not following them are ignored
+// @Tags			user
+// @Accept			json
+// @Produce		json
+// @Security BearerAuth
+//
+//	@Param			users	body		model.FollowersRemovalParam	true	"Followers to remove"
+//
+// @Success		200	{object} []response_models.GetUserResponseInterface
+// @Failure		400
+// @Failure		401
+// @Failure		500
+// @Router			/users/followers/remove [post]
+func RemoveFollowers(c *gin.Context) {
+	currentUserId, exists := c.Get("userId")
+
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	uintCurrentUserId, ok := currentUserId.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var removalParam model.FollowersRemovalParam
+
+	if err := c.ShouldBindJSON(&removalParam); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fs := &follow.FollowService{
+		Repo: repositories.Setup(),
+	}
+
+	removed, err := fs.RemoveFollowers(uintCurrentUserId, removalParam.UserIDs)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	removedResponse := make([]response_models.GetUserResponseInterface, 0, len(removed))
+	for _, removedFollow := range removed {
+		removedResponse = append(removedResponse, response_models.FormatGetUserResponse(removedFollow.GetFollower()))
+	}
+
+	c.JSON(http.StatusOK, removedResponse)
+}
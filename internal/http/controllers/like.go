@@ -1,10 +1,12 @@
 package controllers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"go-api/internal/repositories"
 	likeservice "go-api/internal/services/like"
 	pushnotificationservice "go-api/internal/services/push_notification"
+	"go-api/pkg/errors2"
 	"go-api/pkg/model"
 	"log"
 	"net/http"
@@ -24,6 +26,8 @@ import (
 //	@Param			id path int true "Drop ID"
 //	@Success		201	{object} postgres.Like
 //	@Failure		401
+//	@Failure		403
+//	@Failure		404
 //	@Failure		422 {object} errors2.MultiFieldsError
 //	@Failure		500
 //	@Router			/drops/{id}/like [post]
@@ -62,6 +66,16 @@ func LikeDrop(c *gin.Context) {
 	like, err := ls.LikeDrop(uintCurrentUserId, likeParam)
 
 	if err != nil {
+		var notAllowedErr errors2.NotAllowedError
+		if errors.As(err, &notAllowedErr) {
+			c.JSON(http.StatusForbidden, gin.H{"error": notAllowedErr.Reason})
+			return
+		}
+		var notFoundErr errors2.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			c.JSON(http.StatusNotFound, gin.H{"error": notFoundErr.Error()})
+			return
+		}
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 		return
 	}
@@ -88,17 +102,7 @@ func LikeDrop(c *gin.Context) {
 		}
 	}
 
-	followers, err := ls.Repo.FollowRepository.GetFollowers(likedDrop.GetCreatedById())
-
-	if err != nil {
-		return
-	}
-
-	for _, follower := range followers {
-		_ = NewDropAvailable(follower.GetFollowerID(), likedDrop)
-	}
-
-	_ = NewDropAvailable(likedDrop.GetCreatedById(), likedDrop)
+	go NewDropAvailableToFollowers(likedDrop, true)
 }
 
 // UnlikeDrop godoc
@@ -164,15 +168,5 @@ func UnlikeDrop(c *gin.Context) {
 		return
 	}
 
-	followers, err := ls.Repo.FollowRepository.GetFollowers(unlikedDrop.GetCreatedById())
-
-	if err != nil {
-		return
-	}
-
-	for _, follower := range followers {
-		_ = NewDropAvailable(follower.GetFollowerID(), unlikedDrop)
-	}
-
-	_ = NewDropAvailable(unlikedDrop.GetCreatedById(), unlikedDrop)
+	go NewDropAvailableToFollowers(unlikedDrop, true)
 }
@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-api/internal/http/response_models"
+	"go-api/internal/realtime"
+	"go-api/internal/repositories"
+	"go-api/pkg/jwt_helper"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RealtimeHub delivers the websocket and SSE events, main replaces it once the configured broker is available
+var RealtimeHub = realtime.NewHub(realtime.NewMemoryBroker(), realtime.NewHubConfigFromEnv())
+
+// GetCurrentUserFeedSSE godoc
+//
+//	@Summary		Stream the current user feed
+//	@Description	Server-Sent Events alternative to /users/my-feed/ws, every event is a list of drops
+//	@Tags			user
+//	@Produce		text/event-stream
+//	@Security BearerAuth
+//	@Param			Last-Event-ID header string false "ID of the last event received, to get the missed ones"
+//	@Param			ticket query string false "Ticket of /auth/stream-ticket, for clients which cannot send the Authorization header"
+//	@Success		200 {object} []response_models.GetDropResponse
+//	@Failure		401
+//	@Router			/users/my-feed/sse [get]
+func GetCurrentUserFeedSSE(c *gin.Context) {
+	streamChannel(c, realtime.ChannelFeed, func(userID uint) (interface{}, error) {
+		return getUserFeedResponses(userID)
+	})
+}
+
+// HasUserDroppedTodaySSE godoc
+//
+//	@Summary		Stream whether the current user dropped
+//	@Description	Server-Sent Events alternative to /drops/has-user-dropped
+//	@Tags			drop
+//	@Produce		text/event-stream
+//	@Security BearerAuth
+//	@Param			Last-Event-ID header string false "ID of the last event received, to get the missed ones"
+//	@Param			ticket query string false "Ticket of /auth/stream-ticket, for clients which cannot send the Authorization header"
+//	@Success		200 {object} response_models.HasUserDroppedTodayResponse
+//	@Failure		401
+//	@Router			/drops/has-user-dropped/sse [get]
+func HasUserDroppedTodaySSE(c *gin.Context) {
+	streamChannel(c, realtime.ChannelHasDropped, func(userID uint) (interface{}, error) {
+		return getHasUserDroppedTodayResponse(userID)
+	})
+}
+
+// GetMyPendingRequestsSSE godoc
+//
+//	@Summary		Stream the current user pending follow requests
+//	@Description	Server-Sent Events alternative to /follows/pending
+//	@Tags			follow
+//	@Produce		text/event-stream
+//	@Security BearerAuth
+//	@Param			Last-Event-ID header string false "ID of the last event received, to get the missed ones"
+//	@Param			ticket query string false "Ticket of /auth/stream-ticket, for clients which cannot send the Authorization header"
+//	@Success		200 {object} []response_models.GetOnePendingFollowResponse
+//	@Failure		401
+//	@Router			/follows/pending/sse [get]
+func GetMyPendingRequestsSSE(c *gin.Context) {
+	streamChannel(c, realtime.ChannelPendingFollows, func(userID uint) (interface{}, error) {
+		return getPendingFollowResponses(userID, repositories.Setup().FollowRepository)
+	})
+}
+
+// GetFollowRequestEventsSSE godoc
+//
+//	@Summary		Stream the follow request events of the current user
+//	@Description	Server-Sent Events telling both users when a follow request is sent, accepted, rejected, canceled or expired
+//	@Tags			follow
+//	@Produce		text/event-stream
+//	@Security BearerAuth
+//	@Param			Last-Event-ID header string false "ID of the last event received, to get the missed ones"
+//	@Param			ticket query string false "Ticket of /auth/stream-ticket, for clients which cannot send the Authorization header"
+//	@Success		200 {object} []response_models.FollowRequestEventResponse
+//	@Failure		401
+//	@Router			/follows/events/sse [get]
+func GetFollowRequestEventsSSE(c *gin.Context) {
+	streamChannel(c, realtime.ChannelFollowRequests, func(userID uint) (interface{}, error) {
+		// Nothing happened yet on a new stream, the missed events are resent on reconnection
+		return []response_models.FollowRequestEventResponse{}, nil
+	})
+}
+
+// CreateStreamTicket godoc
+//
+//	@Summary		Create a stream ticket
+//	@Description	issue a ticket valid one minute to open a Server-Sent Events stream with ?ticket=, as browsers' EventSource cannot send the Authorization header.
+//	@Description	EventSource reconnects with the same URL, so clients create a new ticket when a stream closes.
+//	@Tags			auth
+//	@Produce		json
+//	@Security BearerAuth
+//	@Success		200	{object} response_models.StreamTicketResponse
+//	@Failure		401
+//	@Failure		500
+//	@Router			/auth/stream-ticket [post]
+func CreateStreamTicket(c *gin.Context) {
+	uintCurrentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	ticket, err := jwt_helper.GenerateStreamTicket(uintCurrentUserId, c.GetUint("sessionId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response_models.StreamTicketResponse{
+		Ticket:    ticket,
+		ExpiresIn: int(jwt_helper.StreamTicketLifetime.Seconds()),
+	})
+}
+
+// streamChannel sends the events of the channel as Server-Sent Events.
+// Reconnecting clients get the events they missed since Last-Event-ID (header or lastEventId query parameter),
+// or the current state of the channel when those events are not kept anymore.
+// Event IDs are not committed in order, so the events just before Last-Event-ID are sent again too and clients skip the IDs they already handled.
+func streamChannel(c *gin.Context, channel realtime.Channel, snapshot func(userID uint) (interface{}, error)) {
+	currentUserId, exists := c.Get("userId")
+
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	uintCurrentUserId, ok := currentUserId.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Listening before reading the state means nothing published in between is lost
+	client := RealtimeHub.Listen(channel, uintCurrentUserId)
+	defer client.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// sentIDs skips the events the client also receives while listening, they can arrive in any order
+	sentIDs := map[uint]bool{}
+
+	missedEvents, resumed := getMissedEvents(c, channel, uintCurrentUserId)
+	if resumed {
+		for _, event := range missedEvents {
+			writeServerSentEvent(c, event)
+			sentIDs[event.ID] = true
+		}
+	} else {
+		lastEventID, err := RealtimeHub.LastEventID()
+		if err != nil {
+			log.Printf("Error: Error getting last realtime event: %v", err)
+			return
+		}
+
+		state, err := snapshot(uintCurrentUserId)
+		if err != nil {
+			log.Printf("Error: Error getting %s state of user %d: %v", channel, uintCurrentUserId, err)
+			return
+		}
+
+		payload, err := json.Marshal(state)
+		if err != nil {
+			log.Printf("Error: Error sending message to user %d: %v", uintCurrentUserId, err)
+			return
+		}
+
+		writeServerSentEvent(c, realtime.Event{ID: lastEventID, Channel: channel, Payload: payload})
+		sentIDs[lastEventID] = true
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(RealtimeHub.Config().PingInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-client.Done():
+			return
+		case event := <-client.Events():
+			if event.ID != 0 && sentIDs[event.ID] {
+				// An ID is only delivered once, it does not need to be remembered anymore
+				delete(sentIDs, event.ID)
+				continue
+			}
+			writeServerSentEvent(c, event)
+		case <-heartbeat.C:
+			// Comments keep proxies from closing idle streams
+			_, _ = fmt.Fprint(c.Writer, ": ping\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+func getMissedEvents(c *gin.Context, channel realtime.Channel, userID uint) ([]realtime.Event, bool) {
+	lastEventIdParam := c.GetHeader("Last-Event-ID")
+	if lastEventIdParam == "" {
+		lastEventIdParam = c.Query("lastEventId")
+	}
+	if lastEventIdParam == "" {
+		return nil, false
+	}
+
+	lastEventId, err := strconv.ParseUint(lastEventIdParam, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	newestEventId, err := RealtimeHub.LastEventID()
+	if err != nil {
+		log.Printf("Error: Error getting last realtime event: %v", err)
+		return nil, false
+	}
+	// An ID above the last one comes from before a restart of the memory broker
+	if uint(lastEventId) > newestEventId {
+		return nil, false
+	}
+
+	replayFrom := uint(0)
+	if overlap := RealtimeHub.Config().ReplayOverlap; uint(lastEventId) > overlap {
+		replayFrom = uint(lastEventId) - overlap
+	}
+
+	events, complete, err := RealtimeHub.History(channel, userID, replayFrom)
+	if err != nil {
+		log.Printf("Error: Error getting missed %s events of user %d: %v", channel, userID, err)
+		return nil, false
+	}
+
+	return events, complete
+}
+
+func writeServerSentEvent(c *gin.Context, event realtime.Event) {
+	if event.ID != 0 {
+		_, _ = fmt.Fprintf(c.Writer, "id: %d\n", event.ID)
+	}
+	_, _ = fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event.Channel, event.Payload)
+}
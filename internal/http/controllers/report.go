@@ -1,9 +1,11 @@
 package controllers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"go-api/internal/repositories"
 	"go-api/internal/services/report"
+	"go-api/pkg/errors2"
 	"go-api/pkg/model"
 	"net/http"
 )
@@ -18,6 +20,8 @@ import (
 // @Security BearerAuth
 // @Param			report	body		model.ReportCreationParam	true	"Report object"
 // @Success		201	{object} response_models.GetReportResponse
+// @Failure		403
+// @Failure		404
 // @Failure		422 {object} errors2.MultiFieldsError
 // @Router			/reports [post]
 func CreateReport(c *gin.Context) {
@@ -46,6 +50,16 @@ func CreateReport(c *gin.Context) {
 	createdReport, err := reportService.CreateReport(uintCurrentUserId, reportRequest)
 
 	if err != nil {
+		var notAllowedErr errors2.NotAllowedError
+		if errors.As(err, &notAllowedErr) {
+			c.JSON(http.StatusForbidden, gin.H{"error": notAllowedErr.Reason})
+			return
+		}
+		var notFoundErr errors2.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			c.JSON(http.StatusNotFound, gin.H{"error": notFoundErr.Error()})
+			return
+		}
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 		return
 	}
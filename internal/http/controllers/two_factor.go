@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go-api/internal/repositories"
+	"go-api/internal/services/account"
+	"go-api/pkg/model"
+	accountiface "go-api/pkg/services/account"
+	"net/http"
+)
+
+// LoginWithTwoFactor godoc
+//
+//	@Summary		Login with two-factor authentication
+//	@Description	complete a login with the twoFactorToken returned by /auth and either a code of the authenticator app or a recovery code
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			params body		model.TwoFactorLoginParam	true	"Two-factor token and code"
+//	@Success		200	{object} account.TokenInfo
+//	@Failure		400
+//	@Failure		401 "Invalid or expired two-factor token"
+//	@Failure		422 "Invalid two-factor code"
+//	@Failure		429 "Too many failed attempts"
+//	@Failure		500
+//	@Router			/auth/2fa [post]
+func LoginWithTwoFactor(c *gin.Context) {
+	var loginParam model.TwoFactorLoginParam
+
+	if err := c.ShouldBindJSON(&loginParam); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	acc := &account.AccountService{
+		Repo:     repositories.Setup(),
+		Device:   getDeviceInfo(c),
+		Mailer:   Mailer,
+		Throttle: LoginThrottle,
+	}
+
+	tokenInfo, err := acc.LoginWithTwoFactor(loginParam.TwoFactorToken, loginParam.Code, loginParam.RecoveryCode)
+	if handleTooManyAttempts(c, err) {
+		return
+	}
+	if errors.Is(err, account.ErrInvalidTwoFactorToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		handleTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenInfo)
+}
+
+// SetupTwoFactor godoc
+//
+//	@Summary		Set two-factor authentication up
+//	@Description	generate a new TOTP secret for the current user, to confirm with /auth/2fa/enable
+//	@Tags			auth
+//	@Produce		json
+//	@Security BearerAuth
+//	@Success		200	{object} account.TwoFactorSetup
+//	@Failure		401
+//	@Failure		409 "Two-factor authentication already enabled"
+//	@Failure		500
+//	@Router			/auth/2fa/setup [post]
+func SetupTwoFactor(c *gin.Context) {
+	uintCurrentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	acc := &account.AccountService{
+		Repo: repositories.Setup(),
+	}
+
+	setup, err := acc.SetupTwoFactor(uintCurrentUserId)
+	if err != nil {
+		handleTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+// EnableTwoFactor godoc
+//
+//	@Summary		Enable two-factor authentication
+//	@Description	confirm the secret of /auth/2fa/setup with a code of the authenticator app, the recovery codes are only returned once.
+//	@Description	The other sessions are logged out, admins refresh their tokens to access the admin routes.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Security BearerAuth
+//	@Param			params body		model.TwoFactorCodeParam	true	"Code of the authenticator app"
+//	@Success		200	{object} account.RecoveryCodes
+//	@Failure		400
+//	@Failure		401
+//	@Failure		409 "Two-factor authentication not set up or already enabled"
+//	@Failure		422 "Invalid two-factor code"
+//	@Failure		500
+//	@Router			/auth/2fa/enable [post]
+func EnableTwoFactor(c *gin.Context) {
+	uintCurrentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	var codeParam model.TwoFactorCodeParam
+
+	if err := c.ShouldBindJSON(&codeParam); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	acc := &account.AccountService{
+		Repo: repositories.Setup(),
+	}
+
+	recoveryCodes, err := acc.EnableTwoFactor(uintCurrentUserId, c.GetUint("sessionId"), codeParam.Code)
+	if err != nil {
+		handleTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, accountiface.RecoveryCodes{RecoveryCodes: recoveryCodes})
+}
+
+// DisableTwoFactor godoc
+//
+//	@Summary		Disable two-factor authentication
+//	@Description	turn two-factor authentication off for the current user, admins cannot
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Security BearerAuth
+//	@Param			params body		model.TwoFactorDisableParam	true	"Password and code of the authenticator app"
+//	@Success		204
+//	@Failure		400
+//	@Failure		401
+//	@Failure		403 "Two-factor authentication is required for admins"
+//	@Failure		409 "Two-factor authentication not set up"
+//	@Failure		422
+//	@Failure		429 "Too many failed attempts"
+//	@Failure		500
+//	@Router			/auth/2fa/disable [post]
+func DisableTwoFactor(c *gin.Context) {
+	uintCurrentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	var disableParam model.TwoFactorDisableParam
+
+	if err := c.ShouldBindJSON(&disableParam); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	acc := &account.AccountService{
+		Repo:     repositories.Setup(),
+		Device:   getDeviceInfo(c),
+		Mailer:   Mailer,
+		Throttle: LoginThrottle,
+	}
+
+	err := acc.DisableTwoFactor(uintCurrentUserId, disableParam.Password, disableParam.Code)
+	if handleTooManyAttempts(c, err) {
+		return
+	}
+	if err != nil {
+		handleTwoFactorError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes godoc
+//
+//	@Summary		Regenerate recovery codes
+//	@Description	replace the recovery codes of the current user, the previous ones stop working
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Security BearerAuth
+//	@Param			params body		model.TwoFactorCodeParam	true	"Code of the authenticator app"
+//	@Success		200	{object} account.RecoveryCodes
+//	@Failure		400
+//	@Failure		401
+//	@Failure		409 "Two-factor authentication not set up"
+//	@Failure		422 "Invalid two-factor code"
+//	@Failure		429 "Too many failed attempts"
+//	@Failure		500
+//	@Router			/auth/2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(c *gin.Context) {
+	uintCurrentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	var codeParam model.TwoFactorCodeParam
+
+	if err := c.ShouldBindJSON(&codeParam); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	acc := &account.AccountService{
+		Repo:     repositories.Setup(),
+		Device:   getDeviceInfo(c),
+		Mailer:   Mailer,
+		Throttle: LoginThrottle,
+	}
+
+	recoveryCodes, err := acc.RegenerateRecoveryCodes(uintCurrentUserId, codeParam.Code)
+	if handleTooManyAttempts(c, err) {
+		return
+	}
+	if err != nil {
+		handleTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, accountiface.RecoveryCodes{RecoveryCodes: recoveryCodes})
+}
+
+func handleTwoFactorError(c *gin.Context, err error) {
+	if errors.Is(err, account.ErrInvalidTwoFactorCode) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, account.ErrTwoFactorNotSetUp) || errors.Is(err, model.ErrTwoFactorAlreadyEnabled) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	handleAccountEmailError(c, err)
+}
@@ -5,11 +5,17 @@ import (
 	"github.com/gin-gonic/gin/binding"
 	"go-api/internal/http/response_models"
 	"go-api/internal/repositories"
+	"go-api/internal/services/account"
+	blockservice "go-api/internal/services/block"
 	"go-api/internal/services/user"
+	"go-api/internal/services/user_suggestion"
+	"go-api/internal/services/visibility"
 	"go-api/internal/storage/postgres"
 	"go-api/pkg/errors2"
 	"go-api/pkg/model"
+	"log"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -71,6 +77,19 @@ func GetUserById(c *gin.Context) {
 		return
 	}
 
+	bs := &blockservice.BlockService{Repo: repositories.Setup()}
+	isBlocked, err := bs.Repo.BlockRepository.IsBlockedBetween(uintCurrentUserId, requestedUser.GetID())
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if isBlocked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
 	dr := postgres.NewDropRepo(sqlDB)
 
 	pinnedDrops, err := dr.GetUserPinnedDrops(requestedUser.GetID())
@@ -96,6 +115,24 @@ func GetUserById(c *gin.Context) {
 		}
 		userLastDrop = nil
 	}
+	vs := &visibility.VisibilityService{Repo: bs.Repo}
+	pinnedDrops, err = vs.FilterDrops(uintCurrentUserId, pinnedDrops)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if nil != userLastDrop {
+		visibleLastDrops, err := vs.FilterDrops(uintCurrentUserId, []model.DropModel{userLastDrop})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(visibleLastDrops) == 0 {
+			userLastDrop = nil
+		}
+	}
+
 	isLastDropLiking := false
 	if nil != userLastDrop {
 		lr := postgres.NewLikeRepo(sqlDB)
@@ -176,6 +213,16 @@ func Create(c *gin.Context) {
 		return
 	}
 
+	acc := &account.AccountService{
+		Repo:   repositories.Setup(),
+		Mailer: Mailer,
+	}
+
+	// The user can ask for a new link if this one is not sent
+	if err := acc.SendEmailVerification(newUser); err != nil {
+		log.Printf("Error: Error sending verification email to user %d: %v", newUser.GetID(), err)
+	}
+
 	c.JSON(http.StatusCreated, response_models.FormatGetUserResponse(newUser))
 }
 
@@ -206,6 +253,7 @@ func PatchUserById(c *gin.Context) {
 	}
 
 	us := user.NewUserService(repositories.Setup())
+	us.OnFollowRequestTransition = PublishFollowRequestTransition
 
 	id := strings.TrimSpace(c.Param("id"))
 
@@ -270,6 +318,7 @@ func PatchUserById(c *gin.Context) {
 // @Param			search query string true "Search query"
 // @Success		200	{object} []response_models.GetUserResponse
 // @Failure		400
+// @Failure		404 "No user found, users who blocked the current user or whom they blocked are left out"
 // @Failure		500
 // @Router			/users/search [get]
 func SearchUsers(c *gin.Context) {
@@ -291,7 +340,27 @@ func SearchUsers(c *gin.Context) {
 		return
 	}
 
-	if nil == users {
+	if currentUserId, exists := c.Get("userId"); exists {
+		if uintCurrentUserId, ok := currentUserId.(uint); ok {
+			// Muted users can still be found, only blocked ones are left out
+			blockedUserIds, err := repositories.Setup().BlockRepository.GetBlockRelatedUserIds(uintCurrentUserId)
+
+			if err != nil {
+				c.JSON(500, gin.H{"error": err.Error()})
+				return
+			}
+
+			var visibleUsers []model.UserModel
+			for _, searchedUser := range users {
+				if !slices.Contains(blockedUserIds, searchedUser.GetID()) {
+					visibleUsers = append(visibleUsers, searchedUser)
+				}
+			}
+			users = visibleUsers
+		}
+	}
+
+	if len(users) == 0 {
 		c.JSON(404, gin.H{"error": "Users not found"})
 		return
 	}
@@ -303,3 +372,50 @@ func SearchUsers(c *gin.Context) {
 
 	c.JSON(200, usersResponse)
 }
+
+// GetUserSuggestions godoc
+//
+// @Summary		Get user suggestions
+// @Description	Get users to follow ranked by mutual friends, groups and drops in common, with the reasons of each suggestion
+// @Tags			user
+// @Accept			json
+// @Produce		json
+// @Security BearerAuth
+// @Param			limit query int false "Number of suggestions, 20 by default and 50 at most"
+// @Success		200	{object} []response_models.GetUserSuggestionResponse
+// @Failure		401
+// @Failure		500
+// @Router			/users/suggestions [get]
+func GetUserSuggestions(c *gin.Context) {
+	currentUserId, exists := c.Get("userId")
+
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	uintCurrentUserId, ok := currentUserId.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+	ss := &user_suggestion.UserSuggestionService{
+		Repo: repositories.Setup(),
+	}
+
+	suggestions, err := ss.GetSuggestions(uintCurrentUserId, limit)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	suggestionsResponse := make([]response_models.GetUserSuggestionResponse, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		suggestionsResponse = append(suggestionsResponse, response_models.FormatGetUserSuggestionResponse(suggestion))
+	}
+
+	c.JSON(http.StatusOK, suggestionsResponse)
+}
@@ -2,7 +2,6 @@ package middlewares
 
 import (
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"go-api/pkg/jwt_helper"
 	"net/http"
 	"strings"
@@ -19,6 +18,7 @@ func CurrentUserMiddleware(forceLogin bool) gin.HandlerFunc {
 				return
 			}
 			c.Next()
+			return
 		}
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
@@ -28,11 +28,12 @@ func CurrentUserMiddleware(forceLogin bool) gin.HandlerFunc {
 				return
 			}
 			c.Next()
+			return
 		}
 
 		if 2 == len(parts) {
 			tokenString := parts[1]
-			token, err := jwt_helper.VerifyToken(tokenString)
+			claims, err := jwt_helper.VerifyToken(tokenString, jwt_helper.TokenTypeAccess)
 
 			if err != nil {
 				if forceLogin {
@@ -41,12 +42,32 @@ func CurrentUserMiddleware(forceLogin bool) gin.HandlerFunc {
 					return
 				}
 				c.Next()
+				return
+			}
+
+			userId, err := claims.GetUserID()
+			if err != nil {
+				if forceLogin {
+					c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to parse JWT token: " + err.Error()})
+					c.Abort()
+					return
+				}
+				c.Next()
+				return
 			}
 
-			if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-				userId := uint(claims["sub"].(float64))
-				c.Set("userId", userId)
+			if err := checkSession(claims); err != nil {
+				if forceLogin {
+					c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session: " + err.Error()})
+					c.Abort()
+					return
+				}
+				c.Next()
+				return
 			}
+
+			c.Set("userId", userId)
+			c.Set("sessionId", claims.SessionID)
 		}
 
 		c.Next()
@@ -2,7 +2,6 @@ package middlewares
 
 import (
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"go-api/pkg/jwt_helper"
 	"net/http"
 	"strings"
@@ -27,7 +26,7 @@ func AdminRequired() gin.HandlerFunc {
 
 		if 2 == len(parts) {
 			tokenString := parts[1]
-			token, err := jwt_helper.VerifyToken(tokenString)
+			claims, err := jwt_helper.VerifyToken(tokenString, jwt_helper.TokenTypeAccess)
 
 			if err != nil {
 				c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to parse JWT token: " + err.Error()})
@@ -35,13 +34,23 @@ func AdminRequired() gin.HandlerFunc {
 				return
 			}
 
-			if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-				role := claims["role"].(string)
-				if role != "admin" {
-					c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
-					c.Abort()
-					return
-				}
+			if err := checkSession(claims); err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session: " + err.Error()})
+				c.Abort()
+				return
+			}
+
+			if claims.Role != "admin" {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+				c.Abort()
+				return
+			}
+
+			// Admins must have logged in with their second factor, see /auth/2fa
+			if !claims.TwoFactor {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Two-factor authentication required"})
+				c.Abort()
+				return
 			}
 		}
 
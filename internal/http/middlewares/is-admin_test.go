@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-api/pkg/jwt_helper"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAdminRequired(t *testing.T) {
+	keyring, err := jwt_helper.NewKeyring(jwt_helper.NewHMACKey("hmac", []byte("secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwt_helper.SetKeyring(keyring)
+	sessions = newSessionCache(time.Minute, func(sessionId uint) (bool, error) {
+		return false, nil
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin", AdminRequired(), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	for name, test := range map[string]struct {
+		role      string
+		twoFactor bool
+		want      int
+	}{
+		"admin with two-factor":    {role: "admin", twoFactor: true, want: http.StatusNoContent},
+		"admin without two-factor": {role: "admin", twoFactor: false, want: http.StatusForbidden},
+		"user with two-factor":     {role: "user", twoFactor: true, want: http.StatusForbidden},
+	} {
+		t.Run(name, func(t *testing.T) {
+			token, err := jwt_helper.GenerateToken(1, test.role, 1, test.twoFactor)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != test.want {
+				t.Errorf("got status %d, want %d", w.Code, test.want)
+			}
+		})
+	}
+}
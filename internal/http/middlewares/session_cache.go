@@ -0,0 +1,96 @@
+package middlewares
+
+import (
+	"errors"
+	"go-api/internal/repositories"
+	"go-api/pkg/jwt_helper"
+	"sync"
+	"time"
+)
+
+// sessionCacheTTL bounds how long the access token of a session revoked by another API instance keeps working
+const (
+	sessionCacheTTL     = 30 * time.Second
+	sessionCacheMaxSize = 10000
+)
+
+var (
+	errNoSession      = errors.New("token without session")
+	errSessionRevoked = errors.New("session revoked")
+)
+
+type sessionCacheEntry struct {
+	revoked   bool
+	checkedAt time.Time
+}
+
+// sessionCache remembers for a while whether sessions are revoked, not to look them up on every request
+type sessionCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[uint]sessionCacheEntry
+	lookup  func(sessionId uint) (bool, error)
+}
+
+func newSessionCache(ttl time.Duration, lookup func(sessionId uint) (bool, error)) *sessionCache {
+	return &sessionCache{ttl: ttl, entries: make(map[uint]sessionCacheEntry), lookup: lookup}
+}
+
+var sessions = newSessionCache(sessionCacheTTL, func(sessionId uint) (bool, error) {
+	session, err := repositories.Setup().TokenRepository.GetSession(sessionId)
+	if err != nil {
+		return false, err
+	}
+	return session == nil || session.IsRevoked(), nil
+})
+
+// IsRevoked tells whether the session is revoked, a revoked session never comes back so it is remembered for good
+func (c *sessionCache) IsRevoked(sessionId uint, now time.Time) (bool, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[sessionId]
+	c.mu.Unlock()
+	if ok && (entry.revoked || now.Sub(entry.checkedAt) < c.ttl) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := c.lookup(sessionId)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= sessionCacheMaxSize {
+		c.prune(now)
+	}
+	c.entries[sessionId] = sessionCacheEntry{revoked: revoked, checkedAt: now}
+	return revoked, nil
+}
+
+// prune forgets the expired entries, or everything when none expired
+func (c *sessionCache) prune(now time.Time) {
+	for id, entry := range c.entries {
+		if now.Sub(entry.checkedAt) >= c.ttl {
+			delete(c.entries, id)
+		}
+	}
+	if len(c.entries) >= sessionCacheMaxSize {
+		c.entries = make(map[uint]sessionCacheEntry)
+	}
+}
+
+// checkSession rejects the access tokens whose session was logged out or revoked
+func checkSession(claims *jwt_helper.Claims) error {
+	if claims.SessionID == 0 {
+		return errNoSession
+	}
+
+	revoked, err := sessions.IsRevoked(claims.SessionID, time.Now())
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return errSessionRevoked
+	}
+	return nil
+}
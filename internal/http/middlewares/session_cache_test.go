@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionCache(t *testing.T) {
+	revoked := map[uint]bool{}
+	lookups := 0
+	cache := newSessionCache(time.Minute, func(sessionId uint) (bool, error) {
+		lookups++
+		return revoked[sessionId], nil
+	})
+	now := time.Now()
+
+	if isRevoked, _ := cache.IsRevoked(1, now); isRevoked {
+		t.Fatalf("active session reported revoked")
+	}
+
+	revoked[1] = true
+	if isRevoked, _ := cache.IsRevoked(1, now.Add(30*time.Second)); isRevoked || lookups != 1 {
+		t.Errorf("got revoked %t after %d lookups, want the cached active session", isRevoked, lookups)
+	}
+
+	if isRevoked, _ := cache.IsRevoked(1, now.Add(2*time.Minute)); !isRevoked {
+		t.Errorf("revoked session still active once the cache expired")
+	}
+
+	revoked[1] = false
+	if isRevoked, _ := cache.IsRevoked(1, now.Add(time.Hour)); !isRevoked || lookups != 2 {
+		t.Errorf("got revoked %t after %d lookups, want revoked sessions remembered", isRevoked, lookups)
+	}
+}
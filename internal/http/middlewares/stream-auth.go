@@ -0,0 +1,45 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-api/pkg/jwt_helper"
+	"net/http"
+)
+
+// StreamAuthMiddleware authenticates Server-Sent Events streams. Browsers' EventSource cannot send the Authorization header,
+// so a ticket of /auth/stream-ticket is accepted in the ticket query parameter instead.
+func StreamAuthMiddleware() gin.HandlerFunc {
+	currentUser := CurrentUserMiddleware(true)
+
+	return func(c *gin.Context) {
+		ticket := c.Query("ticket")
+		if ticket == "" {
+			currentUser(c)
+			return
+		}
+
+		claims, err := jwt_helper.VerifyToken(ticket, jwt_helper.TokenTypeStream)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid stream ticket: " + err.Error()})
+			c.Abort()
+			return
+		}
+
+		userId, err := claims.GetUserID()
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid stream ticket: " + err.Error()})
+			c.Abort()
+			return
+		}
+
+		if err := checkSession(claims); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session: " + err.Error()})
+			c.Abort()
+			return
+		}
+
+		c.Set("userId", userId)
+		c.Set("sessionId", claims.SessionID)
+		c.Next()
+	}
+}
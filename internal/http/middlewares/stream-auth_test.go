@@ -0,0 +1,56 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-api/pkg/jwt_helper"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStreamAuthMiddleware(t *testing.T) {
+	keyring, err := jwt_helper.NewKeyring(jwt_helper.NewHMACKey("hmac", []byte("secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwt_helper.SetKeyring(keyring)
+	sessions = newSessionCache(time.Minute, func(sessionId uint) (bool, error) {
+		return sessionId == 2, nil
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/sse", StreamAuthMiddleware(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"userId": c.GetUint("userId")})
+	})
+
+	ticket, _ := jwt_helper.GenerateStreamTicket(1, 1)
+	revokedTicket, _ := jwt_helper.GenerateStreamTicket(1, 2)
+	accessToken, _ := jwt_helper.GenerateToken(1, "user", 1, false)
+
+	for name, test := range map[string]struct {
+		query  string
+		header string
+		want   int
+	}{
+		"ticket":                    {query: "?ticket=" + ticket, want: http.StatusOK},
+		"ticket of revoked session": {query: "?ticket=" + revokedTicket, want: http.StatusUnauthorized},
+		"access token as ticket":    {query: "?ticket=" + accessToken, want: http.StatusUnauthorized},
+		"authorization header":      {header: "Bearer " + accessToken, want: http.StatusOK},
+		"nothing":                   {want: http.StatusUnauthorized},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/sse"+test.query, nil)
+			if test.header != "" {
+				req.Header.Set("Authorization", test.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != test.want {
+				t.Errorf("got status %d, want %d", w.Code, test.want)
+			}
+		})
+	}
+}
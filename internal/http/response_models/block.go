@@ -0,0 +1,29 @@
+package response_models
+
+import (
+	"go-api/pkg/model"
+	"time"
+)
+
+type GetRestrictedUserResponse struct {
+	User      GetUserResponseInterface
+	CreatedAt *time.Time
+}
+
+func FormatGetBlockResponse(block model.BlockModel) GetRestrictedUserResponse {
+	createdAt := time.Unix(int64(block.GetCreatedAt()), 0)
+
+	return GetRestrictedUserResponse{
+		User:      FormatGetUserResponse(block.GetBlocked()),
+		CreatedAt: &createdAt,
+	}
+}
+
+func FormatGetMuteResponse(mute model.MuteModel) GetRestrictedUserResponse {
+	createdAt := time.Unix(int64(mute.GetCreatedAt()), 0)
+
+	return GetRestrictedUserResponse{
+		User:      FormatGetUserResponse(mute.GetMuted()),
+		CreatedAt: &createdAt,
+	}
+}
@@ -24,6 +24,16 @@ type GetDropResponse struct {
 	TotalLikes          int
 	IsCurrentUserLiking bool `json:",omitempty"`
 	IsPinned            bool `json:",omitempty"`
+	IsLate              bool
+	// LateBy is the number of seconds between the end of the response window and the drop
+	LateBy int
+	// ContentDuration is expressed in seconds, 0 when the provider does not know it
+	ContentDuration    int      `json:",omitempty"`
+	ContentReleaseYear int      `json:",omitempty"`
+	ContentGenres      []string `json:",omitempty"`
+	ContentLink        string   `json:",omitempty"`
+	// Audience is one of "followers", "close_friends" or "groups"
+	Audience string
 }
 
 func FormatGetDropResponse(drop model.DropModel, isCurrentUserLiking bool) GetDropResponse {
@@ -58,9 +68,22 @@ func FormatGetDropResponse(drop model.DropModel, isCurrentUserLiking bool) GetDr
 		TotalLikes:          drop.GetTotalLikes(),
 		IsCurrentUserLiking: isCurrentUserLiking,
 		IsPinned:            drop.GetIsPinned(),
+		IsLate:              drop.GetIsLate(),
+		LateBy:              drop.GetLateBy(),
+		ContentDuration:     drop.GetContentDuration(),
+		ContentReleaseYear:  drop.GetContentReleaseYear(),
+		ContentGenres:       drop.GetContentGenres(),
+		ContentLink:         drop.GetContentLink(),
+		Audience:            drop.GetAudience(),
 	}
 }
 
+type GetDropFeedPageResponse struct {
+	Drops []GetDropResponse
+	// NextCursor is empty on the last page
+	NextCursor string
+}
+
 type HasUserDroppedTodayResponse struct {
 	Status bool `json:"status"`
 }
@@ -1,11 +1,22 @@
 package response_models
 
-import "go-api/pkg/model"
+import (
+	"go-api/pkg/drop_type_apis"
+	"go-api/pkg/model"
+	"time"
+)
 
 type GetDropNotificationResponse struct {
-	ID        uint
-	Type      string
-	CreatedAt string
+	ID          uint
+	Type        string
+	Kind        string `json:",omitempty"`
+	CreatedAt   string
+	Status      int
+	ScheduledAt *time.Time
+	FiredAt     *time.Time
+	// ResponseWindow is expressed in minutes
+	ResponseWindow int
+	Deadline       *time.Time
 }
 
 func FormatGetDropNotificationResponse(dropNotification model.DropNotificationModel) GetDropNotificationResponse {
@@ -14,8 +25,36 @@ func FormatGetDropNotificationResponse(dropNotification model.DropNotificationMo
 	}
 
 	return GetDropNotificationResponse{
-		ID:        dropNotification.GetID(),
-		Type:      dropNotification.GetType(),
-		CreatedAt: dropNotification.GetCreatedAt(),
+		ID:             dropNotification.GetID(),
+		Type:           dropNotification.GetType(),
+		Kind:           dropNotification.GetKind(),
+		CreatedAt:      dropNotification.GetCreatedAt(),
+		Status:         dropNotification.GetStatus(),
+		ScheduledAt:    unixToTime(dropNotification.GetScheduledAt()),
+		FiredAt:        unixToTime(dropNotification.GetFiredAt()),
+		ResponseWindow: dropNotification.GetResponseWindow(),
+		Deadline:       unixToTime(dropNotification.GetDeadline()),
 	}
 }
+
+type GetDropTypeResponse struct {
+	Type        string
+	DisplayName string
+	Kinds       []string `json:",omitempty"`
+}
+
+func FormatGetDropTypeResponse(provider drop_type_apis.Provider) GetDropTypeResponse {
+	return GetDropTypeResponse{
+		Type:        provider.Type,
+		DisplayName: provider.DisplayName,
+		Kinds:       provider.Kinds,
+	}
+}
+
+func unixToTime(timestamp int) *time.Time {
+	if timestamp == 0 {
+		return nil
+	}
+	t := time.Unix(int64(timestamp), 0)
+	return &t
+}
@@ -48,3 +48,15 @@ func FormatGetOneFollowResponse(follow model.FollowModel) GetOneFollowResponse {
 		Status:    follow.GetStatus(),
 	}
 }
+
+type FollowRequestEventResponse struct {
+	Transition model.FollowRequestTransition `json:"transition"`
+	Follow     GetOneFollowResponse          `json:"follow"`
+}
+
+func FormatFollowRequestEventResponse(follow model.FollowModel, transition model.FollowRequestTransition) FollowRequestEventResponse {
+	return FollowRequestEventResponse{
+		Transition: transition,
+		Follow:     FormatGetOneFollowResponse(follow),
+	}
+}
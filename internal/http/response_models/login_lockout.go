@@ -0,0 +1,35 @@
+package response_models
+
+import (
+	"go-api/pkg/model"
+	"time"
+)
+
+type GetLoginLockoutResponse struct {
+	ID          uint
+	Key         string
+	Scope       string
+	Failures    int
+	LockedUntil *time.Time
+	CreatedAt   *time.Time
+	Active      bool
+}
+
+func FormatGetLoginLockoutResponse(lockout model.LoginLockoutModel) GetLoginLockoutResponse {
+	if nil == lockout {
+		return GetLoginLockoutResponse{}
+	}
+
+	lockedUntil := time.Unix(int64(lockout.GetLockedUntil()), 0)
+	createdAt := time.Unix(int64(lockout.GetCreatedAt()), 0)
+
+	return GetLoginLockoutResponse{
+		ID:          lockout.GetID(),
+		Key:         lockout.GetKey(),
+		Scope:       lockout.GetScope(),
+		Failures:    lockout.GetFailures(),
+		LockedUntil: &lockedUntil,
+		CreatedAt:   &createdAt,
+		Active:      lockedUntil.After(time.Now()),
+	}
+}
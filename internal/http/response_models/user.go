@@ -253,3 +253,66 @@ func FormatGetOneUserResponse(
 		CurrentFollow:  currentFollowPointer,
 	}
 }
+
+type GetUserSuggestionResponse struct {
+	User GetUserResponseInterface
+	// Reasons are displayable, e.g. "3 mutual friends"
+	Reasons        []string
+	MutualFollows  int
+	SharedGroups   int
+	SharedContents int
+}
+
+func FormatGetUserSuggestionResponse(suggestion model.UserSuggestion) GetUserSuggestionResponse {
+	return GetUserSuggestionResponse{
+		User:           FormatGetUserResponse(suggestion.User),
+		Reasons:        suggestion.Reasons,
+		MutualFollows:  suggestion.Signals.MutualFollows,
+		SharedGroups:   suggestion.Signals.SharedGroups,
+		SharedContents: suggestion.Signals.SharedContents,
+	}
+}
+
+type GetCloseFriendResponse struct {
+	User      GetUserResponseInterface
+	CreatedAt *time.Time
+}
+
+func FormatGetCloseFriendResponse(closeFriend model.CloseFriendModel) GetCloseFriendResponse {
+	createdAt := time.Unix(int64(closeFriend.GetCreatedAt()), 0)
+
+	return GetCloseFriendResponse{
+		User:      FormatGetUserResponse(closeFriend.GetFriend()),
+		CreatedAt: &createdAt,
+	}
+}
+
+type StreamTicketResponse struct {
+	Ticket string `json:"ticket"`
+	// ExpiresIn is the number of seconds left to open a stream with the ticket
+	ExpiresIn int `json:"expiresIn"`
+}
+
+type GetSessionResponse struct {
+	ID         uint       `json:"id"`
+	DeviceName string     `json:"deviceName"`
+	IPAddress  string     `json:"ipAddress"`
+	CreatedAt  *time.Time `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	// Current is true for the session of the access token used to list the sessions
+	Current bool `json:"current"`
+}
+
+func FormatGetSessionResponse(session model.AuthSessionModel, currentSessionId uint) GetSessionResponse {
+	createdAt := time.Unix(int64(session.GetCreatedAt()), 0)
+	lastUsedAt := time.Unix(int64(session.GetLastUsedAt()), 0)
+
+	return GetSessionResponse{
+		ID:         session.GetID(),
+		DeviceName: session.GetDeviceName(),
+		IPAddress:  session.GetIPAddress(),
+		CreatedAt:  &createdAt,
+		LastUsedAt: &lastUsedAt,
+		Current:    session.GetID() == currentSessionId,
+	}
+}
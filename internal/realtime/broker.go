@@ -0,0 +1,107 @@
+package realtime
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Broker carries events between the API replicas, every replica then delivers them to its own connections
+type Broker interface {
+	Publish(event Event) error
+	// Subscribe registers the handler called for every published event, including the ones of this replica
+	Subscribe(handler func(event Event))
+	// History returns the events of the user on the channel, broadcasts included, published after lastEventID.
+	// complete is false when some of them are not kept anymore.
+	History(channel Channel, userID uint, lastEventID uint) (events []Event, complete bool, err error)
+	LastEventID() (uint, error)
+	Close() error
+}
+
+// NewBrokerFromEnv uses REALTIME_BROKER, "postgres" to fan events out through LISTEN/NOTIFY
+// and "memory" (the default) when a single API instance is running.
+func NewBrokerFromEnv() Broker {
+	switch os.Getenv("REALTIME_BROKER") {
+	case "postgres":
+		return NewPostgresBroker(NewPostgresBrokerConfigFromEnv())
+	case "", "memory":
+		return NewMemoryBroker()
+	default:
+		log.Printf("Error: Unknown REALTIME_BROKER %s, falling back to memory\n", os.Getenv("REALTIME_BROKER"))
+		return NewMemoryBroker()
+	}
+}
+
+const defaultMemoryHistorySize = 1000
+
+// MemoryBroker delivers events synchronously inside the process and keeps the last ones for History
+type MemoryBroker struct {
+	mu       sync.RWMutex
+	handlers []func(event Event)
+	lastID   uint
+	history  []Event
+	// historySize is the number of events kept, the oldest ones are dropped first
+	historySize int
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{historySize: defaultMemoryHistorySize}
+}
+
+func (b *MemoryBroker) Publish(event Event) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	b.mu.Lock()
+	b.lastID++
+	event.ID = b.lastID
+	b.history = append(b.history, event)
+	if len(b.history) > b.historySize {
+		b.history = b.history[len(b.history)-b.historySize:]
+	}
+	handlers := b.handlers
+	b.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(handler func(event Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+func (b *MemoryBroker) History(channel Channel, userID uint, lastEventID uint) ([]Event, bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	// IDs start over when the process restarts, an ID above the last one comes from a previous run
+	complete := lastEventID == b.lastID ||
+		(lastEventID < b.lastID && len(b.history) > 0 && b.history[0].ID <= lastEventID+1)
+
+	var events []Event
+	for _, event := range b.history {
+		if event.ID > lastEventID && event.Channel == channel && (event.UserID == 0 || event.UserID == userID) {
+			events = append(events, event)
+		}
+	}
+	return events, complete, nil
+}
+
+func (b *MemoryBroker) LastEventID() (uint, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.lastID, nil
+}
+
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = nil
+	return nil
+}
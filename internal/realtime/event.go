@@ -0,0 +1,29 @@
+package realtime
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Channel is the kind of events a connection listens to
+type Channel string
+
+const (
+	// ChannelFeed carries the drops of the current notification, every event is a list of one or several drops
+	ChannelFeed Channel = "feed"
+	// ChannelHasDropped carries response_models.HasUserDroppedTodayResponse
+	ChannelHasDropped Channel = "has-dropped"
+	// ChannelPendingFollows carries the follow requests waiting for an answer
+	ChannelPendingFollows Channel = "pending-follows"
+	// ChannelFollowRequests carries what happened to the follow requests a user sent or received
+	ChannelFollowRequests Channel = "follow-requests"
+)
+
+type Event struct {
+	ID      uint
+	Channel Channel
+	// UserID is 0 for events sent to every user connected on the channel
+	UserID    uint
+	Payload   json.RawMessage
+	CreatedAt time.Time
+}
@@ -0,0 +1,285 @@
+package realtime
+
+import (
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type HubConfig struct {
+	// PingInterval must be shorter than PongWait so healthy clients answer before their deadline
+	PingInterval time.Duration
+	PongWait     time.Duration
+	WriteWait    time.Duration
+	// SendBufferSize is the number of messages queued for a client before it is considered too slow and disconnected
+	SendBufferSize int
+	MaxMessageSize int64
+	// ReplayOverlap is the number of events before Last-Event-ID replayed to reconnecting streams,
+	// event IDs are not committed in order so an event with a lower ID can be published after the last one a client got
+	ReplayOverlap uint
+}
+
+// NewHubConfigFromEnv reads REALTIME_PING_INTERVAL_SECONDS, REALTIME_SEND_BUFFER_SIZE and REALTIME_REPLAY_OVERLAP
+func NewHubConfigFromEnv() HubConfig {
+	config := HubConfig{
+		PingInterval:   30 * time.Second,
+		WriteWait:      10 * time.Second,
+		SendBufferSize: 32,
+		MaxMessageSize: 4096,
+		ReplayOverlap:  50,
+	}
+
+	if pingInterval, err := strconv.Atoi(os.Getenv("REALTIME_PING_INTERVAL_SECONDS")); err == nil && pingInterval > 0 {
+		config.PingInterval = time.Duration(pingInterval) * time.Second
+	}
+
+	if sendBufferSize, err := strconv.Atoi(os.Getenv("REALTIME_SEND_BUFFER_SIZE")); err == nil && sendBufferSize > 0 {
+		config.SendBufferSize = sendBufferSize
+	}
+
+	if value := os.Getenv("REALTIME_REPLAY_OVERLAP"); value != "" {
+		replayOverlap, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			log.Printf("Error: Invalid REALTIME_REPLAY_OVERLAP %s, falling back to %d\n", value, config.ReplayOverlap)
+		} else {
+			config.ReplayOverlap = uint(replayOverlap)
+		}
+	}
+
+	config.PongWait = config.PingInterval * 2
+
+	return config
+}
+
+// Hub keeps the websocket connections of this replica and delivers the broker events to them.
+// A user can be connected several times on the same channel, e.g. from two devices.
+type Hub struct {
+	broker Broker
+	config HubConfig
+
+	mu      sync.RWMutex
+	clients map[Channel]map[uint]map[*Client]struct{}
+}
+
+func NewHub(broker Broker, config HubConfig) *Hub {
+	hub := &Hub{
+		broker:  broker,
+		config:  config,
+		clients: make(map[Channel]map[uint]map[*Client]struct{}),
+	}
+	broker.Subscribe(hub.dispatch)
+	return hub
+}
+
+// Publish sends the payload to every connection of the user on the channel, on all replicas
+func (h *Hub) Publish(channel Channel, userID uint, payload interface{}) error {
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return h.broker.Publish(Event{Channel: channel, UserID: userID, Payload: message})
+}
+
+// Broadcast sends the payload to every connection on the channel, on all replicas
+func (h *Hub) Broadcast(channel Channel, payload interface{}) error {
+	return h.Publish(channel, 0, payload)
+}
+
+// History returns the events the user missed on the channel since lastEventID, see Broker.History
+func (h *Hub) History(channel Channel, userID uint, lastEventID uint) ([]Event, bool, error) {
+	return h.broker.History(channel, userID, lastEventID)
+}
+
+func (h *Hub) LastEventID() (uint, error) {
+	return h.broker.LastEventID()
+}
+
+func (h *Hub) Config() HubConfig {
+	return h.config
+}
+
+// Register attaches a websocket connection to the hub, Run must then be called to serve it
+func (h *Hub) Register(channel Channel, userID uint, conn *websocket.Conn) *Client {
+	client := h.Listen(channel, userID)
+	client.conn = conn
+	return client
+}
+
+// Listen subscribes to the channel without a websocket, events are read from Events until Close is called
+func (h *Hub) Listen(channel Channel, userID uint) *Client {
+	client := &Client{
+		hub:     h,
+		channel: channel,
+		userID:  userID,
+		send:    make(chan Event, h.config.SendBufferSize),
+		done:    make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	if h.clients[channel] == nil {
+		h.clients[channel] = make(map[uint]map[*Client]struct{})
+	}
+	if h.clients[channel][userID] == nil {
+		h.clients[channel][userID] = make(map[*Client]struct{})
+	}
+	h.clients[channel][userID][client] = struct{}{}
+	h.mu.Unlock()
+
+	log.Printf("Info: User %d connected to %s\n", userID, channel)
+	return client
+}
+
+func (h *Hub) unregister(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	userClients := h.clients[client.channel][client.userID]
+	if _, ok := userClients[client]; !ok {
+		return
+	}
+
+	delete(userClients, client)
+	if len(userClients) == 0 {
+		delete(h.clients[client.channel], client.userID)
+	}
+	log.Printf("Info: User %d disconnected from %s\n", client.userID, client.channel)
+}
+
+func (h *Hub) dispatch(event Event) {
+	h.mu.RLock()
+	var recipients []*Client
+	if event.UserID == 0 {
+		for _, userClients := range h.clients[event.Channel] {
+			for client := range userClients {
+				recipients = append(recipients, client)
+			}
+		}
+	} else {
+		for client := range h.clients[event.Channel][event.UserID] {
+			recipients = append(recipients, client)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, client := range recipients {
+		client.enqueue(event)
+	}
+}
+
+// Client is one connection of a user on a channel, conn is nil for connections which are not websockets
+type Client struct {
+	hub     *Hub
+	conn    *websocket.Conn
+	channel Channel
+	userID  uint
+
+	send      chan Event
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Send queues a message for this connection only, e.g. the initial state of the channel
+func (c *Client) Send(payload interface{}) error {
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	c.enqueue(Event{Channel: c.channel, UserID: c.userID, Payload: message, CreatedAt: time.Now()})
+	return nil
+}
+
+// Events returns the events to write to the connection
+func (c *Client) Events() <-chan Event {
+	return c.send
+}
+
+// Done is closed once the connection is closed, by the hub or by Close
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close unregisters the connection, websockets are closed by Run
+func (c *Client) Close() {
+	c.close()
+}
+
+// enqueue never blocks the hub, clients which do not read their messages fast enough are disconnected
+func (c *Client) enqueue(event Event) {
+	select {
+	case <-c.done:
+	case c.send <- event:
+	default:
+		log.Printf("Error: User %d is too slow on %s, closing the connection\n", c.userID, c.channel)
+		c.close()
+	}
+}
+
+func (c *Client) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.hub.unregister(c)
+	})
+}
+
+// Run serves the connection until the client leaves or stops answering pings
+func (c *Client) Run() {
+	go c.writePump()
+	c.readPump()
+}
+
+func (c *Client) readPump() {
+	defer c.close()
+
+	c.conn.SetReadLimit(c.hub.config.MaxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(c.hub.config.PongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(c.hub.config.PongWait))
+	})
+
+	for {
+		// Clients are not expected to send anything, reading is needed to process pongs and close frames
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("Error: Error reading from user %d on %s: %v\n", c.userID, c.channel, err)
+			}
+			return
+		}
+	}
+}
+
+func (c *Client) writePump() {
+	ticker := time.NewTicker(c.hub.config.PingInterval)
+	defer func() {
+		ticker.Stop()
+		if err := c.conn.Close(); err != nil {
+			log.Printf("Error: Error closing WebSocket connection: %v\n", err)
+		}
+	}()
+
+	for {
+		select {
+		case <-c.done:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(c.hub.config.WriteWait))
+			_ = c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+			return
+		case event := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(c.hub.config.WriteWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, event.Payload); err != nil {
+				log.Printf("Error: Error sending message to user %d on %s: %v\n", c.userID, c.channel, err)
+				c.close()
+				return
+			}
+		case <-ticker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(c.hub.config.WriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.close()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package realtime
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestHub(sendBufferSize int) *Hub {
+	return NewHub(NewMemoryBroker(), HubConfig{
+		PingInterval:   time.Second,
+		PongWait:       2 * time.Second,
+		WriteWait:      time.Second,
+		SendBufferSize: sendBufferSize,
+		MaxMessageSize: 512,
+	})
+}
+
+func TestHub_PublishReachesEveryConnectionOfTheUser(t *testing.T) {
+	hub := newTestHub(8)
+
+	// Events are not read, they stay in the send buffers
+	firstDevice := hub.Listen(ChannelFeed, 1)
+	secondDevice := hub.Listen(ChannelFeed, 1)
+	otherUser := hub.Listen(ChannelFeed, 2)
+	otherChannel := hub.Listen(ChannelHasDropped, 1)
+
+	if err := hub.Publish(ChannelFeed, 1, map[string]bool{"status": true}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	for name, client := range map[string]*Client{"first device": firstDevice, "second device": secondDevice} {
+		select {
+		case event := <-client.send:
+			if string(event.Payload) != `{"status":true}` {
+				t.Errorf("%s received %s, want %s", name, event.Payload, `{"status":true}`)
+			}
+		default:
+			t.Errorf("%s did not receive the event", name)
+		}
+	}
+
+	if len(otherUser.send) != 0 || len(otherChannel.send) != 0 {
+		t.Errorf("event was delivered outside of its user and channel")
+	}
+
+	if err := hub.Broadcast(ChannelFeed, []int{}); err != nil {
+		t.Fatalf("Broadcast() error = %v", err)
+	}
+
+	for _, client := range []*Client{firstDevice, secondDevice, otherUser} {
+		if len(client.send) != 1 {
+			t.Errorf("broadcast was not delivered to user %d", client.userID)
+		}
+	}
+}
+
+func TestHub_SlowClientIsDisconnected(t *testing.T) {
+	hub := newTestHub(1)
+	client := hub.Listen(ChannelFeed, 1)
+
+	for i := 0; i < 2; i++ {
+		if err := hub.Publish(ChannelFeed, 1, i); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	select {
+	case <-client.done:
+	default:
+		t.Fatal("slow client was not closed")
+	}
+
+	if len(hub.clients[ChannelFeed][1]) != 0 {
+		t.Errorf("slow client is still registered")
+	}
+}
+
+func TestMemoryBroker_History(t *testing.T) {
+	broker := NewMemoryBroker()
+	broker.historySize = 3
+
+	for _, event := range []Event{
+		{Channel: ChannelFeed, UserID: 1},
+		{Channel: ChannelFeed, UserID: 2},
+		{Channel: ChannelHasDropped, UserID: 0},
+		{Channel: ChannelFeed, UserID: 0},
+		{Channel: ChannelFeed, UserID: 1},
+	} {
+		if err := broker.Publish(event); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		lastEventID  uint
+		wantIDs      []uint
+		wantComplete bool
+	}{
+		{name: "Test resume inside the history", lastEventID: 2, wantIDs: []uint{4, 5}, wantComplete: true},
+		{name: "Test resume after the oldest kept event", lastEventID: 3, wantIDs: []uint{4, 5}, wantComplete: true},
+		{name: "Test resume from a dropped event", lastEventID: 1, wantIDs: []uint{4, 5}, wantComplete: false},
+		{name: "Test nothing missed", lastEventID: 5, wantIDs: nil, wantComplete: true},
+		{name: "Test ID of a previous run", lastEventID: 42, wantIDs: nil, wantComplete: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, complete, err := broker.History(ChannelFeed, 1, tt.lastEventID)
+			if err != nil {
+				t.Fatalf("History() error = %v", err)
+			}
+
+			var ids []uint
+			for _, event := range events {
+				ids = append(ids, event.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) || complete != tt.wantComplete {
+				t.Errorf("History() = %v, %v, want %v, %v", ids, complete, tt.wantIDs, tt.wantComplete)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package realtime
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"go-api/internal/storage/postgres"
+	"go-api/pkg/model"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const postgresNotifyChannel = "droppy_realtime"
+
+type PostgresBrokerConfig struct {
+	DSN string
+	// Retention is how long published events are kept in realtime_events
+	Retention time.Duration
+}
+
+// NewPostgresBrokerConfigFromEnv reads REALTIME_EVENT_RETENTION_MINUTES, 60 minutes by default
+func NewPostgresBrokerConfigFromEnv() PostgresBrokerConfig {
+	config := PostgresBrokerConfig{
+		DSN:       postgres.DSN(),
+		Retention: time.Hour,
+	}
+
+	if value := os.Getenv("REALTIME_EVENT_RETENTION_MINUTES"); value != "" {
+		retention, err := strconv.Atoi(value)
+		if err != nil || retention <= 0 {
+			log.Printf("Error: Invalid REALTIME_EVENT_RETENTION_MINUTES %s, falling back to 60 minutes\n", value)
+		} else {
+			config.Retention = time.Duration(retention) * time.Minute
+		}
+	}
+
+	return config
+}
+
+// PostgresBroker stores events in realtime_events and sends their ID with NOTIFY,
+// every replica LISTENs on a dedicated connection and loads the events it is notified of.
+type PostgresBroker struct {
+	config PostgresBrokerConfig
+	repo   model.RealtimeEventRepository
+
+	mu       sync.RWMutex
+	handlers []func(event Event)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewPostgresBroker(config PostgresBrokerConfig) *PostgresBroker {
+	ctx, cancel := context.WithCancel(context.Background())
+	broker := &PostgresBroker{
+		config: config,
+		repo:   postgres.NewRealtimeEventRepo(postgres.Connect()),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	go broker.listen()
+	go broker.cleanup()
+
+	return broker
+}
+
+func (b *PostgresBroker) Publish(event Event) error {
+	_, err := b.repo.Create(postgresNotifyChannel, string(event.Channel), event.UserID, event.Payload)
+	return err
+}
+
+func (b *PostgresBroker) Subscribe(handler func(event Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+func (b *PostgresBroker) History(channel Channel, userID uint, lastEventID uint) ([]Event, bool, error) {
+	oldestID, newestID, err := b.repo.GetEventIDRange()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if lastEventID > newestID || (lastEventID < newestID && oldestID > lastEventID+1) {
+		return nil, false, nil
+	}
+
+	storedEvents, err := b.repo.GetUserEventsSince(string(channel), userID, lastEventID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var events []Event
+	for _, storedEvent := range storedEvents {
+		events = append(events, toEvent(storedEvent))
+	}
+	return events, true, nil
+}
+
+func (b *PostgresBroker) LastEventID() (uint, error) {
+	_, newestID, err := b.repo.GetEventIDRange()
+	return newestID, err
+}
+
+func (b *PostgresBroker) Close() error {
+	b.cancel()
+	return nil
+}
+
+// listen reconnects until the broker is closed, events published while disconnected are lost
+func (b *PostgresBroker) listen() {
+	backoff := time.Second
+	for {
+		err := b.listenOnce()
+		if b.ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("Error: Realtime listener disconnected, retrying in %v: %v\n", backoff, err)
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+func (b *PostgresBroker) listenOnce() error {
+	conn, err := pgx.Connect(b.ctx, b.config.DSN)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(b.ctx, "LISTEN "+postgresNotifyChannel); err != nil {
+		return err
+	}
+	log.Println("Info: Realtime listener connected")
+
+	for {
+		notification, err := conn.WaitForNotification(b.ctx)
+		if err != nil {
+			return err
+		}
+
+		eventId, err := strconv.ParseUint(notification.Payload, 10, 64)
+		if err != nil {
+			log.Printf("Error: Invalid realtime notification payload %s\n", notification.Payload)
+			continue
+		}
+
+		b.deliver(uint(eventId))
+	}
+}
+
+func (b *PostgresBroker) deliver(eventId uint) {
+	storedEvent, err := b.repo.GetEventByID(eventId)
+	if err != nil {
+		log.Printf("Error: Error loading realtime event %d: %v\n", eventId, err)
+		return
+	}
+	if storedEvent == nil {
+		return
+	}
+
+	event := toEvent(storedEvent)
+
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+func (b *PostgresBroker) cleanup() {
+	ticker := time.NewTicker(b.config.Retention / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := b.repo.DeleteOlderThan(time.Now().Add(-b.config.Retention)); err != nil {
+				log.Printf("Error: Error deleting old realtime events: %v\n", err)
+			}
+		}
+	}
+}
+
+func toEvent(storedEvent model.RealtimeEventModel) Event {
+	return Event{
+		ID:        storedEvent.GetID(),
+		Channel:   Channel(storedEvent.GetChannel()),
+		UserID:    storedEvent.GetUserID(),
+		Payload:   storedEvent.GetPayload(),
+		CreatedAt: time.Unix(int64(storedEvent.GetCreatedAt()), 0),
+	}
+}
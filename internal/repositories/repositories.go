@@ -18,6 +18,15 @@ type Repositories struct {
 	CommentResponseRepository  model.CommentResponseRepository
 	LikeRepository             model.LikeRepository
 	ReportRepository           model.ReportRepository
+	RealtimeEventRepository    model.RealtimeEventRepository
+	DropViewRepository         model.DropViewRepository
+	UserSuggestionRepository   model.UserSuggestionRepository
+	BlockRepository            model.BlockRepository
+	MuteRepository             model.MuteRepository
+	CloseFriendRepository      model.CloseFriendRepository
+	UserTokenRepository        model.UserTokenRepository
+	LoginAttemptRepository     model.LoginAttemptRepository
+	TwoFactorRepository        model.TwoFactorRepository
 }
 
 func Setup() *Repositories {
@@ -36,6 +45,15 @@ func Setup() *Repositories {
 		CommentResponseRepository:  postgres.NewCommentResponseRepo(sqlDB),
 		LikeRepository:             postgres.NewLikeRepo(sqlDB),
 		ReportRepository:           postgres.NewReportRepo(sqlDB),
+		RealtimeEventRepository:    postgres.NewRealtimeEventRepo(sqlDB),
+		DropViewRepository:         postgres.NewDropViewRepo(sqlDB),
+		UserSuggestionRepository:   postgres.NewUserSuggestionRepo(sqlDB),
+		BlockRepository:            postgres.NewBlockRepo(sqlDB),
+		MuteRepository:             postgres.NewMuteRepo(sqlDB),
+		CloseFriendRepository:      postgres.NewCloseFriendRepo(sqlDB),
+		UserTokenRepository:        postgres.NewUserTokenRepo(sqlDB),
+		LoginAttemptRepository:     postgres.NewLoginAttemptRepo(sqlDB),
+		TwoFactorRepository:        postgres.NewTwoFactorRepo(sqlDB),
 	}
 }
 
@@ -0,0 +1,217 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+	"go-api/pkg/errors2"
+	"go-api/pkg/hash"
+	"go-api/pkg/mailer"
+	"go-api/pkg/model"
+	"go-api/pkg/random"
+	"go-api/pkg/validation"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	emailVerificationTokenLifetime = 48 * time.Hour
+	passwordResetTokenLifetime     = time.Hour
+	// userPendingVerificationStatus is the status of the accounts whose email address is not verified yet
+	userPendingVerificationStatus = 0
+)
+
+var (
+	ErrInvalidEmailToken = errors.New("invalid or expired token")
+	ErrEmailNotVerified  = errors.New("email not verified")
+)
+
+// SendEmailVerification emails the user a link to activate their account
+func (a *AccountService) SendEmailVerification(user model.UserModel) error {
+	token, err := a.createUserToken(user.GetID(), model.UserTokenEmailVerification, emailVerificationTokenLifetime)
+	if err != nil {
+		return err
+	}
+
+	return a.send(mailer.Message{
+		To:      user.GetEmail(),
+		Subject: "Verify your Droppy account",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nWelcome to Droppy! Confirm your email address to activate your account:\n%s\n\nThis link expires in %d hours.",
+			user.GetUsername(), emailLink("verify-email", token), int(emailVerificationTokenLifetime.Hours()),
+		),
+	})
+}
+
+// ResendEmailVerification sends a new verification link, it does nothing for unknown or already verified addresses
+func (a *AccountService) ResendEmailVerification(email string) error {
+	if allowed, err := a.allowEmail("verification", email); err != nil || !allowed {
+		return err
+	}
+
+	user, err := a.Repo.UserRepository.GetByEmail(email)
+	if err != nil || user == nil || user.GetStatus() != userPendingVerificationStatus {
+		return nil
+	}
+
+	return a.SendEmailVerification(user)
+}
+
+// VerifyEmail activates the account of the token's user
+func (a *AccountService) VerifyEmail(token string) error {
+	userToken, err := a.Repo.UserTokenRepository.Consume(model.UserTokenEmailVerification, hash.HashToken(token))
+	if err != nil {
+		return err
+	}
+	if userToken == nil {
+		return ErrInvalidEmailToken
+	}
+
+	user, err := a.Repo.UserRepository.GetById(userToken.GetUserID())
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrInvalidEmailToken
+	}
+
+	// Banned users stay banned
+	if user.GetStatus() != userPendingVerificationStatus {
+		return nil
+	}
+
+	_, err = a.Repo.UserRepository.Update(user.GetID(), map[string]interface{}{"status": 1})
+	return err
+}
+
+// RequestPasswordReset emails a password reset link, it does nothing for unknown addresses so they can't be guessed
+func (a *AccountService) RequestPasswordReset(email string) error {
+	if allowed, err := a.allowEmail("password-reset", email); err != nil || !allowed {
+		return err
+	}
+
+	user, err := a.Repo.UserRepository.GetByEmail(email)
+	if err != nil || user == nil {
+		return nil
+	}
+
+	token, err := a.createUserToken(user.GetID(), model.UserTokenPasswordReset, passwordResetTokenLifetime)
+	if err != nil {
+		return err
+	}
+
+	return a.send(mailer.Message{
+		To:      user.GetEmail(),
+		Subject: "Reset your Droppy password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nSomeone asked to reset your Droppy password. Choose a new one here:\n%s\n\nThis link expires in %d minutes. If you did not ask for it, you can ignore this email.",
+			user.GetUsername(), emailLink("reset-password", token), int(passwordResetTokenLifetime.Minutes()),
+		),
+	})
+}
+
+// ResetPassword sets the password of the token's user and logs them out of every device
+func (a *AccountService) ResetPassword(token string, password string) error {
+	validationError := validation.ValidatePassword("password", password)
+	if len(validationError.Fields) > 0 {
+		return validationError
+	}
+
+	userToken, err := a.Repo.UserTokenRepository.Consume(model.UserTokenPasswordReset, hash.HashToken(token))
+	if err != nil {
+		return err
+	}
+	if userToken == nil {
+		return ErrInvalidEmailToken
+	}
+
+	if err := a.setPassword(userToken.GetUserID(), password); err != nil {
+		return err
+	}
+
+	return a.Repo.TokenRepository.RevokeUserSessions(userToken.GetUserID())
+}
+
+// ChangePassword replaces the password of a logged-in user and logs them out of their other sessions
+func (a *AccountService) ChangePassword(userId uint, sessionId uint, currentPassword string, newPassword string) error {
+	validationError := validation.ValidatePassword("newPassword", newPassword)
+	if len(validationError.Fields) > 0 {
+		return validationError
+	}
+
+	user, err := a.Repo.UserRepository.GetById(userId)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors2.NotFoundError{Entity: "User"}
+	}
+
+	if user.GetPassword() == "" {
+		return errors2.NotAllowedError{Reason: "Your account has no password, use the password reset instead"}
+	}
+
+	match, err := hash.ComparePasswordAndHash(currentPassword, user.GetPassword())
+	if err != nil {
+		return err
+	}
+	if !match {
+		return errors2.MultiFieldsError{Fields: map[string]string{"currentPassword": "Wrong password"}}
+	}
+
+	if err := a.setPassword(userId, newPassword); err != nil {
+		return err
+	}
+
+	return a.Repo.TokenRepository.RevokeOtherSessions(userId, sessionId)
+}
+
+func (a *AccountService) setPassword(userId uint, password string) error {
+	hashedPassword, err := hash.GenerateFromPassword(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.Repo.UserRepository.Update(userId, map[string]interface{}{"password": hashedPassword})
+	return err
+}
+
+// createUserToken returns a new single use token, only its hash is stored
+func (a *AccountService) createUserToken(userId uint, purpose model.UserTokenPurpose, lifetime time.Duration) (string, error) {
+	token, err := random.SecureToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := a.Repo.UserTokenRepository.Create(userId, purpose, hash.HashToken(token), time.Now().Add(lifetime)); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (a *AccountService) send(message mailer.Message) error {
+	if a.Mailer == nil {
+		log.Printf("Error: No mailer configured, email to %s not sent\n", message.To)
+		return nil
+	}
+	return a.Mailer.Send(message)
+}
+
+// emailLink points to the page of the app handling the token, EMAIL_LINK_BASE_URL falls back to BASE_URL
+func emailLink(path string, token string) string {
+	baseURL := os.Getenv("EMAIL_LINK_BASE_URL")
+	if baseURL == "" {
+		baseURL = os.Getenv("BASE_URL")
+	}
+	return fmt.Sprintf("%s/%s?token=%s", strings.TrimSuffix(baseURL, "/"), path, token)
+}
+
+// allowEmail tells whether an email can be sent to the address, throttled emails are silently dropped
+func (a *AccountService) allowEmail(kind string, email string) (bool, error) {
+	if a.Throttle == nil {
+		return true, nil
+	}
+	return a.Throttle.AllowEmail(kind, email, a.Device.IPAddress, time.Now())
+}
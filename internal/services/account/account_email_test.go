@@ -0,0 +1,54 @@
+package account
+
+import (
+	"errors"
+	"go-api/internal/repositories"
+	"go-api/pkg/errors2"
+	"go-api/pkg/mailer"
+	"go-api/pkg/model"
+	"testing"
+	"time"
+)
+
+type MockUserTokenRepository struct{}
+
+func (m *MockUserTokenRepository) Create(userID uint, purpose model.UserTokenPurpose, tokenHash string, expiresAt time.Time) (model.UserTokenModel, error) {
+	return nil, nil
+}
+
+func (m *MockUserTokenRepository) Consume(purpose model.UserTokenPurpose, tokenHash string) (model.UserTokenModel, error) {
+	return nil, nil
+}
+
+type mockMailer struct {
+	sent []mailer.Message
+}
+
+func (m *mockMailer) Send(message mailer.Message) error {
+	m.sent = append(m.sent, message)
+	return nil
+}
+
+func TestAccountService_ResetPassword(t *testing.T) {
+	sentMails := &mockMailer{}
+	a := AccountService{
+		Repo: &repositories.Repositories{
+			UserRepository:      &MockUserRepository{},
+			UserTokenRepository: &MockUserTokenRepository{},
+		},
+		Mailer: sentMails,
+	}
+
+	var multiFieldsErr errors2.MultiFieldsError
+	if err := a.ResetPassword("token", "short"); !errors.As(err, &multiFieldsErr) || multiFieldsErr.Fields["password"] == "" {
+		t.Errorf("short password: got %v, want a password field error", err)
+	}
+
+	if err := a.ResetPassword("unknown", "long enough"); !errors.Is(err, ErrInvalidEmailToken) {
+		t.Errorf("unknown token: got %v, want %v", err, ErrInvalidEmailToken)
+	}
+
+	if err := a.RequestPasswordReset("unknown@droppy.app"); err != nil || len(sentMails.sent) != 0 {
+		t.Errorf("unknown email: got %v and %d emails, want no error and no email", err, len(sentMails.sent))
+	}
+}
@@ -3,19 +3,40 @@ package account
 import (
 	"context"
 	"errors"
+	"fmt"
 	"go-api/internal/repositories"
+	loginthrottle "go-api/internal/services/login_throttle"
 	"go-api/internal/storage/firebase"
+	"go-api/pkg/errors2"
 	"go-api/pkg/hash"
 	"go-api/pkg/jwt_helper"
+	"go-api/pkg/mailer"
 	"go-api/pkg/model"
 	"go-api/pkg/random"
 	"go-api/pkg/services/account"
 	"go-api/pkg/validation"
+	"gorm.io/gorm"
+	"log"
 	"time"
 )
 
+// refreshTokenLifetime is how long a refresh token can be exchanged, every refresh issues a new one
+const refreshTokenLifetime = 60 * 24 * time.Hour
+
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenExpired  = errors.New("refresh token expired")
+	ErrRefreshTokenReused   = errors.New("refresh token already used, the session has been revoked")
+	ErrSessionRevoked       = errors.New("session revoked")
+)
+
 type AccountService struct {
 	Repo *repositories.Repositories
+	// Device describes where the user logs in from, it names the sessions the service opens
+	Device account.DeviceInfo
+	Mailer mailer.Mailer
+	// Throttle blocks the logins after too many failures, nil to never block them
+	Throttle *loginthrottle.LoginThrottle
 }
 
 func (a *AccountService) Create(email string, password string, username string) error {
@@ -29,16 +50,13 @@ func (a *AccountService) Create(email string, password string, username string)
 	if len(validationError.Fields) > 0 {
 		return validationError
 	}
-	hashedPassword, err := hash.GenerateFromPassword(password)
-	if err != nil {
+	// The repository hashes the password
+	createdUser, err := a.Repo.UserRepository.Create(user)
+	if err != nil || createdUser == nil {
 		return err
 	}
 
-	user.Password = hashedPassword
-
-	_, err = a.Repo.UserRepository.Create(user)
-
-	return err
+	return a.SendEmailVerification(createdUser)
 }
 
 func (a *AccountService) CreateWithGoogle(email string, name string, googleId string) error {
@@ -54,41 +72,85 @@ func (a *AccountService) CreateWithGoogle(email string, name string, googleId st
 }
 
 func (a *AccountService) Login(email string, password string, fcmToken string) (*account.TokenInfo, error) {
+	attempt, err := a.reserveLoginAttempt(email)
+	if err != nil {
+		return &account.TokenInfo{}, err
+	}
+
 	user, err := a.Repo.UserRepository.GetByEmail(email)
 	if err != nil {
+		failLoginAttempt(attempt)
 		return &account.TokenInfo{}, err
 	}
 	match, err := hash.ComparePasswordAndHash(password, user.GetPassword())
 	if err != nil {
+		failLoginAttempt(attempt)
 		return &account.TokenInfo{}, errors.New("error while comparing password and hash")
 	}
 	if !match {
+		failLoginAttempt(attempt)
 		return &account.TokenInfo{}, errors.New("email or password does not match our record")
 	}
 
-	newToken, refreshToken, newTokenExpiry, err := jwt_helper.GenerateToken(user.GetID(), user.GetRole())
-	if err != nil {
-		return &account.TokenInfo{}, err
+	if user.GetStatus() == userPendingVerificationStatus {
+		releaseLoginAttempt(attempt)
+		return &account.TokenInfo{}, ErrEmailNotVerified
 	}
 
 	if fcmToken != "" && user.GetFCMToken() != fcmToken {
 		_, err = a.Repo.UserRepository.Update(user.GetID(), map[string]interface{}{"fcm_token": fcmToken})
 		if err != nil {
+			releaseLoginAttempt(attempt)
 			return &account.TokenInfo{}, err
 		}
 	}
 
-	_, err = a.Repo.TokenRepository.Create(context.TODO(), model.TokenCreationParam{
-		Token:  refreshToken,
-		UserID: user.GetID(),
-		Expiry: newTokenExpiry,
-	})
+	return a.completeLogin(user, attempt)
+}
 
+// reserveLoginAttempt counts an attempt against the account and the device's IP address before its credentials are checked
+func (a *AccountService) reserveLoginAttempt(email string) (*loginthrottle.Attempt, error) {
+	if a.Throttle == nil {
+		return nil, nil
+	}
+	return a.Throttle.Reserve(email, a.Device.IPAddress, time.Now())
+}
+
+func failLoginAttempt(attempt *loginthrottle.Attempt) {
+	if err := attempt.Fail(time.Now()); err != nil {
+		log.Printf("Error: Error recording failed login: %v\n", err)
+	}
+}
+
+func releaseLoginAttempt(attempt *loginthrottle.Attempt) {
+	if err := attempt.Release(time.Now()); err != nil {
+		log.Printf("Error: Error releasing login attempt: %v\n", err)
+	}
+}
+
+// succeedLoginAttempt forgets the failed logins of the user, and warns them when their account has been locked out in the meantime
+func (a *AccountService) succeedLoginAttempt(attempt *loginthrottle.Attempt, user model.UserModel) {
+	lockouts, err := attempt.Succeed(time.Now())
 	if err != nil {
-		return &account.TokenInfo{}, err
+		log.Printf("Error: Error recording successful login of user %d: %v\n", user.GetID(), err)
+		return
+	}
+	if lockouts == 0 {
+		return
 	}
 
-	return &account.TokenInfo{JWTToken: newToken, RefreshToken: refreshToken, Expiry: newTokenExpiry}, nil
+	err = a.send(mailer.Message{
+		To:      user.GetEmail(),
+		Subject: "Failed login attempts on your Droppy account",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nYour account has been locked %d time(s) since your last login because of too many failed login attempts.\n"+
+				"If it was not you, change your password and log out of your other sessions.",
+			user.GetUsername(), lockouts,
+		),
+	})
+	if err != nil {
+		log.Printf("Error: Error warning user %d about login lockouts: %v\n", user.GetID(), err)
+	}
 }
 
 func (a *AccountService) LoginWithFirebase(token string, ctx context.Context) (*account.TokenInfo, error) {
@@ -136,17 +198,31 @@ func (a *AccountService) LoginWithFirebase(token string, ctx context.Context) (*
 	return a.LoginWithGoogle(user.GetEmail())
 }
 
+// LoginFromRefreshToken exchanges a refresh token for a new pair of tokens.
+// A refresh token can only be exchanged once, presenting it again revokes the whole session as it may have been stolen.
 func (a *AccountService) LoginFromRefreshToken(refreshToken string) (*account.TokenInfo, error) {
-	t, err := a.Repo.TokenRepository.FindByRefreshToken(refreshToken)
+	t, err := a.Repo.TokenRepository.FindByTokenHash(hash.HashToken(refreshToken))
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && t == nil) {
+		return &account.TokenInfo{}, ErrRefreshTokenNotFound
+	}
 	if err != nil {
 		return &account.TokenInfo{}, err
 	}
-	if t == nil {
-		return &account.TokenInfo{}, errors.New("refresh token not found")
+
+	session, err := a.Repo.TokenRepository.GetSession(t.GetSessionID())
+	if err != nil {
+		return &account.TokenInfo{}, err
+	}
+	if session == nil || session.IsRevoked() {
+		return &account.TokenInfo{}, ErrSessionRevoked
+	}
+
+	if t.IsUsed() {
+		return &account.TokenInfo{}, a.revokeReusedSession(session)
 	}
 
 	if t.GetExpiry() < int(time.Now().Unix()) {
-		return &account.TokenInfo{}, errors.New("refresh token expired")
+		return &account.TokenInfo{}, ErrRefreshTokenExpired
 	}
 
 	user, err := a.Repo.UserRepository.GetById(t.GetUserID())
@@ -154,24 +230,25 @@ func (a *AccountService) LoginFromRefreshToken(refreshToken string) (*account.To
 		return &account.TokenInfo{}, err
 	}
 
-	newToken, newRefreshToken, newTokenExpiry, err := jwt_helper.GenerateToken(user.GetID(), user.GetRole())
+	newToken, err := jwt_helper.GenerateToken(user.GetID(), user.GetRole(), session.GetID(), session.IsTwoFactor())
 	if err != nil {
 		return &account.TokenInfo{}, err
 	}
 
-	_, err = a.Repo.TokenRepository.Create(context.TODO(), model.TokenCreationParam{
-		Token:  newRefreshToken,
-		UserID: user.GetID(),
-		Expiry: newTokenExpiry,
-	})
-
+	newRefreshToken, next, err := newRefreshTokenParam(user.GetID(), session.GetID())
 	if err != nil {
 		return &account.TokenInfo{}, err
 	}
 
-	err = a.Repo.TokenRepository.Delete(t.GetID())
+	_, err = a.Repo.TokenRepository.Rotate(t.GetID(), next)
+	if errors.Is(err, model.ErrRefreshTokenAlreadyUsed) {
+		return &account.TokenInfo{}, a.revokeReusedSession(session)
+	}
+	if err != nil {
+		return &account.TokenInfo{}, err
+	}
 
-	return &account.TokenInfo{JWTToken: newToken, RefreshToken: newRefreshToken, Expiry: newTokenExpiry}, nil
+	return &account.TokenInfo{JWTToken: newToken, RefreshToken: newRefreshToken, Expiry: next.Expiry}, nil
 }
 
 func (a *AccountService) LoginWithGoogle(email string) (*account.TokenInfo, error) {
@@ -180,23 +257,83 @@ func (a *AccountService) LoginWithGoogle(email string) (*account.TokenInfo, erro
 		return &account.TokenInfo{}, err
 	}
 
-	//TODO refacto avec function login juste au dessus
-	newToken, refreshToken, newTokenExpiry, err := jwt_helper.GenerateToken(user.GetID(), user.GetRole())
+	return a.completeLogin(user, nil)
+}
+
+// GetSessions returns the devices the user is logged in on
+func (a *AccountService) GetSessions(userId uint) ([]model.AuthSessionModel, error) {
+	return a.Repo.TokenRepository.GetActiveSessions(userId)
+}
+
+// RevokeSession logs the user out of one of their sessions
+func (a *AccountService) RevokeSession(userId uint, sessionId uint) error {
+	session, err := a.Repo.TokenRepository.GetSession(sessionId)
 	if err != nil {
-		return &account.TokenInfo{}, err
+		return err
 	}
 
-	_, err = a.Repo.TokenRepository.Create(context.TODO(), model.TokenCreationParam{
-		Token:  refreshToken,
-		UserID: user.GetID(),
-		Expiry: newTokenExpiry,
+	if session == nil || session.GetUserID() != userId || session.IsRevoked() {
+		return errors2.NotFoundError{Entity: "Session"}
+	}
+
+	return a.Repo.TokenRepository.RevokeSession(sessionId)
+}
+
+// RevokeAllSessions logs the user out of every device
+func (a *AccountService) RevokeAllSessions(userId uint) error {
+	return a.Repo.TokenRepository.RevokeUserSessions(userId)
+}
+
+// openSession starts a new token family for the device the user logs in from, twoFactor telling whether they used their second factor
+func (a *AccountService) openSession(user model.UserModel, twoFactor bool) (*account.TokenInfo, error) {
+	session, err := a.Repo.TokenRepository.CreateSession(model.SessionCreationParam{
+		UserID:     user.GetID(),
+		DeviceName: a.Device.Name,
+		IPAddress:  a.Device.IPAddress,
+		TwoFactor:  twoFactor,
 	})
+	if err != nil {
+		return &account.TokenInfo{}, err
+	}
 
+	newToken, err := jwt_helper.GenerateToken(user.GetID(), user.GetRole(), session.GetID(), twoFactor)
 	if err != nil {
 		return &account.TokenInfo{}, err
 	}
 
-	return &account.TokenInfo{JWTToken: newToken, RefreshToken: refreshToken, Expiry: newTokenExpiry}, nil
+	refreshToken, param, err := newRefreshTokenParam(user.GetID(), session.GetID())
+	if err != nil {
+		return &account.TokenInfo{}, err
+	}
+
+	if _, err = a.Repo.TokenRepository.Create(context.TODO(), param); err != nil {
+		return &account.TokenInfo{}, err
+	}
+
+	return &account.TokenInfo{JWTToken: newToken, RefreshToken: refreshToken, Expiry: param.Expiry}, nil
+}
+
+func (a *AccountService) revokeReusedSession(session model.AuthSessionModel) error {
+	log.Printf("Info: Refresh token reused on session %d of user %d, revoking the session\n", session.GetID(), session.GetUserID())
+	if err := a.Repo.TokenRepository.RevokeSession(session.GetID()); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// newRefreshTokenParam generates an opaque refresh token, only its hash is meant to be stored
+func newRefreshTokenParam(userId uint, sessionId uint) (string, model.TokenCreationParam, error) {
+	refreshToken, err := random.SecureToken(32)
+	if err != nil {
+		return "", model.TokenCreationParam{}, err
+	}
+
+	return refreshToken, model.TokenCreationParam{
+		TokenHash: hash.HashToken(refreshToken),
+		UserID:    userId,
+		SessionID: sessionId,
+		Expiry:    int(time.Now().Add(refreshTokenLifetime).Unix()),
+	}, nil
 }
 
 func (a *AccountService) EmailExists(email string) (bool, error) {
@@ -10,7 +10,32 @@ import (
 
 type MockUserRepository struct{}
 
-func (m *MockUserRepository) Update(args model.UserPatchParam) (model.UserModel, error) {
+func (m *MockUserRepository) Update(userID uint, args map[string]interface{}) (model.UserModel, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (m *MockUserRepository) GetAllUserCount() (int64, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (m *MockUserRepository) GetAllFCMTokens() ([]string, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (m *MockUserRepository) BanUser(userId uint) (model.UserModel, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (m *MockUserRepository) UnbanUser(userId uint) (model.UserModel, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (m *MockUserRepository) UpdateByAdmin(userId uint, args model.AdminUpdateUserRequest) (model.UserModel, error) {
 	//TODO implement me
 	panic("implement me")
 }
@@ -44,7 +69,7 @@ func (m *MockUserRepository) CreateWithGoogle(args model.UserCreationWithGoogleP
 func (m *MockUserRepository) Delete(id uint) error {
 	return nil
 }
-func (m *MockUserRepository) GetAll() ([]model.UserModel, error) {
+func (m *MockUserRepository) GetAll(page int, pageSize int) ([]model.UserModel, error) {
 	return nil, nil
 }
 func (m *MockUserRepository) GetByEmail(email string) (model.UserModel, error) {
@@ -53,7 +78,7 @@ func (m *MockUserRepository) GetByEmail(email string) (model.UserModel, error) {
 func (m *MockUserRepository) GetById(id uint) (model.UserModel, error) {
 	return nil, nil
 }
-func (m *MockUserRepository) GetByGoogleAuthId(googleID string) (model.UserModel, error) {
+func (m *MockUserRepository) GetByFirebaseUid(googleID string) (model.UserModel, error) {
 	return nil, nil
 }
 
@@ -0,0 +1,141 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"go-api/internal/repositories"
+	"go-api/pkg/hash"
+	"go-api/pkg/model"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+type mockAuthToken struct {
+	id        uint
+	tokenHash string
+	sessionID uint
+	expiry    int
+	used      bool
+}
+
+func (t *mockAuthToken) GetID() uint          { return t.id }
+func (t *mockAuthToken) GetTokenHash() string { return t.tokenHash }
+func (t *mockAuthToken) GetUserID() uint      { return 1 }
+func (t *mockAuthToken) GetSessionID() uint   { return t.sessionID }
+func (t *mockAuthToken) GetExpiry() int       { return t.expiry }
+func (t *mockAuthToken) IsUsed() bool         { return t.used }
+
+type mockAuthSession struct {
+	id        uint
+	revoked   bool
+	twoFactor bool
+}
+
+func (s *mockAuthSession) GetID() uint           { return s.id }
+func (s *mockAuthSession) GetUserID() uint       { return 1 }
+func (s *mockAuthSession) GetDeviceName() string { return "" }
+func (s *mockAuthSession) GetIPAddress() string  { return "" }
+func (s *mockAuthSession) GetCreatedAt() int     { return 0 }
+func (s *mockAuthSession) GetLastUsedAt() int    { return 0 }
+func (s *mockAuthSession) IsRevoked() bool       { return s.revoked }
+func (s *mockAuthSession) IsTwoFactor() bool     { return s.twoFactor }
+
+type MockTokenRepository struct {
+	tokens   []*mockAuthToken
+	sessions map[uint]*mockAuthSession
+}
+
+func (m *MockTokenRepository) Create(ctx context.Context, args model.TokenCreationParam) (model.AuthTokenModel, error) {
+	token := &mockAuthToken{id: uint(len(m.tokens) + 1), tokenHash: args.TokenHash, sessionID: args.SessionID, expiry: args.Expiry}
+	m.tokens = append(m.tokens, token)
+	return token, nil
+}
+
+func (m *MockTokenRepository) FindByTokenHash(tokenHash string) (model.AuthTokenModel, error) {
+	for _, token := range m.tokens {
+		if token.tokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *MockTokenRepository) Rotate(tokenID uint, next model.TokenCreationParam) (model.AuthTokenModel, error) {
+	m.tokens[tokenID-1].used = true
+	return m.Create(context.TODO(), next)
+}
+
+func (m *MockTokenRepository) CreateSession(args model.SessionCreationParam) (model.AuthSessionModel, error) {
+	session := &mockAuthSession{id: uint(len(m.sessions) + 1), twoFactor: args.TwoFactor}
+	m.sessions[session.id] = session
+	return session, nil
+}
+
+func (m *MockTokenRepository) GetSession(sessionID uint) (model.AuthSessionModel, error) {
+	if session, ok := m.sessions[sessionID]; ok {
+		return session, nil
+	}
+	return nil, nil
+}
+
+func (m *MockTokenRepository) GetActiveSessions(userID uint) ([]model.AuthSessionModel, error) {
+	return nil, nil
+}
+
+func (m *MockTokenRepository) RevokeSession(sessionID uint) error {
+	m.sessions[sessionID].revoked = true
+	return nil
+}
+
+func (m *MockTokenRepository) RevokeUserSessions(userID uint) error {
+	for _, session := range m.sessions {
+		session.revoked = true
+	}
+	return nil
+}
+
+func (m *MockTokenRepository) RevokeOtherSessions(userID uint, keptSessionID uint) error {
+	for id, session := range m.sessions {
+		if id != keptSessionID {
+			session.revoked = true
+		}
+	}
+	return nil
+}
+
+func (m *MockTokenRepository) MarkSessionTwoFactor(sessionID uint) error {
+	m.sessions[sessionID].twoFactor = true
+	return nil
+}
+
+func TestAccountService_LoginFromRefreshToken(t *testing.T) {
+	tokenRepo := &MockTokenRepository{sessions: map[uint]*mockAuthSession{}}
+	a := AccountService{Repo: &repositories.Repositories{TokenRepository: tokenRepo}}
+
+	session, _ := tokenRepo.CreateSession(model.SessionCreationParam{UserID: 1})
+	expiry := int(time.Now().Add(time.Hour).Unix())
+	_, _ = tokenRepo.Create(context.TODO(), model.TokenCreationParam{TokenHash: hash.HashToken("rotated"), SessionID: session.GetID(), Expiry: expiry})
+	_, _ = tokenRepo.Create(context.TODO(), model.TokenCreationParam{TokenHash: hash.HashToken("expired"), SessionID: session.GetID(), Expiry: 1})
+	tokenRepo.tokens[0].used = true
+
+	if _, err := a.LoginFromRefreshToken("unknown"); !errors.Is(err, ErrRefreshTokenNotFound) {
+		t.Errorf("unknown token: got %v, want %v", err, ErrRefreshTokenNotFound)
+	}
+
+	if _, err := a.LoginFromRefreshToken("expired"); !errors.Is(err, ErrRefreshTokenExpired) {
+		t.Errorf("expired token: got %v, want %v", err, ErrRefreshTokenExpired)
+	}
+
+	if _, err := a.LoginFromRefreshToken("rotated"); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Errorf("reused token: got %v, want %v", err, ErrRefreshTokenReused)
+	}
+
+	if !tokenRepo.sessions[session.GetID()].IsRevoked() {
+		t.Errorf("reusing a token must revoke its session")
+	}
+
+	if _, err := a.LoginFromRefreshToken("expired"); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("token of a revoked session: got %v, want %v", err, ErrSessionRevoked)
+	}
+}
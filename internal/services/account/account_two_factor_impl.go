@@ -0,0 +1,353 @@
+package account
+
+import (
+	"crypto/rand"
+	"errors"
+	loginthrottle "go-api/internal/services/login_throttle"
+	"go-api/pkg/errors2"
+	"go-api/pkg/hash"
+	"go-api/pkg/jwt_helper"
+	"go-api/pkg/model"
+	"go-api/pkg/services/account"
+	"go-api/pkg/totp"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	recoveryCodesCount  = 10
+	recoveryCodeLength  = 10
+	recoveryCodeCharset = "abcdefghijklmnopqrstuvwxyz234567"
+)
+
+var (
+	ErrInvalidTwoFactorCode  = errors.New("invalid two-factor code")
+	ErrInvalidTwoFactorToken = errors.New("invalid or expired two-factor token")
+	ErrTwoFactorNotSetUp     = errors.New("two-factor authentication is not set up")
+)
+
+// SetupTwoFactor generates a new secret for the user, two-factor authentication is only enabled once a code of this secret is confirmed
+func (a *AccountService) SetupTwoFactor(userId uint) (*account.TwoFactorSetup, error) {
+	user, err := a.Repo.UserRepository.GetById(userId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors2.NotFoundError{Entity: "User"}
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = a.Repo.TwoFactorRepository.SavePending(userId, secret); err != nil {
+		return nil, err
+	}
+
+	return &account.TwoFactorSetup{Secret: secret, URI: totp.URI(totpIssuer(), user.GetEmail(), secret)}, nil
+}
+
+// EnableTwoFactor confirms the pending secret with a code of the authenticator app and returns the recovery codes,
+// the other sessions of the user are logged out as they were not opened with the second factor, the current one now counts as if it was
+func (a *AccountService) EnableTwoFactor(userId uint, sessionId uint, code string) ([]string, error) {
+	twoFactor, err := a.Repo.TwoFactorRepository.Get(userId)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor == nil {
+		return nil, ErrTwoFactorNotSetUp
+	}
+	if twoFactor.IsEnabled() {
+		return nil, model.ErrTwoFactorAlreadyEnabled
+	}
+
+	step, err := totp.Verify(twoFactor.GetSecret(), code, time.Now())
+	if err != nil {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, codeHashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.Repo.TwoFactorRepository.Enable(userId, step, codeHashes); err != nil {
+		return nil, err
+	}
+
+	if err = a.Repo.TokenRepository.RevokeOtherSessions(userId, sessionId); err != nil {
+		return nil, err
+	}
+
+	if err = a.Repo.TokenRepository.MarkSessionTwoFactor(sessionId); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off after checking the password and a code, admins cannot go without it
+func (a *AccountService) DisableTwoFactor(userId uint, password string, code string) error {
+	user, err := a.Repo.UserRepository.GetById(userId)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors2.NotFoundError{Entity: "User"}
+	}
+	if user.GetRole() == "admin" {
+		return errors2.NotAllowedError{Reason: "Two-factor authentication is required for admins"}
+	}
+
+	err = a.throttleTwoFactorCheck(user, func() error {
+		if user.GetPassword() != "" {
+			match, err := hash.ComparePasswordAndHash(password, user.GetPassword())
+			if err != nil {
+				return err
+			}
+			if !match {
+				return errors2.MultiFieldsError{Fields: map[string]string{"password": "Wrong password"}}
+			}
+		}
+		return a.verifyTwoFactorCode(userId, code)
+	})
+	if err != nil {
+		return err
+	}
+
+	return a.Repo.TwoFactorRepository.Disable(userId)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user, the previous ones cannot be used anymore
+func (a *AccountService) RegenerateRecoveryCodes(userId uint, code string) ([]string, error) {
+	user, err := a.Repo.UserRepository.GetById(userId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors2.NotFoundError{Entity: "User"}
+	}
+
+	err = a.throttleTwoFactorCheck(user, func() error {
+		return a.verifyTwoFactorCode(userId, code)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codes, codeHashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.Repo.TwoFactorRepository.ReplaceRecoveryCodes(userId, codeHashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// LoginWithTwoFactor completes a login with the token returned by Login and either a code of the authenticator app or a recovery code
+func (a *AccountService) LoginWithTwoFactor(twoFactorToken string, code string, recoveryCode string) (*account.TokenInfo, error) {
+	claims, err := jwt_helper.VerifyToken(twoFactorToken, jwt_helper.TokenTypeTwoFactor)
+	if err != nil {
+		return &account.TokenInfo{}, ErrInvalidTwoFactorToken
+	}
+	userId, err := claims.GetUserID()
+	if err != nil || claims.ID == "" || claims.ExpiresAt == nil {
+		return &account.TokenInfo{}, ErrInvalidTwoFactorToken
+	}
+
+	user, err := a.Repo.UserRepository.GetById(userId)
+	if err != nil {
+		return &account.TokenInfo{}, err
+	}
+	if user == nil {
+		return &account.TokenInfo{}, ErrInvalidTwoFactorToken
+	}
+
+	attempt, err := a.reserveLoginAttempt(user.GetEmail())
+	if err != nil {
+		return &account.TokenInfo{}, err
+	}
+
+	if recoveryCode != "" {
+		err = a.useRecoveryCode(userId, recoveryCode)
+	} else {
+		err = a.verifyTwoFactorCode(userId, code)
+	}
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		failLoginAttempt(attempt)
+		return &account.TokenInfo{}, err
+	}
+	if err != nil {
+		releaseLoginAttempt(attempt)
+		return &account.TokenInfo{}, err
+	}
+
+	// A token is only exchanged once, even when it is presented with two valid codes
+	unused, err := a.Repo.TwoFactorRepository.UseToken(claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		releaseLoginAttempt(attempt)
+		return &account.TokenInfo{}, err
+	}
+	if !unused {
+		releaseLoginAttempt(attempt)
+		return &account.TokenInfo{}, ErrInvalidTwoFactorToken
+	}
+
+	a.succeedLoginAttempt(attempt, user)
+
+	return a.openSession(user, true)
+}
+
+// completeLogin opens a session for the user, or asks for their second factor when they enabled it.
+// The failed logins are only forgotten once the second factor is checked too.
+func (a *AccountService) completeLogin(user model.UserModel, attempt *loginthrottle.Attempt) (*account.TokenInfo, error) {
+	twoFactor, err := a.hasTwoFactor(user.GetID())
+	if err != nil {
+		releaseLoginAttempt(attempt)
+		return &account.TokenInfo{}, err
+	}
+
+	if !twoFactor {
+		a.succeedLoginAttempt(attempt, user)
+		return a.openSession(user, false)
+	}
+
+	releaseLoginAttempt(attempt)
+
+	twoFactorToken, err := jwt_helper.GenerateTwoFactorToken(user.GetID())
+	if err != nil {
+		return &account.TokenInfo{}, err
+	}
+
+	return &account.TokenInfo{TwoFactorRequired: true, TwoFactorToken: twoFactorToken}, nil
+}
+
+// throttleTwoFactorCheck runs a check of the password or the second factor of a logged-in user through the login throttle,
+// so a stolen session cannot be used to guess the codes
+func (a *AccountService) throttleTwoFactorCheck(user model.UserModel, check func() error) error {
+	attempt, err := a.reserveLoginAttempt(user.GetEmail())
+	if err != nil {
+		return err
+	}
+
+	err = check()
+	var multiFieldsErr errors2.MultiFieldsError
+	if errors.Is(err, ErrInvalidTwoFactorCode) || errors.As(err, &multiFieldsErr) {
+		failLoginAttempt(attempt)
+		return err
+	}
+	if err != nil {
+		releaseLoginAttempt(attempt)
+		return err
+	}
+
+	a.succeedLoginAttempt(attempt, user)
+	return nil
+}
+
+func (a *AccountService) hasTwoFactor(userId uint) (bool, error) {
+	twoFactor, err := a.Repo.TwoFactorRepository.Get(userId)
+	if err != nil {
+		return false, err
+	}
+	return twoFactor != nil && twoFactor.IsEnabled(), nil
+}
+
+// verifyTwoFactorCode checks a code of the authenticator app, a code is only accepted once
+func (a *AccountService) verifyTwoFactorCode(userId uint, code string) error {
+	twoFactor, err := a.Repo.TwoFactorRepository.Get(userId)
+	if err != nil {
+		return err
+	}
+	if twoFactor == nil || !twoFactor.IsEnabled() {
+		return ErrTwoFactorNotSetUp
+	}
+
+	step, err := totp.Verify(twoFactor.GetSecret(), code, time.Now())
+	if err != nil {
+		return ErrInvalidTwoFactorCode
+	}
+
+	unused, err := a.Repo.TwoFactorRepository.UseStep(userId, step)
+	if err != nil {
+		return err
+	}
+	if !unused {
+		return ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+func (a *AccountService) useRecoveryCode(userId uint, recoveryCode string) error {
+	codes, err := a.Repo.TwoFactorRepository.GetUnusedRecoveryCodes(userId)
+	if err != nil {
+		return err
+	}
+
+	recoveryCode = normalizeRecoveryCode(recoveryCode)
+	for _, code := range codes {
+		match, err := hash.ComparePasswordAndHash(recoveryCode, code.GetCodeHash())
+		if err != nil || !match {
+			continue
+		}
+
+		used, err := a.Repo.TwoFactorRepository.UseRecoveryCode(code.GetID())
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidTwoFactorCode
+		}
+		if len(codes) == 1 {
+			log.Printf("Info: User %d used their last recovery code\n", userId)
+		}
+		return nil
+	}
+
+	return ErrInvalidTwoFactorCode
+}
+
+// generateRecoveryCodes returns the codes to show the user once, formatted as xxxxx-xxxxx, and their argon2 hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	codeHashes := make([]string, 0, recoveryCodesCount)
+
+	for i := 0; i < recoveryCodesCount; i++ {
+		b := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		for j := range b {
+			b[j] = recoveryCodeCharset[int(b[j])%len(recoveryCodeCharset)]
+		}
+
+		code := string(b)
+		codeHash, err := hash.GenerateFromPassword(code)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		codes = append(codes, code[:recoveryCodeLength/2]+"-"+code[recoveryCodeLength/2:])
+		codeHashes = append(codeHashes, codeHash)
+	}
+
+	return codes, codeHashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+}
+
+func totpIssuer() string {
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "Droppy"
+}
@@ -0,0 +1,220 @@
+package account
+
+import (
+	"errors"
+	"go-api/internal/repositories"
+	"go-api/internal/storage/postgres"
+	"go-api/pkg/jwt_helper"
+	"go-api/pkg/model"
+	"go-api/pkg/totp"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+type mockTwoFactor struct {
+	secret       string
+	enabled      bool
+	lastUsedStep int64
+}
+
+func (t *mockTwoFactor) GetUserID() uint   { return 1 }
+func (t *mockTwoFactor) GetSecret() string { return t.secret }
+func (t *mockTwoFactor) IsEnabled() bool   { return t.enabled }
+
+type mockRecoveryCode struct {
+	id       uint
+	codeHash string
+	used     bool
+}
+
+func (r *mockRecoveryCode) GetID() uint         { return r.id }
+func (r *mockRecoveryCode) GetCodeHash() string { return r.codeHash }
+
+type MockTwoFactorRepository struct {
+	twoFactor     *mockTwoFactor
+	recoveryCodes []*mockRecoveryCode
+	usedTokens    map[string]bool
+}
+
+func (m *MockTwoFactorRepository) Get(userID uint) (model.TwoFactorModel, error) {
+	if m.twoFactor == nil {
+		return nil, nil
+	}
+	return m.twoFactor, nil
+}
+
+func (m *MockTwoFactorRepository) SavePending(userID uint, secret string) (model.TwoFactorModel, error) {
+	m.twoFactor = &mockTwoFactor{secret: secret}
+	return m.twoFactor, nil
+}
+
+func (m *MockTwoFactorRepository) Enable(userID uint, step int64, recoveryCodeHashes []string) error {
+	m.twoFactor.enabled = true
+	m.twoFactor.lastUsedStep = step
+	return m.ReplaceRecoveryCodes(userID, recoveryCodeHashes)
+}
+
+func (m *MockTwoFactorRepository) Disable(userID uint) error {
+	m.twoFactor = nil
+	m.recoveryCodes = nil
+	return nil
+}
+
+func (m *MockTwoFactorRepository) UseStep(userID uint, step int64) (bool, error) {
+	if m.twoFactor.lastUsedStep >= step {
+		return false, nil
+	}
+	m.twoFactor.lastUsedStep = step
+	return true, nil
+}
+
+func (m *MockTwoFactorRepository) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	m.recoveryCodes = nil
+	for i, codeHash := range codeHashes {
+		m.recoveryCodes = append(m.recoveryCodes, &mockRecoveryCode{id: uint(i + 1), codeHash: codeHash})
+	}
+	return nil
+}
+
+func (m *MockTwoFactorRepository) GetUnusedRecoveryCodes(userID uint) ([]model.RecoveryCodeModel, error) {
+	var codes []model.RecoveryCodeModel
+	for _, code := range m.recoveryCodes {
+		if !code.used {
+			codes = append(codes, code)
+		}
+	}
+	return codes, nil
+}
+
+func (m *MockTwoFactorRepository) UseRecoveryCode(id uint) (bool, error) {
+	code := m.recoveryCodes[id-1]
+	if code.used {
+		return false, nil
+	}
+	code.used = true
+	return true, nil
+}
+
+func (m *MockTwoFactorRepository) UseToken(tokenID string, expiresAt time.Time) (bool, error) {
+	if m.usedTokens[tokenID] {
+		return false, nil
+	}
+	m.usedTokens[tokenID] = true
+	return true, nil
+}
+
+type mockTwoFactorUserRepository struct {
+	MockUserRepository
+	user model.UserModel
+}
+
+func (m *mockTwoFactorUserRepository) GetById(id uint) (model.UserModel, error) {
+	return m.user, nil
+}
+
+func TestAccountService_TwoFactor(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	twoFactorRepo := &MockTwoFactorRepository{twoFactor: &mockTwoFactor{secret: secret}}
+	tokenRepo := &MockTokenRepository{sessions: map[uint]*mockAuthSession{}}
+	a := AccountService{Repo: &repositories.Repositories{TwoFactorRepository: twoFactorRepo, TokenRepository: tokenRepo}}
+
+	current, _ := tokenRepo.CreateSession(model.SessionCreationParam{UserID: 1})
+	other, _ := tokenRepo.CreateSession(model.SessionCreationParam{UserID: 1})
+
+	if _, err := a.EnableTwoFactor(1, current.GetID(), "000000x"); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("invalid code: got %v, want %v", err, ErrInvalidTwoFactorCode)
+	}
+
+	code, _ := totp.Code(secret, time.Now())
+	recoveryCodes, err := a.EnableTwoFactor(1, current.GetID(), code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recoveryCodes) != recoveryCodesCount || !twoFactorRepo.twoFactor.enabled {
+		t.Fatalf("got %d recovery codes, enabled %t, want %d, true", len(recoveryCodes), twoFactorRepo.twoFactor.enabled, recoveryCodesCount)
+	}
+	if tokenRepo.sessions[current.GetID()].IsRevoked() || !tokenRepo.sessions[other.GetID()].IsRevoked() {
+		t.Errorf("enabling two-factor authentication must only keep the current session")
+	}
+
+	if err := a.verifyTwoFactorCode(1, code); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("replayed code: got %v, want %v", err, ErrInvalidTwoFactorCode)
+	}
+
+	if err := a.useRecoveryCode(1, " "+recoveryCodes[3]+" "); err != nil {
+		t.Errorf("recovery code rejected: %v", err)
+	}
+	if err := a.useRecoveryCode(1, recoveryCodes[3]); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("reused recovery code: got %v, want %v", err, ErrInvalidTwoFactorCode)
+	}
+}
+
+func TestAccountService_LoginWithTwoFactor(t *testing.T) {
+	keyring, err := jwt_helper.NewKeyring(jwt_helper.NewHMACKey("hmac", []byte("secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwt_helper.SetKeyring(keyring)
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	twoFactorRepo := &MockTwoFactorRepository{twoFactor: &mockTwoFactor{secret: secret, enabled: true}, usedTokens: map[string]bool{}}
+	tokenRepo := &MockTokenRepository{sessions: map[uint]*mockAuthSession{}}
+	userRepo := &mockTwoFactorUserRepository{user: &postgres.User{Model: gorm.Model{ID: 1}, Email: "admin@droppy.app", Role: "admin"}}
+	a := AccountService{Repo: &repositories.Repositories{UserRepository: userRepo, TwoFactorRepository: twoFactorRepo, TokenRepository: tokenRepo}}
+
+	twoFactorToken, err := jwt_helper.GenerateTwoFactorToken(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.LoginWithTwoFactor("not a token", "000000", ""); !errors.Is(err, ErrInvalidTwoFactorToken) {
+		t.Errorf("invalid token: got %v, want %v", err, ErrInvalidTwoFactorToken)
+	}
+
+	code, _ := totp.Code(secret, time.Now())
+	tokenInfo, err := a.LoginWithTwoFactor(twoFactorToken, code, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := jwt_helper.VerifyToken(tokenInfo.JWTToken, jwt_helper.TokenTypeAccess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claims.TwoFactor || !tokenRepo.sessions[claims.SessionID].IsTwoFactor() {
+		t.Errorf("got mfa claim %t, want a session opened with the second factor", claims.TwoFactor)
+	}
+
+	recoveryCodes, err := a.RegenerateRecoveryCodes(1, mustNextCode(t, secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.LoginWithTwoFactor(twoFactorToken, "", recoveryCodes[0]); !errors.Is(err, ErrInvalidTwoFactorToken) {
+		t.Errorf("reused two-factor token: got %v, want %v", err, ErrInvalidTwoFactorToken)
+	}
+
+	refreshed, err := a.LoginFromRefreshToken(tokenInfo.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err = jwt_helper.VerifyToken(refreshed.JWTToken, jwt_helper.TokenTypeAccess)
+	if err != nil || !claims.TwoFactor {
+		t.Errorf("refreshed token lost the mfa claim of its session: %v", err)
+	}
+}
+
+// mustNextCode returns the code of the next time step, the current one being used already
+func mustNextCode(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := totp.Code(secret, time.Now().Add(30*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
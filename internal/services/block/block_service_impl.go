@@ -0,0 +1,117 @@
+package block
+
+import (
+	"go-api/internal/repositories"
+	"go-api/pkg/errors2"
+	"go-api/pkg/model"
+)
+
+type BlockService struct {
+	Repo *repositories.Repositories
+}
+
+func (s *BlockService) BlockUser(requesterID uint, userID uint) (model.BlockModel, error) {
+	if err := s.canTargetUser(requesterID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.Repo.BlockRepository.Create(requesterID, userID)
+}
+
+func (s *BlockService) UnblockUser(requesterID uint, userID uint) error {
+	return s.Repo.BlockRepository.Delete(requesterID, userID)
+}
+
+func (s *BlockService) GetBlockedUsers(requesterID uint) ([]model.BlockModel, error) {
+	return s.Repo.BlockRepository.GetBlockedUsers(requesterID)
+}
+
+func (s *BlockService) MuteUser(requesterID uint, userID uint) (model.MuteModel, error) {
+	if err := s.canTargetUser(requesterID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.Repo.MuteRepository.Create(requesterID, userID)
+}
+
+func (s *BlockService) UnmuteUser(requesterID uint, userID uint) error {
+	return s.Repo.MuteRepository.Delete(requesterID, userID)
+}
+
+func (s *BlockService) GetMutedUsers(requesterID uint) ([]model.MuteModel, error) {
+	return s.Repo.MuteRepository.GetMutedUsers(requesterID)
+}
+
+// GetHiddenUserIds returns the users whose drops, comments and responses must not be shown to the user
+func (s *BlockService) GetHiddenUserIds(userID uint) (map[uint]bool, error) {
+	userIds, err := s.Repo.BlockRepository.GetHiddenUserIds(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	hiddenUserIds := make(map[uint]bool, len(userIds))
+	for _, hiddenUserId := range userIds {
+		hiddenUserIds[hiddenUserId] = true
+	}
+	return hiddenUserIds, nil
+}
+
+// CheckNotBlocked returns a NotAllowedError when one of the users blocked the other
+func (s *BlockService) CheckNotBlocked(userID uint, otherUserID uint) error {
+	isBlocked, err := s.Repo.BlockRepository.IsBlockedBetween(userID, otherUserID)
+	if err != nil {
+		return err
+	}
+
+	if isBlocked {
+		return errors2.NotAllowedError{Reason: "You can't interact with this user"}
+	}
+	return nil
+}
+
+func (s *BlockService) canTargetUser(requesterID uint, userID uint) error {
+	if requesterID == userID {
+		return errors2.NotAllowedError{Reason: "You can't block or mute yourself"}
+	}
+
+	user, err := s.Repo.UserRepository.GetById(userID)
+	if err != nil {
+		return err
+	}
+
+	if nil == user {
+		return errors2.NotFoundError{Entity: "User"}
+	}
+	return nil
+}
+
+// HideDrops removes the drops of hidden users, and the comments and responses they left on the other drops
+func HideDrops(drops []model.DropModel, hiddenUserIds map[uint]bool) []model.DropModel {
+	if len(hiddenUserIds) == 0 {
+		return drops
+	}
+
+	visibleDrops := make([]model.DropModel, 0, len(drops))
+	for _, drop := range drops {
+		if hiddenUserIds[drop.GetCreatedById()] {
+			continue
+		}
+		visibleDrops = append(visibleDrops, drop.WithoutContentFrom(hiddenUserIds))
+	}
+	return visibleDrops
+}
+
+// WithoutHiddenUsers removes the hidden users from a list of user ids
+func WithoutHiddenUsers(userIds []uint, hiddenUserIds map[uint]bool) []uint {
+	if len(hiddenUserIds) == 0 {
+		return userIds
+	}
+
+	visibleUserIds := make([]uint, 0, len(userIds))
+	for _, userId := range userIds {
+		if !hiddenUserIds[userId] {
+			visibleUserIds = append(visibleUserIds, userId)
+		}
+	}
+	return visibleUserIds
+}
@@ -0,0 +1,56 @@
+package block
+
+import (
+	"go-api/internal/storage/postgres"
+	"go-api/pkg/model"
+	"gorm.io/gorm"
+	"reflect"
+	"testing"
+)
+
+func TestHideDrops(t *testing.T) {
+	visibleDrop := &postgres.Drop{
+		Model:       gorm.Model{ID: 1},
+		CreatedById: 1,
+		Comments: []postgres.Comment{
+			{Model: gorm.Model{ID: 10}, CreatedById: 2},
+			{
+				Model:       gorm.Model{ID: 11},
+				CreatedById: 3,
+				Responses: []postgres.CommentResponse{
+					{Model: gorm.Model{ID: 20}, CreatedById: 2},
+					{Model: gorm.Model{ID: 21}, CreatedById: 1},
+				},
+			},
+		},
+	}
+	hiddenDrop := &postgres.Drop{Model: gorm.Model{ID: 2}, CreatedById: 2}
+
+	drops := HideDrops([]model.DropModel{visibleDrop, hiddenDrop}, map[uint]bool{2: true})
+
+	if len(drops) != 1 || drops[0].GetID() != 1 {
+		t.Fatalf("HideDrops() kept %d drops, want only drop 1", len(drops))
+	}
+
+	comments := drops[0].GetComments()
+	if len(comments) != 1 || comments[0].GetID() != 11 {
+		t.Fatalf("HideDrops() kept %d comments, want only comment 11", len(comments))
+	}
+
+	responses := comments[0].GetResponses()
+	if len(responses) != 1 || responses[0].GetID() != 21 {
+		t.Errorf("HideDrops() kept %d responses, want only response 21", len(responses))
+	}
+
+	if len(visibleDrop.Comments) != 2 {
+		t.Errorf("HideDrops() modified the original drop")
+	}
+}
+
+func TestWithoutHiddenUsers(t *testing.T) {
+	got := WithoutHiddenUsers([]uint{1, 2, 3}, map[uint]bool{2: true})
+
+	if want := []uint{1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("WithoutHiddenUsers() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,46 @@
+package close_friend
+
+import (
+	"go-api/internal/repositories"
+	"go-api/pkg/errors2"
+	"go-api/pkg/model"
+)
+
+type CloseFriendService struct {
+	Repo *repositories.Repositories
+}
+
+// AddCloseFriend adds one of the user's followers to their close friends
+func (s *CloseFriendService) AddCloseFriend(userID uint, friendID uint) (model.CloseFriendModel, error) {
+	if userID == friendID {
+		return nil, errors2.NotAllowedError{Reason: "You can't add yourself to your close friends"}
+	}
+
+	friend, err := s.Repo.UserRepository.GetById(friendID)
+	if err != nil {
+		return nil, err
+	}
+
+	if nil == friend || friend.GetStatus() != 1 {
+		return nil, errors2.NotFoundError{Entity: "User"}
+	}
+
+	isFollower, err := s.Repo.FollowRepository.IsActiveFollowing(friendID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isFollower {
+		return nil, errors2.NotAllowedError{Reason: "Only your followers can be added to your close friends"}
+	}
+
+	return s.Repo.CloseFriendRepository.Create(userID, friendID)
+}
+
+func (s *CloseFriendService) RemoveCloseFriend(userID uint, friendID uint) error {
+	return s.Repo.CloseFriendRepository.Delete(userID, friendID)
+}
+
+func (s *CloseFriendService) GetCloseFriends(userID uint) ([]model.CloseFriendModel, error) {
+	return s.Repo.CloseFriendRepository.GetCloseFriends(userID)
+}
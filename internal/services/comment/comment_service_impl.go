@@ -3,6 +3,7 @@ package comment
 import (
 	"errors"
 	"go-api/internal/repositories"
+	"go-api/internal/services/visibility"
 	"go-api/pkg/model"
 	"go-api/pkg/validation"
 )
@@ -52,6 +53,11 @@ func (s *CommentService) CanCommentDrop(dropID uint, userID uint) (bool, error)
 		return false, errors.New("drop not found")
 	}
 
+	vs := &visibility.VisibilityService{Repo: s.Repo}
+	if err := vs.CheckDrop(userID, drop); err != nil {
+		return false, err
+	}
+
 	return true, nil
 }
 
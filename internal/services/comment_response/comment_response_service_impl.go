@@ -3,6 +3,7 @@ package comment_response
 import (
 	"errors"
 	"go-api/internal/repositories"
+	"go-api/internal/services/visibility"
 	"go-api/pkg/model"
 	"go-api/pkg/validation"
 )
@@ -56,6 +57,11 @@ func (s *CommentResponseService) CanCommentResponse(commentId uint, userID uint)
 		return false, errors.New("comment not found")
 	}
 
+	vs := &visibility.VisibilityService{Repo: s.Repo}
+	if err := vs.CheckComment(userID, comment); err != nil {
+		return false, err
+	}
+
 	return true, nil
 }
 
@@ -0,0 +1,88 @@
+package drop
+
+import (
+	"go-api/pkg/model"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type DiscoverWeights struct {
+	Likes         float64
+	Comments      float64
+	Recency       float64
+	SharedContent float64
+	// RecencyHalfLife is the age at which a drop gets half of the recency weight
+	RecencyHalfLife time.Duration
+}
+
+// NewDiscoverWeightsFromEnv reads DISCOVER_WEIGHT_LIKES, DISCOVER_WEIGHT_COMMENTS, DISCOVER_WEIGHT_RECENCY,
+// DISCOVER_WEIGHT_SHARED_CONTENT and DISCOVER_RECENCY_HALF_LIFE_MINUTES.
+func NewDiscoverWeightsFromEnv() DiscoverWeights {
+	return DiscoverWeights{
+		Likes:           floatFromEnv("DISCOVER_WEIGHT_LIKES", 1),
+		Comments:        floatFromEnv("DISCOVER_WEIGHT_COMMENTS", 1.5),
+		Recency:         floatFromEnv("DISCOVER_WEIGHT_RECENCY", 2),
+		SharedContent:   floatFromEnv("DISCOVER_WEIGHT_SHARED_CONTENT", 1),
+		RecencyHalfLife: time.Duration(floatFromEnv("DISCOVER_RECENCY_HALF_LIFE_MINUTES", 60) * float64(time.Minute)),
+	}
+}
+
+// DiscoverScore ranks a drop, likes and comments are damped so one viral drop does not hide every other one.
+// sharedContent is the number of other drops of the notification with the same content.
+func DiscoverScore(drop model.DropModel, sharedContent int, now time.Time, weights DiscoverWeights) float64 {
+	score := weights.Likes*math.Log1p(float64(drop.GetTotalLikes())) +
+		weights.Comments*math.Log1p(float64(len(drop.GetComments()))) +
+		weights.SharedContent*math.Log1p(float64(sharedContent))
+
+	if weights.RecencyHalfLife > 0 {
+		age := now.Sub(time.Unix(int64(drop.GetCreatedAt()), 0))
+		if age < 0 {
+			age = 0
+		}
+		score += weights.Recency * math.Pow(0.5, age.Hours()/weights.RecencyHalfLife.Hours())
+	}
+
+	return score
+}
+
+// RankDiscoverDrops sorts the drops by score, the newest first on equal scores
+func RankDiscoverDrops(drops []model.DropModel, dropsByContent map[string]int, now time.Time, weights DiscoverWeights) []model.DropModel {
+	scores := make(map[uint]float64, len(drops))
+	for _, drop := range drops {
+		sharedContent := dropsByContent[drop.GetContent()] - 1
+		if sharedContent < 0 {
+			sharedContent = 0
+		}
+		scores[drop.GetID()] = DiscoverScore(drop, sharedContent, now, weights)
+	}
+
+	ranked := make([]model.DropModel, len(drops))
+	copy(ranked, drops)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if scores[ranked[i].GetID()] != scores[ranked[j].GetID()] {
+			return scores[ranked[i].GetID()] > scores[ranked[j].GetID()]
+		}
+		return ranked[i].GetID() > ranked[j].GetID()
+	})
+
+	return ranked
+}
+
+func floatFromEnv(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed < 0 {
+		log.Printf("Error: Invalid %s %s, falling back to %v\n", key, value, defaultValue)
+		return defaultValue
+	}
+
+	return parsed
+}
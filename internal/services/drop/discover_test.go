@@ -0,0 +1,106 @@
+package drop
+
+import (
+	"go-api/internal/storage/postgres"
+	"go-api/pkg/model"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+func newDiscoverDrop(id uint, content string, likes int, comments int, createdAt time.Time) *postgres.Drop {
+	return &postgres.Drop{
+		Model:      gorm.Model{ID: id, CreatedAt: createdAt},
+		Content:    content,
+		TotalLikes: likes,
+		Comments:   make([]postgres.Comment, comments),
+	}
+}
+
+func TestRankDiscoverDrops(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	weights := DiscoverWeights{Likes: 1, Comments: 1.5, Recency: 2, SharedContent: 1, RecencyHalfLife: time.Hour}
+
+	tests := []struct {
+		name           string
+		drops          []model.DropModel
+		dropsByContent map[string]int
+		weights        DiscoverWeights
+		want           []uint
+	}{
+		{
+			name: "Test liked drops rank first",
+			drops: []model.DropModel{
+				newDiscoverDrop(1, "a", 0, 0, now),
+				newDiscoverDrop(2, "b", 10, 0, now),
+			},
+			want: []uint{2, 1},
+		},
+		{
+			name: "Test comments weigh more than likes",
+			drops: []model.DropModel{
+				newDiscoverDrop(1, "a", 3, 0, now),
+				newDiscoverDrop(2, "b", 0, 3, now),
+			},
+			want: []uint{2, 1},
+		},
+		{
+			name: "Test recent drops rank first",
+			drops: []model.DropModel{
+				newDiscoverDrop(1, "a", 0, 0, now.Add(-3*time.Hour)),
+				newDiscoverDrop(2, "b", 0, 0, now.Add(-10*time.Minute)),
+			},
+			want: []uint{2, 1},
+		},
+		{
+			name: "Test shared content ranks first",
+			drops: []model.DropModel{
+				newDiscoverDrop(1, "spotify:track:1", 0, 0, now),
+				newDiscoverDrop(2, "spotify:track:2", 0, 0, now),
+			},
+			dropsByContent: map[string]int{"spotify:track:1": 1, "spotify:track:2": 4},
+			want:           []uint{2, 1},
+		},
+		{
+			name: "Test weights are configurable",
+			drops: []model.DropModel{
+				newDiscoverDrop(1, "a", 50, 0, now.Add(-5*time.Hour)),
+				newDiscoverDrop(2, "b", 0, 0, now),
+			},
+			weights: DiscoverWeights{Recency: 1, RecencyHalfLife: time.Hour},
+			want:    []uint{2, 1},
+		},
+		{
+			name: "Test equal scores keep the newest drop first",
+			drops: []model.DropModel{
+				newDiscoverDrop(1, "a", 1, 1, now),
+				newDiscoverDrop(3, "c", 1, 1, now),
+				newDiscoverDrop(2, "b", 1, 1, now),
+			},
+			want: []uint{3, 2, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testWeights := weights
+			if tt.weights != (DiscoverWeights{}) {
+				testWeights = tt.weights
+			}
+
+			ranked := RankDiscoverDrops(tt.drops, tt.dropsByContent, now, testWeights)
+
+			var got []uint
+			for _, drop := range ranked {
+				got = append(got, drop.GetID())
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("RankDiscoverDrops() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("RankDiscoverDrops() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
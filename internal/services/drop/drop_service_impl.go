@@ -3,13 +3,21 @@ package drop
 import (
 	"errors"
 	"go-api/internal/repositories"
+	"go-api/internal/services/block"
+	"go-api/internal/services/visibility"
 	"go-api/internal/storage/postgres"
+	"go-api/pkg/drop_type_apis"
 	"go-api/pkg/errors2"
 	"go-api/pkg/file"
 	"go-api/pkg/model"
+	"go-api/pkg/pagination"
 	"go-api/pkg/validation"
 	"gorm.io/gorm"
+	"log"
+	"os"
 	"slices"
+	"strings"
+	"time"
 )
 
 type DropService struct {
@@ -33,9 +41,46 @@ func (s *DropService) CanCreateDrop(userId uint) (bool, error) {
 	return true, nil
 }
 
+// CanSeeFeed tells whether the user can see their friends' drops for the current notification.
+// When DROP_FEED_REQUIRES_OWN_DROP is enabled, users have to drop before seeing the others.
+func (s *DropService) CanSeeFeed(userId uint) (bool, error) {
+	if os.Getenv("DROP_FEED_REQUIRES_OWN_DROP") != "true" {
+		return true, nil
+	}
+
+	return s.HasUserDroppedToday(userId)
+}
+
+// FilterCanSeeFeed returns the users among userIds who can see the feed, with a single query for all of them
+func (s *DropService) FilterCanSeeFeed(userIds []uint) ([]uint, error) {
+	if os.Getenv("DROP_FEED_REQUIRES_OWN_DROP") != "true" {
+		return userIds, nil
+	}
+
+	currentDropNotification, err := s.Repo.DropNotificationRepository.GetCurrentDropNotification()
+	if err != nil {
+		return nil, err
+	}
+
+	if currentDropNotification == nil {
+		return nil, errors.New("no drop notifications found")
+	}
+
+	return s.Repo.DropRepository.GetUserIdsHavingDropped(currentDropNotification.GetID(), userIds)
+}
+
 func (s *DropService) IsValidDropCreation(args model.DropCreationParam) (bool, error) {
 	validationError := validation.ValidateDropCreation(args)
 
+	// The audiences are validated here, pkg/validation being imported by the postgres package
+	if args.Audience != "" && !slices.Contains(postgres.DropAudiences(), args.Audience) {
+		validationError.Fields["audience"] = "Invalid audience"
+	}
+
+	if args.Audience == new(postgres.DropAudienceGroups).ToString() && len(args.Groups) == 0 {
+		validationError.Fields["groups"] = "At least one group is required to share a drop with groups only"
+	}
+
 	if len(validationError.Fields) > 0 {
 		return false, validationError
 	}
@@ -52,11 +97,45 @@ func (s *DropService) CreateDrop(userId uint, args model.DropCreationParam) (mod
 		return nil, err
 	}
 
+	user, err := s.Repo.UserRepository.GetById(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	audience := args.Audience
+	if audience == "" {
+		audience = new(postgres.DropAudienceFollowers).ToString()
+	}
+
+	// Close friends drops stay out of groups
+	var dropGroupIds []uint
+	if audience != new(postgres.DropAudienceCloseFriends).ToString() {
+		for _, group := range user.GetGroups() {
+			if slices.Contains(args.Groups, group.GetID()) {
+				dropGroupIds = append(dropGroupIds, group.GetID())
+			}
+		}
+	}
+
+	if audience == new(postgres.DropAudienceGroups).ToString() && len(dropGroupIds) == 0 {
+		return nil, errors2.MultiFieldsError{Fields: map[string]string{"groups": "You must be a member of the selected groups"}}
+	}
+
 	currentDropNotification, err := s.Repo.DropNotificationRepository.GetCurrentDropNotification()
 	if err != nil {
 		return nil, err
 	}
 
+	metadata, err := ResolveDropContent(currentDropNotification, args)
+	if err != nil {
+		return nil, err
+	}
+
 	var picturePath string
 	if args.Picture != nil {
 		picturePath, err = file.UploadFile(args.Picture)
@@ -65,18 +144,27 @@ func (s *DropService) CreateDrop(userId uint, args model.DropCreationParam) (mod
 		}
 	}
 
+	isLate, lateBy := GetLateness(currentDropNotification, time.Now())
+
 	filledDrop := model.FilledDropCreation{
 		Type:               currentDropNotification.GetType(),
-		Content:            args.Content,
-		ContentTile:        args.ContentTitle,
-		ContentSubTitle:    args.ContentSubTitle,
-		ContentPicturePath: args.ContentPicturePath,
+		Content:            metadata.Content,
+		ContentTile:        metadata.Title,
+		ContentSubTitle:    metadata.Subtitle,
+		ContentPicturePath: metadata.PicturePath,
 		Description:        args.Description,
 		DropNotificationId: currentDropNotification.GetID(),
 		PicturePath:        picturePath,
 		Lat:                args.Lat,
 		Lng:                args.Lng,
 		Location:           args.Location,
+		IsLate:             isLate,
+		LateBy:             lateBy,
+		ContentDuration:    metadata.Duration,
+		ContentReleaseYear: metadata.ReleaseYear,
+		ContentGenres:      metadata.Genres,
+		ContentLink:        metadata.Link,
+		Audience:           audience,
 	}
 
 	statusActive := postgres.DropStatusActive{}
@@ -96,32 +184,64 @@ func (s *DropService) CreateDrop(userId uint, args model.DropCreationParam) (mod
 		filledDrop.Lat,
 		filledDrop.Lng,
 		filledDrop.Location,
+		filledDrop.IsLate,
+		filledDrop.LateBy,
+		filledDrop.ContentDuration,
+		filledDrop.ContentReleaseYear,
+		filledDrop.ContentGenres,
+		filledDrop.ContentLink,
+		filledDrop.Audience,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
-	user, err := s.Repo.UserRepository.GetById(userId)
+	for _, groupId := range dropGroupIds {
+		_, err = s.Repo.GroupDropRepository.Create(createdDrop.GetID(), groupId)
+		if err != nil {
+			return nil, err
+		}
+	}
 
+	return s.Repo.DropRepository.GetDropById(createdDrop.GetID())
+}
+
+// ResolveDropContent looks the dropped content up with the provider of the current notification,
+// so drops store what the provider knows rather than what the client sent.
+// Fields the provider leaves empty fall back to the ones picked by the user.
+func ResolveDropContent(notification model.DropNotificationModel, args model.DropCreationParam) (drop_type_apis.ContentMetadata, error) {
+	metadata, err := drop_type_apis.ResolveContent(notification.GetType(), args.Content, args.ContentTitle)
 	if err != nil {
-		return nil, err
+		var rateLimitedError errors2.RateLimitedError
+		if errors.As(err, &rateLimitedError) {
+			return metadata, err
+		}
+		if errors.Is(err, drop_type_apis.ErrContentNotFound) {
+			return metadata, errors2.CannotDropError{Reason: "Content could not be found"}
+		}
+		log.Printf("Error: Error resolving %s content %s: %v\n", notification.GetType(), args.Content, err)
+		return metadata, errors2.CannotDropError{Reason: "Content could not be verified, please try again later"}
 	}
 
-	if user == nil {
-		return nil, errors.New("user not found")
+	if notification.GetKind() != "" && metadata.Kind != "" && metadata.Kind != notification.GetKind() {
+		return metadata, errors2.CannotDropError{Reason: "Content does not match the kind of this drop"}
 	}
 
-	for _, group := range user.GetGroups() {
-		if slices.Contains(args.Groups, group.GetID()) {
-			_, err = s.Repo.GroupDropRepository.Create(createdDrop.GetID(), group.GetID())
-			if err != nil {
-				return nil, err
-			}
-		}
+	if metadata.Content == "" {
+		metadata.Content = args.Content
+	}
+	if metadata.Title == "" {
+		metadata.Title = args.ContentTitle
+	}
+	if metadata.Subtitle == "" {
+		metadata.Subtitle = args.ContentSubTitle
+	}
+	if metadata.PicturePath == "" {
+		metadata.PicturePath = args.ContentPicturePath
 	}
 
-	return s.Repo.DropRepository.GetDropById(createdDrop.GetID())
+	return metadata, nil
 }
 
 func (s *DropService) GetUserFeed(userId uint) ([]model.DropModel, error) {
@@ -145,6 +265,16 @@ func (s *DropService) GetUserFeed(userId uint) ([]model.DropModel, error) {
 		return nil, errors.New("No drop notifications found")
 	}
 
+	canSeeFeed, err := s.CanSeeFeed(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !canSeeFeed {
+		return []model.DropModel{}, nil
+	}
+
 	followingUsers, err := s.Repo.FollowRepository.GetFollowing(userId)
 
 	if err != nil {
@@ -158,17 +288,255 @@ func (s *DropService) GetUserFeed(userId uint) ([]model.DropModel, error) {
 
 	followingUserIds = append(followingUserIds, userId)
 
+	hiddenUserIds, err := s.blockService().GetHiddenUserIds(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	followingUserIds = block.WithoutHiddenUsers(followingUserIds, hiddenUserIds)
+
 	drops, err := s.Repo.DropRepository.GetDropsByUserIdsAndDropNotificationId(followingUserIds, lastDropNotification.GetID())
 
 	if err != nil {
 		return nil, err
 	}
 
+	drops, err = s.visibilityService().FilterDrops(userId, drops)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return block.HideDrops(drops, hiddenUserIds), nil
+}
+
+const (
+	defaultFeedHistoryLimit = 20
+	maxFeedHistoryLimit     = 50
+)
+
+// GetUserFeedHistory returns a page of the drops of followed users across all notifications,
+// along with the cursor of the next page, empty on the last one.
+func (s *DropService) GetUserFeedHistory(userId uint, args model.FeedHistoryParam) ([]model.DropModel, string, error) {
+	isActiveUser, err := s.Repo.UserRepository.IsActiveUser(userId)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !isActiveUser {
+		return nil, "", errors.New("User is not active")
+	}
+
+	errorsFields := make(map[string]string)
+
+	dropType := strings.ToLower(args.Type)
+	if dropType != "" && !drop_type_apis.IsRegisteredDropType(dropType) {
+		errorsFields["type"] = "Invalid drop type"
+	}
+
+	var after *model.DropFeedCursor
+	if args.Cursor != "" {
+		after = &model.DropFeedCursor{}
+		if err := pagination.DecodeCursor(args.Cursor, after); err != nil {
+			errorsFields["cursor"] = "Invalid cursor"
+		}
+	}
+
+	if len(errorsFields) > 0 {
+		return nil, "", errors2.MultiFieldsError{Fields: errorsFields}
+	}
+
+	followingUsers, err := s.Repo.FollowRepository.GetFollowing(userId)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	userIds := []uint{userId}
+	for _, follow := range followingUsers {
+		userIds = append(userIds, follow.GetFollowedID())
+	}
+
+	if args.UserId != 0 {
+		if !slices.Contains(userIds, args.UserId) {
+			return nil, "", errors2.NotAllowedError{Reason: "You can only see the drops of users you follow"}
+		}
+		userIds = []uint{args.UserId}
+	}
+
+	hiddenUserIds, err := s.blockService().GetHiddenUserIds(userId)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	userIds = block.WithoutHiddenUsers(userIds, hiddenUserIds)
+
+	if len(userIds) == 0 {
+		return []model.DropModel{}, "", nil
+	}
+
+	filter := model.DropFeedFilter{
+		UserIds: userIds,
+		Type:    dropType,
+		After:   after,
+	}
+
+	canSeeFeed, err := s.CanSeeFeed(userId)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !canSeeFeed {
+		currentDropNotification, err := s.Repo.DropNotificationRepository.GetCurrentDropNotification()
+		if err != nil {
+			return nil, "", err
+		}
+		filter.ExcludedDropNotificationID = currentDropNotification.GetID()
+	}
+
+	limit := pagination.ClampLimit(args.Limit, defaultFeedHistoryLimit, maxFeedHistoryLimit)
+	// One more drop tells whether there is a next page
+	filter.Limit = limit + 1
+
+	drops, err := s.Repo.DropRepository.GetDropsFeedPage(filter)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if len(drops) > limit {
+		drops = drops[:limit]
+		// The cursor is taken before filtering so hidden drops are not fetched again
+		nextCursor, err = pagination.EncodeCursor(drops[limit-1].GetFeedCursor())
+
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	drops, err = s.visibilityService().FilterDrops(userId, drops)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return block.HideDrops(drops, hiddenUserIds), nextCursor, nil
+}
+
+const (
+	defaultDiscoverLimit = 20
+	maxDiscoverLimit     = 50
+	// discoverCandidatesLimit bounds the number of drops ranked for one request
+	discoverCandidatesLimit = 300
+)
+
+// GetDiscoverFeed returns the best ranked drops of the current notification from public users the user does not follow.
+// Returned drops are marked as seen and will not be suggested again.
+func (s *DropService) GetDiscoverFeed(userId uint, limit int) ([]model.DropModel, error) {
+	isActiveUser, err := s.Repo.UserRepository.IsActiveUser(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !isActiveUser {
+		return nil, errors.New("User is not active")
+	}
+
+	canSeeFeed, err := s.CanSeeFeed(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !canSeeFeed {
+		return []model.DropModel{}, nil
+	}
+
+	currentDropNotification, err := s.Repo.DropNotificationRepository.GetCurrentDropNotification()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if currentDropNotification.GetID() == 0 {
+		return []model.DropModel{}, nil
+	}
+
+	candidates, err := s.Repo.DropRepository.GetDiscoverCandidates(userId, currentDropNotification.GetID(), discoverCandidatesLimit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dropsByContent, err := s.Repo.DropRepository.CountDropsByContent(currentDropNotification.GetID())
+
+	if err != nil {
+		return nil, err
+	}
+
+	hiddenUserIds, err := s.blockService().GetHiddenUserIds(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err = s.visibilityService().FilterDrops(userId, candidates)
+
+	if err != nil {
+		return nil, err
+	}
+
+	candidates = block.HideDrops(candidates, hiddenUserIds)
+
+	drops := RankDiscoverDrops(candidates, dropsByContent, time.Now(), NewDiscoverWeightsFromEnv())
+
+	limit = pagination.ClampLimit(limit, defaultDiscoverLimit, maxDiscoverLimit)
+	if len(drops) > limit {
+		drops = drops[:limit]
+	}
+
+	var dropIds []uint
+	for _, drop := range drops {
+		dropIds = append(dropIds, drop.GetID())
+	}
+
+	if err := s.Repo.DropViewRepository.MarkAsSeen(userId, dropIds); err != nil {
+		return nil, err
+	}
+
 	return drops, nil
 }
 
 func (s *DropService) GetDropById(dropID uint, requesterID uint) (model.DropModel, error) {
-	return s.Repo.DropRepository.GetDropById(dropID)
+	drop, err := s.Repo.DropRepository.GetDropById(dropID)
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors2.NotFoundError{Entity: "Drop"}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	drop, err = s.visibilityService().VisibleDrop(requesterID, drop)
+
+	if err != nil {
+		return nil, err
+	}
+
+	hiddenUserIds, err := s.blockService().GetHiddenUserIds(requesterID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return drop.WithoutContentFrom(hiddenUserIds), nil
 }
 
 func (s *DropService) GetDropsByUserId(userId uint, currentUser model.UserModel) ([]model.DropModel, error) {
@@ -192,29 +560,46 @@ func (s *DropService) GetDropsByUserId(userId uint, currentUser model.UserModel)
 		return nil, errors.New("User not found")
 	}
 
-	if user.IsPrivateUser() {
-		if nil == currentUser {
-			return nil, nil
+	if nil != currentUser {
+		if err := s.blockService().CheckNotBlocked(currentUser.GetID(), userId); err != nil {
+			var notAllowedErr errors2.NotAllowedError
+			if errors.As(err, &notAllowedErr) {
+				return nil, errors2.NotFoundError{Entity: "User"}
+			}
+			return nil, err
 		}
+	}
 
-		currentUserIsFollowing, err := s.Repo.FollowRepository.IsActiveFollowing(currentUser.GetID(), userId)
+	drops, err := s.Repo.DropRepository.GetUserDrops(userId)
 
-		if err != nil {
-			return nil, err
-		}
+	if err != nil {
+		return nil, err
+	}
 
-		if !currentUserIsFollowing {
-			return nil, nil
-		}
+	// Drops of private users are filtered out here, but for the ones shared with groups of the current user
+	if nil == currentUser {
+		return s.visibilityService().FilterDrops(0, drops)
 	}
 
-	drops, err := s.Repo.DropRepository.GetUserDrops(userId)
+	drops, err = s.visibilityService().FilterDrops(currentUser.GetID(), drops)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return drops, nil
+	hiddenUserIds, err := s.blockService().GetHiddenUserIds(currentUser.GetID())
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Muted users stay reachable from their profile, only their comments are hidden
+	visibleDrops := make([]model.DropModel, 0, len(drops))
+	for _, drop := range drops {
+		visibleDrops = append(visibleDrops, drop.WithoutContentFrom(hiddenUserIds))
+	}
+
+	return visibleDrops, nil
 }
 
 func (s *DropService) HasUserDroppedToday(userId uint) (bool, error) {
@@ -247,6 +632,20 @@ func (s *DropService) IsCurrentUserLiking(dropId uint, userId uint) (bool, error
 	return likeExists, nil
 }
 
+// GetLikingUserIds tells which of the users like the drop
+func (s *DropService) GetLikingUserIds(dropId uint, userIds []uint) (map[uint]bool, error) {
+	likingUserIds, err := s.Repo.LikeRepository.GetLikingUserIds(dropId, userIds)
+	if err != nil {
+		return nil, err
+	}
+
+	isLiking := make(map[uint]bool, len(likingUserIds))
+	for _, userId := range likingUserIds {
+		isLiking[userId] = true
+	}
+	return isLiking, nil
+}
+
 func (s *DropService) DeleteDrop(dropID uint, requesterID uint) error {
 	drop, err := s.Repo.DropRepository.GetDropById(dropID)
 
@@ -300,3 +699,26 @@ func (s *DropService) PatchDrop(dropID uint, requesterID uint, patch model.DropP
 
 	return s.Repo.DropRepository.GetDropById(updatedDrop.GetID())
 }
+
+// GetLateness returns whether a drop posted at postedAt missed the notification response window, and by how many seconds.
+func GetLateness(dropNotification model.DropNotificationModel, postedAt time.Time) (bool, int) {
+	deadline := dropNotification.GetDeadline()
+	if deadline == 0 {
+		return false, 0
+	}
+
+	lateBy := postedAt.Unix() - int64(deadline)
+	if lateBy <= 0 {
+		return false, 0
+	}
+
+	return true, int(lateBy)
+}
+
+func (s *DropService) blockService() *block.BlockService {
+	return &block.BlockService{Repo: s.Repo}
+}
+
+func (s *DropService) visibilityService() *visibility.VisibilityService {
+	return &visibility.VisibilityService{Repo: s.Repo}
+}
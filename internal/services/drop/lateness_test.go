@@ -0,0 +1,51 @@
+package drop
+
+import (
+	"go-api/internal/storage/postgres"
+	"testing"
+	"time"
+)
+
+func TestGetLateness(t *testing.T) {
+	firedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		notification *postgres.DropNotification
+		postedAt     time.Time
+		wantLate     bool
+		wantLateBy   int
+	}{
+		{
+			name:         "Test drop within the response window is on time",
+			notification: &postgres.DropNotification{FiredAt: &firedAt, ResponseWindow: 10},
+			postedAt:     firedAt.Add(5 * time.Minute),
+		},
+		{
+			name:         "Test drop at the deadline is on time",
+			notification: &postgres.DropNotification{FiredAt: &firedAt, ResponseWindow: 10},
+			postedAt:     firedAt.Add(10 * time.Minute),
+		},
+		{
+			name:         "Test drop after the deadline is late",
+			notification: &postgres.DropNotification{FiredAt: &firedAt, ResponseWindow: 10},
+			postedAt:     firedAt.Add(12 * time.Minute),
+			wantLate:     true,
+			wantLateBy:   120,
+		},
+		{
+			name:         "Test unfired notification is never late",
+			notification: &postgres.DropNotification{ResponseWindow: 10},
+			postedAt:     firedAt.Add(time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			late, lateBy := GetLateness(tt.notification, tt.postedAt)
+			if late != tt.wantLate || lateBy != tt.wantLateBy {
+				t.Errorf("GetLateness() = (%v, %d), want (%v, %d)", late, lateBy, tt.wantLate, tt.wantLateBy)
+			}
+		})
+	}
+}
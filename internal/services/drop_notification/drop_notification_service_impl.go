@@ -0,0 +1,142 @@
+package drop_notification
+
+import (
+	"go-api/internal/repositories"
+	"go-api/pkg/drop_type_apis"
+	"go-api/pkg/errors2"
+	"go-api/pkg/model"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultResponseWindow = 10
+
+// DefaultResponseWindow returns the number of minutes users have to drop on time,
+// read from DROP_RESPONSE_WINDOW_MINUTES.
+func DefaultResponseWindow() int {
+	value := os.Getenv("DROP_RESPONSE_WINDOW_MINUTES")
+	if value == "" {
+		return defaultResponseWindow
+	}
+
+	responseWindow, err := strconv.Atoi(value)
+	if err != nil || responseWindow <= 0 {
+		log.Printf("Error: Invalid DROP_RESPONSE_WINDOW_MINUTES %s, falling back to %d minutes\n", value, defaultResponseWindow)
+		return defaultResponseWindow
+	}
+
+	return responseWindow
+}
+
+type DropNotificationService struct {
+	Repo *repositories.Repositories
+}
+
+func (s *DropNotificationService) ScheduleDrop(args model.ScheduleDropParam) (model.DropNotificationModel, error) {
+	dropType := strings.ToLower(args.Type)
+	kind := strings.ToLower(args.Kind)
+
+	errorsFields := validateScheduledDrop(dropType, kind, args.ScheduledAt, args.ResponseWindow)
+	if len(errorsFields) > 0 {
+		return nil, errors2.MultiFieldsError{Fields: errorsFields}
+	}
+
+	responseWindow := args.ResponseWindow
+	if responseWindow == 0 {
+		responseWindow = DefaultResponseWindow()
+	}
+
+	return s.Repo.DropNotificationRepository.Schedule(dropType, kind, args.ScheduledAt, responseWindow)
+}
+
+func (s *DropNotificationService) GetUpcomingDrops() ([]model.DropNotificationModel, error) {
+	return s.Repo.DropNotificationRepository.GetUpcomingDropNotifications()
+}
+
+func (s *DropNotificationService) RescheduleDrop(notificationId uint, args model.RescheduleDropParam) (model.DropNotificationModel, error) {
+	scheduledDrop, err := s.Repo.DropNotificationRepository.GetNotificationByID(notificationId)
+	if err != nil {
+		return nil, err
+	}
+
+	if scheduledDrop.GetID() == 0 {
+		return nil, errors2.NotFoundError{Entity: "Scheduled drop"}
+	}
+
+	updates := map[string]interface{}{
+		"scheduled_at": args.ScheduledAt,
+	}
+
+	dropType := scheduledDrop.GetType()
+	kind := scheduledDrop.GetKind()
+	if args.Type != "" && strings.ToLower(args.Type) != dropType {
+		dropType = strings.ToLower(args.Type)
+		// The previous kind belongs to the previous type
+		kind = ""
+		updates["type"] = dropType
+		updates["kind"] = kind
+	}
+
+	if args.Kind != "" {
+		kind = strings.ToLower(args.Kind)
+		updates["kind"] = kind
+	}
+
+	if args.ResponseWindow != 0 {
+		updates["response_window"] = args.ResponseWindow
+	}
+
+	errorsFields := validateScheduledDrop(dropType, kind, args.ScheduledAt, args.ResponseWindow)
+	if len(errorsFields) > 0 {
+		return nil, errors2.MultiFieldsError{Fields: errorsFields}
+	}
+
+	dropNotification, err := s.Repo.DropNotificationRepository.UpdatePending(notificationId, updates)
+	if err != nil {
+		return nil, err
+	}
+
+	if dropNotification == nil {
+		return nil, errors2.NotFoundError{Entity: "Scheduled drop"}
+	}
+
+	return dropNotification, nil
+}
+
+func (s *DropNotificationService) CancelDrop(notificationId uint) error {
+	cancelled, err := s.Repo.DropNotificationRepository.Cancel(notificationId)
+	if err != nil {
+		return err
+	}
+
+	if !cancelled {
+		return errors2.NotFoundError{Entity: "Scheduled drop"}
+	}
+
+	return nil
+}
+
+func validateScheduledDrop(dropType string, kind string, scheduledAt time.Time, responseWindow int) map[string]string {
+	errorsFields := make(map[string]string)
+
+	if !drop_type_apis.IsValidDropType(dropType) {
+		errorsFields["type"] = "Invalid drop type"
+	} else if !drop_type_apis.IsValidDropKind(dropType, kind) {
+		errorsFields["kind"] = "Invalid kind for this drop type"
+	}
+
+	if scheduledAt.IsZero() {
+		errorsFields["scheduledAt"] = "Scheduled date is required"
+	} else if !scheduledAt.After(time.Now()) {
+		errorsFields["scheduledAt"] = "Scheduled date must be in the future"
+	}
+
+	if responseWindow < 0 {
+		errorsFields["responseWindow"] = "Response window must be a positive number of minutes"
+	}
+
+	return errorsFields
+}
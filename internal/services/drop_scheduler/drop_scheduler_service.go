@@ -0,0 +1,223 @@
+package drop_scheduler
+
+import (
+	"context"
+	"go-api/internal/repositories"
+	dropnotificationservice "go-api/internal/services/drop_notification"
+	"go-api/pkg/drop_type_apis"
+	"go-api/pkg/model"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+)
+
+type DropSchedulerConfig struct {
+	Enabled      bool
+	StartHour    int
+	EndHour      int
+	Location     *time.Location
+	TickInterval time.Duration
+}
+
+// NewDropSchedulerConfigFromEnv reads DROP_SCHEDULER_ENABLED, DROP_WINDOW_START_HOUR,
+// DROP_WINDOW_END_HOUR and DROP_SCHEDULER_TIMEZONE, falling back to a 8h-21h local window.
+func NewDropSchedulerConfigFromEnv() DropSchedulerConfig {
+	config := DropSchedulerConfig{
+		Enabled:      os.Getenv("DROP_SCHEDULER_ENABLED") != "false",
+		StartHour:    8,
+		EndHour:      21,
+		Location:     time.Local,
+		TickInterval: 30 * time.Second,
+	}
+
+	if startHour, err := strconv.Atoi(os.Getenv("DROP_WINDOW_START_HOUR")); err == nil && startHour >= 0 && startHour < 24 {
+		config.StartHour = startHour
+	}
+
+	if endHour, err := strconv.Atoi(os.Getenv("DROP_WINDOW_END_HOUR")); err == nil && endHour > 0 && endHour <= 24 {
+		config.EndHour = endHour
+	}
+
+	if config.EndHour <= config.StartHour {
+		log.Printf("Error: Invalid drop window %dh-%dh, falling back to 8h-21h\n", config.StartHour, config.EndHour)
+		config.StartHour = 8
+		config.EndHour = 21
+	}
+
+	if timezone := os.Getenv("DROP_SCHEDULER_TIMEZONE"); timezone != "" {
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			log.Printf("Error: Unknown timezone %s for drop scheduler: %v\n", timezone, err)
+		} else {
+			config.Location = location
+		}
+	}
+
+	return config
+}
+
+type DropSchedulerService struct {
+	Repo   *repositories.Repositories
+	Config DropSchedulerConfig
+	// OnFire is called once a planned drop notification becomes the current one
+	OnFire func(notification model.DropNotificationModel)
+	rand   *rand.Rand
+}
+
+func NewDropSchedulerService(repo *repositories.Repositories, config DropSchedulerConfig, onFire func(notification model.DropNotificationModel)) *DropSchedulerService {
+	return &DropSchedulerService{
+		Repo:   repo,
+		Config: config,
+		OnFire: onFire,
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Run plans and fires drop notifications until the context is cancelled.
+// Everything is persisted, so restarting the API neither re-plans a day nor fires a drop twice.
+// When the scheduler is disabled no day is planned, but drops scheduled by admins are still fired.
+func (s *DropSchedulerService) Run(ctx context.Context) {
+	if s.Config.Enabled {
+		log.Printf("Info: Drop scheduler started, window is %dh-%dh (%s)\n", s.Config.StartHour, s.Config.EndHour, s.Config.Location)
+	} else {
+		log.Println("Info: Drop scheduler is disabled, only scheduled drops are fired")
+	}
+
+	ticker := time.NewTicker(s.Config.TickInterval)
+	defer ticker.Stop()
+
+	for {
+		s.Tick(time.Now())
+
+		select {
+		case <-ctx.Done():
+			log.Println("Info: Drop scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *DropSchedulerService) Tick(now time.Time) {
+	now = now.In(s.Config.Location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.Config.Location)
+
+	if s.Config.Enabled {
+		for _, day := range []time.Time{today, today.AddDate(0, 0, 1)} {
+			if err := s.PlanDay(day, now); err != nil {
+				log.Printf("Error: Error planning drop notification for %s: %v\n", day.Format(time.DateOnly), err)
+			}
+		}
+	}
+
+	if err := s.FireDueNotifications(now); err != nil {
+		log.Printf("Error: Error firing drop notifications: %v\n", err)
+	}
+}
+
+// PlanDay persists a drop notification at a random time inside the day's window, unless one is already planned.
+func (s *DropSchedulerService) PlanDay(day time.Time, now time.Time) error {
+	windowStart, windowEnd := DropWindow(day, s.Config.StartHour, s.Config.EndHour)
+	if !windowEnd.After(now) {
+		return nil
+	}
+
+	lastNotification, err := s.Repo.DropNotificationRepository.GetLatestPlannedDropNotification()
+	if err != nil {
+		return err
+	}
+
+	lastType := ""
+	if lastNotification != nil {
+		lastType = lastNotification.GetType()
+	}
+
+	from := windowStart
+	if from.Before(now) {
+		from = now
+	}
+
+	scheduledAt := RandomTimeBetween(from, windowEnd, s.rand)
+	dropType := NextDropType(drop_type_apis.GetValidDropTypes(), lastType)
+
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	planned, err := s.Repo.DropNotificationRepository.PlanIfNoneBetween(dropType, scheduledAt, dropnotificationservice.DefaultResponseWindow(), dayStart, dayStart.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	if planned != nil {
+		log.Printf("Info: Drop notification %d (%s) planned for %v\n", planned.GetID(), planned.GetType(), scheduledAt)
+	}
+
+	return nil
+}
+
+// FireDueNotifications fires the most recent due notification.
+// Older ones that were missed while the API was down are cancelled so users only get one drop.
+func (s *DropSchedulerService) FireDueNotifications(now time.Time) error {
+	dueNotifications, err := s.Repo.DropNotificationRepository.GetDueDropNotifications(now)
+	if err != nil {
+		return err
+	}
+
+	for i, notification := range dueNotifications {
+		if i < len(dueNotifications)-1 {
+			cancelled, err := s.Repo.DropNotificationRepository.Cancel(notification.GetID())
+			if err != nil {
+				return err
+			}
+			if cancelled {
+				log.Printf("Info: Drop notification %d was missed and has been cancelled\n", notification.GetID())
+			}
+			continue
+		}
+
+		fired, err := s.Repo.DropNotificationRepository.MarkAsFired(notification.GetID(), time.Now())
+		if err != nil {
+			return err
+		}
+
+		if !fired {
+			continue
+		}
+
+		log.Printf("Info: Firing drop notification %d (%s)\n", notification.GetID(), notification.GetType())
+		if s.OnFire != nil {
+			s.OnFire(notification)
+		}
+	}
+
+	return nil
+}
+
+func DropWindow(day time.Time, startHour int, endHour int) (time.Time, time.Time) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), startHour, 0, 0, 0, day.Location())
+	end := time.Date(day.Year(), day.Month(), day.Day(), endHour, 0, 0, 0, day.Location())
+	return start, end
+}
+
+func RandomTimeBetween(from time.Time, to time.Time, r *rand.Rand) time.Time {
+	window := to.Sub(from)
+	if window <= 0 {
+		return from
+	}
+	return from.Add(time.Duration(r.Int63n(int64(window))))
+}
+
+// NextDropType rotates through the valid drop types, starting again from the first one.
+func NextDropType(validTypes []string, lastType string) string {
+	if len(validTypes) == 0 {
+		return ""
+	}
+
+	for i, dropType := range validTypes {
+		if dropType == lastType {
+			return validTypes[(i+1)%len(validTypes)]
+		}
+	}
+
+	return validTypes[0]
+}
@@ -0,0 +1,46 @@
+package drop_scheduler
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestNextDropType(t *testing.T) {
+	validTypes := []string{"youtube", "spotify", "films", "twitch"}
+
+	tests := []struct {
+		name     string
+		lastType string
+		want     string
+	}{
+		{name: "Test first drop starts the rotation", lastType: "", want: "youtube"},
+		{name: "Test rotation moves to the next type", lastType: "spotify", want: "films"},
+		{name: "Test rotation wraps around", lastType: "twitch", want: "youtube"},
+		{name: "Test unknown type restarts the rotation", lastType: "books", want: "youtube"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextDropType(validTypes, tt.lastType); got != tt.want {
+				t.Errorf("NextDropType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRandomTimeBetween(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	day := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	from, to := DropWindow(day, 8, 21)
+
+	for i := 0; i < 1000; i++ {
+		got := RandomTimeBetween(from, to, r)
+		if got.Before(from) || !got.Before(to) {
+			t.Fatalf("RandomTimeBetween() = %v, want between %v and %v", got, from, to)
+		}
+	}
+
+	if got := RandomTimeBetween(to, from, r); !got.Equal(to) {
+		t.Errorf("RandomTimeBetween() with an empty window = %v, want %v", got, to)
+	}
+}
@@ -0,0 +1,50 @@
+package follow
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+const defaultFollowRequestExpiryDays = 30
+
+// FollowRequestExpiryFromEnv reads FOLLOW_REQUEST_EXPIRY_DAYS, 0 keeps the requests until they are answered
+func FollowRequestExpiryFromEnv() time.Duration {
+	days := defaultFollowRequestExpiryDays
+
+	if value := os.Getenv("FOLLOW_REQUEST_EXPIRY_DAYS"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			log.Printf("Error: Invalid FOLLOW_REQUEST_EXPIRY_DAYS %s, falling back to %d days\n", value, defaultFollowRequestExpiryDays)
+		} else {
+			days = parsed
+		}
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// RunRequestExpiry expires the unanswered follow requests every tickInterval until the context is cancelled.
+func (s *FollowService) RunRequestExpiry(ctx context.Context, tickInterval time.Duration) {
+	if s.RequestExpiry <= 0 {
+		log.Println("Info: Follow requests never expire")
+		return
+	}
+
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ExpirePendingRequests(time.Now()); err != nil {
+			log.Printf("Error: Error expiring follow requests: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
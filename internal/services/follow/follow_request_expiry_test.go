@@ -0,0 +1,35 @@
+package follow
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFollowRequestExpiryFromEnv(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 30 * 24 * time.Hour},
+		{"7", 7 * 24 * time.Hour},
+		{"0", 0},
+		{"-1", 30 * 24 * time.Hour},
+		{"soon", 30 * 24 * time.Hour},
+	}
+
+	for _, test := range tests {
+		t.Setenv("FOLLOW_REQUEST_EXPIRY_DAYS", test.value)
+		if got := FollowRequestExpiryFromEnv(); got != test.want {
+			t.Errorf("FOLLOW_REQUEST_EXPIRY_DAYS=%q: got %v, want %v", test.value, got, test.want)
+		}
+	}
+}
+
+func TestExpirePendingRequestsDisabled(t *testing.T) {
+	s := &FollowService{}
+
+	expired, err := s.ExpirePendingRequests(time.Now())
+	if err != nil || expired != nil {
+		t.Errorf("got %v, %v, want nothing expired when expiry is disabled", expired, err)
+	}
+}
@@ -1,13 +1,22 @@
 package follow
 
 import (
+	"errors"
 	"go-api/internal/repositories"
+	"go-api/internal/services/block"
 	"go-api/pkg/errors2"
 	"go-api/pkg/model"
+	"gorm.io/gorm"
+	"log"
+	"time"
 )
 
 type FollowService struct {
 	Repo *repositories.Repositories
+	// OnRequestTransition is called for every follow request the service accepts, cancels or expires
+	OnRequestTransition func(follow model.FollowModel, transition model.FollowRequestTransition)
+	// RequestExpiry is how long a request waits for an answer, 0 keeps them forever
+	RequestExpiry time.Duration
 }
 
 func (s *FollowService) GetUserFollowing(userID uint, requesterID uint) ([]model.FollowModel, error) {
@@ -57,6 +66,17 @@ func (s *FollowService) GetUserFollowers(userID uint, requesterID uint) ([]model
 	return s.Repo.FollowRepository.GetFollowers(userID)
 }
 
+// FollowUser creates the follow, pending until accepted when the followed user is private.
+// Users cannot follow someone who blocked them or whom they blocked.
+func (s *FollowService) FollowUser(requesterID uint, followedID uint, isPrivate bool) (model.FollowModel, error) {
+	bs := &block.BlockService{Repo: s.Repo}
+	if err := bs.CheckNotBlocked(requesterID, followedID); err != nil {
+		return nil, err
+	}
+
+	return s.Repo.FollowRepository.Create(requesterID, followedID, !isPrivate)
+}
+
 func (s *FollowService) DeleteFollow(requesterID uint, followID uint) error {
 	follow, err := s.Repo.FollowRepository.GetFollowByID(followID)
 	if err != nil {
@@ -73,3 +93,99 @@ func (s *FollowService) DeleteFollow(requesterID uint, followID uint) error {
 
 	return s.Repo.FollowRepository.Delete(follow.GetID())
 }
+
+func (s *FollowService) GetSentRequests(requesterID uint) ([]model.FollowModel, error) {
+	return s.Repo.FollowRepository.GetSentRequests(requesterID)
+}
+
+func (s *FollowService) CancelRequest(requesterID uint, followID uint) (model.FollowModel, error) {
+	follow, err := s.Repo.FollowRepository.GetPendingFollowByID(followID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && follow == nil) {
+		return nil, errors2.NotFoundError{Entity: "Follow request"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if follow.GetFollowerID() != requesterID {
+		return nil, errors2.NotAllowedError{Reason: "You are not allowed to cancel this follow request"}
+	}
+
+	if err := s.Repo.FollowRepository.Delete(follow.GetID()); err != nil {
+		return nil, err
+	}
+
+	s.notify(follow, model.FollowRequestCanceled)
+
+	return follow, nil
+}
+
+// AcceptPendingRequests accepts every request waiting for the user, once they are not private anymore
+func (s *FollowService) AcceptPendingRequests(userID uint) ([]model.FollowModel, error) {
+	accepted, err := s.Repo.FollowRepository.AcceptPendingRequests(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, follow := range accepted {
+		s.notify(follow, model.FollowRequestAccepted)
+	}
+
+	return accepted, nil
+}
+
+func (s *FollowService) ExpirePendingRequests(now time.Time) ([]model.FollowModel, error) {
+	if s.RequestExpiry <= 0 {
+		return nil, nil
+	}
+
+	expired, err := s.Repo.FollowRepository.ExpirePendingRequests(now.Add(-s.RequestExpiry))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, follow := range expired {
+		s.notify(follow, model.FollowRequestExpired)
+	}
+
+	return expired, nil
+}
+
+// RemoveFollower removes one of the user's followers, who has to follow them again to see their drops
+func (s *FollowService) RemoveFollower(userID uint, followerID uint) error {
+	removed, err := s.RemoveFollowers(userID, []uint{followerID})
+	if err != nil {
+		return err
+	}
+
+	if len(removed) == 0 {
+		return errors2.NotFoundError{Entity: "Follower"}
+	}
+
+	return nil
+}
+
+// RemoveFollowers removes the given followers of the user, those not following them are ignored
+func (s *FollowService) RemoveFollowers(userID uint, followerIDs []uint) ([]model.FollowModel, error) {
+	if len(followerIDs) == 0 {
+		return nil, nil
+	}
+
+	removed, err := s.Repo.FollowRepository.RemoveFollowers(userID, followerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(removed) > 0 {
+		log.Printf("Info: User %d removed %d followers\n", userID, len(removed))
+	}
+
+	return removed, nil
+}
+
+func (s *FollowService) notify(follow model.FollowModel, transition model.FollowRequestTransition) {
+	log.Printf("Info: Follow request %d of user %d to user %d %s\n", follow.GetID(), follow.GetFollowerID(), follow.GetFollowedID(), transition)
+	if s.OnRequestTransition != nil {
+		s.OnRequestTransition(follow, transition)
+	}
+}
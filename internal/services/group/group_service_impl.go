@@ -3,6 +3,8 @@ package group
 import (
 	"errors"
 	"go-api/internal/repositories"
+	"go-api/internal/services/block"
+	"go-api/internal/services/visibility"
 	"go-api/internal/storage/postgres"
 	"go-api/pkg/errors2"
 	"go-api/pkg/file"
@@ -195,7 +197,19 @@ func (s *GroupService) GetGroupDrops(groupId uint, requesterID uint) ([]model.Dr
 		drops = append(drops, gd.GetDrop())
 	}
 
-	return drops, nil
+	vs := &visibility.VisibilityService{Repo: s.Repo}
+	drops, err = vs.FilterDrops(requesterID, drops)
+	if err != nil {
+		return nil, err
+	}
+
+	bs := &block.BlockService{Repo: s.Repo}
+	hiddenUserIds, err := bs.GetHiddenUserIds(requesterID)
+	if err != nil {
+		return nil, err
+	}
+
+	return block.HideDrops(drops, hiddenUserIds), nil
 }
 
 func (s *GroupService) DeleteGroup(groupId uint, userId uint) error {
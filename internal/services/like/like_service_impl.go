@@ -3,6 +3,7 @@ package like
 import (
 	"errors"
 	"go-api/internal/repositories"
+	"go-api/internal/services/visibility"
 	"go-api/pkg/model"
 )
 
@@ -35,6 +36,16 @@ func (s *LikeService) CanLikeDrop(userID uint, args model.LikeParam) (bool, erro
 		return false, errors.New("drop not found")
 	}
 
+	drop, err := s.Repo.DropRepository.GetDropById(args.DropId)
+	if err != nil {
+		return false, err
+	}
+
+	vs := &visibility.VisibilityService{Repo: s.Repo}
+	if err := vs.CheckDrop(userID, drop); err != nil {
+		return false, err
+	}
+
 	canLike, err := s.Repo.LikeRepository.LikeExists(args.DropId, userID)
 	if err != nil {
 		return false, err
@@ -0,0 +1,74 @@
+package login_throttle
+
+import (
+	"go-api/pkg/model"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	ScopeEmail   = "email"
+	ScopeEmailIP = "email-ip"
+)
+
+// EmailPolicy limits the emails sent from the unauthenticated routes to Limit per Window, a Limit of 0 sends them all.
+// The sent emails are counted as failures in the store, the window starting with the first one.
+type EmailPolicy struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Allow returns the state with one more email sent, or false when the limit is reached
+func (p EmailPolicy) Allow(state model.LoginAttemptState, now time.Time) (model.LoginAttemptState, bool) {
+	if p.Limit <= 0 {
+		return state, true
+	}
+
+	if state.LastFailureAt.IsZero() || now.Sub(state.LastFailureAt) >= p.Window {
+		state.Failures = 0
+		state.LastFailureAt = now
+	}
+	if state.Failures >= p.Limit {
+		return state, false
+	}
+
+	state.Failures++
+	return state, true
+}
+
+// NewEmailPoliciesFromEnv reads EMAIL_RATE_PER_ADDRESS and EMAIL_RATE_PER_IP, the number of emails sent per hour
+func NewEmailPoliciesFromEnv() (EmailPolicy, EmailPolicy) {
+	return EmailPolicy{Limit: envInt("EMAIL_RATE_PER_ADDRESS", 3), Window: time.Hour},
+		EmailPolicy{Limit: envInt("EMAIL_RATE_PER_IP", 20), Window: time.Hour}
+}
+
+// AllowEmail counts an email of the given kind to the address, requested from the IP address,
+// and returns false once the address or the IP address reached its limit.
+// Unknown addresses are counted too, so that the answer does not tell which ones have an account.
+func (t *LoginThrottle) AllowEmail(kind string, email string, ip string, now time.Time) (bool, error) {
+	keys := map[string]EmailPolicy{
+		ScopeEmail + ":" + kind + ":" + strings.ToLower(strings.TrimSpace(email)): t.EmailPolicy,
+	}
+	if ip != "" {
+		keys[ScopeEmailIP+":"+ip] = t.EmailIPPolicy
+	}
+
+	allowed := true
+	for key, policy := range keys {
+		_, err := t.Store.UpdateState(key, func(state model.LoginAttemptState) model.LoginAttemptState {
+			var keyAllowed bool
+			state, keyAllowed = policy.Allow(state, now)
+			allowed = allowed && keyAllowed
+			return state
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if !allowed {
+		log.Printf("Info: Too many %s emails requested for %s from %s\n", kind, email, ip)
+	}
+	return allowed, nil
+}
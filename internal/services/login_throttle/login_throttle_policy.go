@@ -0,0 +1,92 @@
+package login_throttle
+
+import (
+	"go-api/pkg/model"
+	"time"
+)
+
+// Policy decides how long a key is blocked after its failed logins:
+// the first FreeAttempts failures are free, the next ones wait BaseDelay doubled on every failure up to MaxDelay,
+// and LockoutThreshold failures lock the key out for LockoutDuration, doubled on every lockout up to MaxLockoutDuration.
+type Policy struct {
+	FreeAttempts       int
+	BaseDelay          time.Duration
+	MaxDelay           time.Duration
+	LockoutThreshold   int
+	LockoutDuration    time.Duration
+	MaxLockoutDuration time.Duration
+	// Window forgets the failures once there has been none for that long
+	Window time.Duration
+}
+
+// pendingTimeout forgets the reservations which were never settled, e.g. when the API stopped while checking a password
+const pendingTimeout = 30 * time.Second
+
+// Reserve returns the state with one more pending attempt, or how long to wait before trying again.
+// The free attempts may run concurrently, the next ones run one at a time so that parallel requests cannot skip the backoff.
+func (p Policy) Reserve(state model.LoginAttemptState, now time.Time) (model.LoginAttemptState, time.Duration) {
+	if wait := state.BlockedUntil.Sub(now); wait > 0 {
+		return state, wait
+	}
+	if state.Pending > 0 && now.Sub(state.PendingSince) > pendingTimeout {
+		state.Pending = 0
+	}
+
+	failures := state.Failures
+	if !state.LastFailureAt.IsZero() && now.Sub(state.LastFailureAt) > p.Window {
+		failures = 0
+	}
+	if state.Pending > 0 && failures+state.Pending >= p.FreeAttempts {
+		return state, time.Second
+	}
+
+	state.Pending++
+	state.PendingSince = now
+	return state, 0
+}
+
+// Settle returns the state once a pending attempt is over, and whether its failure locks the key out
+func (p Policy) Settle(state model.LoginAttemptState, failed bool, now time.Time) (model.LoginAttemptState, bool) {
+	if state.Pending > 0 {
+		state.Pending--
+	}
+	if !failed {
+		return state, false
+	}
+	return p.RegisterFailure(state, now)
+}
+
+// RegisterFailure returns the state after one more failure, and whether it locks the key out
+func (p Policy) RegisterFailure(state model.LoginAttemptState, now time.Time) (model.LoginAttemptState, bool) {
+	if !state.LastFailureAt.IsZero() && now.Sub(state.LastFailureAt) > p.Window {
+		state.Failures = 0
+	}
+
+	state.Failures++
+	state.LastFailureAt = now
+
+	if p.LockoutThreshold > 0 && state.Failures >= p.LockoutThreshold {
+		state.Lockouts++
+		state.BlockedUntil = now.Add(doubled(p.LockoutDuration, state.Lockouts-1, p.MaxLockoutDuration))
+		state.Failures = 0
+		return state, true
+	}
+
+	if state.Failures > p.FreeAttempts {
+		state.BlockedUntil = now.Add(doubled(p.BaseDelay, state.Failures-p.FreeAttempts-1, p.MaxDelay))
+	}
+
+	return state, false
+}
+
+// doubled returns base doubled times times, capped to max
+func doubled(base time.Duration, times int, max time.Duration) time.Duration {
+	delay := base
+	for i := 0; i < times && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
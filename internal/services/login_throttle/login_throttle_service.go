@@ -0,0 +1,230 @@
+package login_throttle
+
+import (
+	"go-api/internal/storage/postgres"
+	"go-api/pkg/errors2"
+	"go-api/pkg/model"
+	"go-api/pkg/pagination"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ScopeAccount = "account"
+	ScopeIP      = "ip"
+
+	defaultLockoutsLimit = 50
+	maxLockoutsLimit     = 200
+)
+
+// LoginThrottle tracks the failed logins of every account and IP address, and blocks them for a while when there are too many
+type LoginThrottle struct {
+	Store         model.LoginAttemptRepository
+	AccountPolicy Policy
+	IPPolicy      Policy
+	// EmailPolicy and EmailIPPolicy limit the verification and password reset emails, see AllowEmail
+	EmailPolicy   EmailPolicy
+	EmailIPPolicy EmailPolicy
+}
+
+// NewLoginThrottleFromEnv uses LOGIN_THROTTLE_STORE, "postgres" to share the attempts between the API replicas
+// and "memory" (the default) when a single API instance is running.
+func NewLoginThrottleFromEnv() *LoginThrottle {
+	var store model.LoginAttemptRepository
+	switch os.Getenv("LOGIN_THROTTLE_STORE") {
+	case "postgres":
+		store = postgres.NewLoginAttemptRepo(postgres.Connect())
+	case "", "memory":
+		store = NewMemoryStore()
+	default:
+		log.Printf("Error: Unknown LOGIN_THROTTLE_STORE %s, falling back to memory\n", os.Getenv("LOGIN_THROTTLE_STORE"))
+		store = NewMemoryStore()
+	}
+
+	accountPolicy, ipPolicy := NewPoliciesFromEnv()
+	emailPolicy, emailIPPolicy := NewEmailPoliciesFromEnv()
+	return &LoginThrottle{
+		Store:         store,
+		AccountPolicy: accountPolicy,
+		IPPolicy:      ipPolicy,
+		EmailPolicy:   emailPolicy,
+		EmailIPPolicy: emailIPPolicy,
+	}
+}
+
+// NewPoliciesFromEnv reads LOGIN_FREE_ATTEMPTS, LOGIN_IP_FREE_ATTEMPTS, LOGIN_BACKOFF_BASE_SECONDS, LOGIN_BACKOFF_MAX_SECONDS,
+// LOGIN_ACCOUNT_LOCKOUT_THRESHOLD, LOGIN_IP_LOCKOUT_THRESHOLD and LOGIN_LOCKOUT_MINUTES.
+// IP addresses get more room than accounts as many users can share one.
+func NewPoliciesFromEnv() (Policy, Policy) {
+	accountPolicy := Policy{
+		FreeAttempts:       envInt("LOGIN_FREE_ATTEMPTS", 3),
+		BaseDelay:          time.Duration(envInt("LOGIN_BACKOFF_BASE_SECONDS", 1)) * time.Second,
+		MaxDelay:           time.Duration(envInt("LOGIN_BACKOFF_MAX_SECONDS", 60)) * time.Second,
+		LockoutThreshold:   envInt("LOGIN_ACCOUNT_LOCKOUT_THRESHOLD", 10),
+		LockoutDuration:    time.Duration(envInt("LOGIN_LOCKOUT_MINUTES", 15)) * time.Minute,
+		MaxLockoutDuration: 24 * time.Hour,
+		Window:             time.Hour,
+	}
+
+	ipPolicy := accountPolicy
+	ipPolicy.FreeAttempts = envInt("LOGIN_IP_FREE_ATTEMPTS", 20)
+	ipPolicy.LockoutThreshold = envInt("LOGIN_IP_LOCKOUT_THRESHOLD", 50)
+
+	return accountPolicy, ipPolicy
+}
+
+func envInt(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		log.Printf("Error: Invalid %s %s, falling back to %d\n", name, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// Attempt is a login attempt reserved against the account and the IP address, it is settled once the credentials are checked.
+// A nil Attempt, as reserved without throttle, settles to nothing.
+type Attempt struct {
+	throttle *LoginThrottle
+	keys     []string
+}
+
+// Reserve registers a pending attempt, or returns an errors2.TooManyAttemptsError while the account or the IP address is blocked.
+// Reserving before checking the credentials makes concurrent attempts count, they could all pass a plain check otherwise.
+func (t *LoginThrottle) Reserve(email string, ip string, now time.Time) (*Attempt, error) {
+	attempt := &Attempt{throttle: t}
+	for _, key := range t.keys(email, ip) {
+		_, policy := t.policy(key)
+
+		var retryAfter time.Duration
+		_, err := t.Store.UpdateState(key, func(state model.LoginAttemptState) model.LoginAttemptState {
+			state, retryAfter = policy.Reserve(state, now)
+			return state
+		})
+		if err == nil && retryAfter > 0 {
+			err = errors2.TooManyAttemptsError{RetryAfter: retryAfter}
+		}
+		if err != nil {
+			if releaseErr := attempt.Release(now); releaseErr != nil {
+				log.Printf("Error: Error releasing login attempt: %v\n", releaseErr)
+			}
+			return nil, err
+		}
+
+		attempt.keys = append(attempt.keys, key)
+	}
+
+	return attempt, nil
+}
+
+// Fail counts the attempt as a failed login against the account and the IP address
+func (a *Attempt) Fail(now time.Time) error {
+	if a == nil {
+		return nil
+	}
+
+	for _, key := range a.keys {
+		scope, policy := a.throttle.policy(key)
+
+		lockedOut := false
+		state, err := a.throttle.Store.UpdateState(key, func(state model.LoginAttemptState) model.LoginAttemptState {
+			state, lockedOut = policy.Settle(state, true, now)
+			return state
+		})
+		if err != nil {
+			return err
+		}
+
+		if lockedOut {
+			log.Printf("Info: Login locked out for %s until %s\n", key, state.BlockedUntil.Format(time.RFC3339))
+			if _, err := a.throttle.Store.CreateLockout(key, scope, policy.LockoutThreshold, state.BlockedUntil); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Release ends the attempt without counting it, when the credentials are right but the login is not over yet
+func (a *Attempt) Release(now time.Time) error {
+	if a == nil {
+		return nil
+	}
+
+	for _, key := range a.keys {
+		_, policy := a.throttle.policy(key)
+		_, err := a.throttle.Store.UpdateState(key, func(state model.LoginAttemptState) model.LoginAttemptState {
+			state, _ = policy.Settle(state, false, now)
+			return state
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Succeed ends the attempt, forgets the failures of the account and returns how many lockouts it had since its last successful login.
+// The IP address keeps its failures, one valid account must not clear the attempts made against the others.
+func (a *Attempt) Succeed(now time.Time) (int, error) {
+	if a == nil {
+		return 0, nil
+	}
+
+	if err := a.Release(now); err != nil {
+		return 0, err
+	}
+
+	key := a.keys[0]
+	state, err := a.throttle.Store.GetState(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if state.Failures == 0 && state.Lockouts == 0 {
+		return 0, nil
+	}
+
+	lockouts := 0
+	_, err = a.throttle.Store.UpdateState(key, func(state model.LoginAttemptState) model.LoginAttemptState {
+		lockouts = state.Lockouts
+		// Concurrent attempts of the account keep their reservations
+		return model.LoginAttemptState{Pending: state.Pending, PendingSince: state.PendingSince}
+	})
+	return lockouts, err
+}
+
+// GetLockouts returns the latest lockouts first, active or not
+func (t *LoginThrottle) GetLockouts(limit int) ([]model.LoginLockoutModel, error) {
+	return t.Store.GetLockouts(pagination.ClampLimit(limit, defaultLockoutsLimit, maxLockoutsLimit))
+}
+
+func (t *LoginThrottle) policy(key string) (string, Policy) {
+	if strings.HasPrefix(key, ScopeIP+":") {
+		return ScopeIP, t.IPPolicy
+	}
+	return ScopeAccount, t.AccountPolicy
+}
+
+// keys returns the account key first
+func (t *LoginThrottle) keys(email string, ip string) []string {
+	keys := []string{accountKey(email)}
+	if ip != "" {
+		keys = append(keys, ScopeIP+":"+ip)
+	}
+	return keys
+}
+
+func accountKey(email string) string {
+	return ScopeAccount + ":" + strings.ToLower(strings.TrimSpace(email))
+}
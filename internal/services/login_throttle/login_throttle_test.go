@@ -0,0 +1,171 @@
+package login_throttle
+
+import (
+	"errors"
+	"go-api/pkg/errors2"
+	"go-api/pkg/model"
+	"testing"
+	"time"
+)
+
+var testPolicy = Policy{
+	FreeAttempts:       2,
+	BaseDelay:          time.Second,
+	MaxDelay:           4 * time.Second,
+	LockoutThreshold:   6,
+	LockoutDuration:    time.Minute,
+	MaxLockoutDuration: 3 * time.Minute,
+	Window:             time.Hour,
+}
+
+func TestPolicyRegisterFailure(t *testing.T) {
+	now := time.Now()
+	var state model.LoginAttemptState
+	var lockedOut bool
+
+	wantDelays := []time.Duration{0, 0, time.Second, 2 * time.Second, 4 * time.Second}
+	for i, want := range wantDelays {
+		state, lockedOut = testPolicy.RegisterFailure(state, now)
+		if lockedOut {
+			t.Fatalf("failure %d: unexpected lockout", i+1)
+		}
+		if got := state.BlockedUntil.Sub(now); want > 0 && got != want {
+			t.Errorf("failure %d: blocked for %v, want %v", i+1, got, want)
+		}
+	}
+
+	state, lockedOut = testPolicy.RegisterFailure(state, now)
+	if !lockedOut || state.BlockedUntil.Sub(now) != time.Minute || state.Lockouts != 1 || state.Failures != 0 {
+		t.Errorf("got %+v (locked out %v), want a first lockout of a minute", state, lockedOut)
+	}
+
+	for i := 0; i < testPolicy.LockoutThreshold; i++ {
+		state, lockedOut = testPolicy.RegisterFailure(state, now)
+	}
+	if !lockedOut || state.BlockedUntil.Sub(now) != 2*time.Minute || state.Lockouts != 2 {
+		t.Errorf("got %+v (locked out %v), want a second lockout of two minutes", state, lockedOut)
+	}
+}
+
+func TestPolicyForgetsOldFailures(t *testing.T) {
+	now := time.Now()
+	state := model.LoginAttemptState{Failures: 5, LastFailureAt: now.Add(-2 * time.Hour)}
+
+	state, lockedOut := testPolicy.RegisterFailure(state, now)
+	if lockedOut || state.Failures != 1 {
+		t.Errorf("got %+v, want the old failures forgotten", state)
+	}
+}
+
+func TestLoginThrottle(t *testing.T) {
+	throttle := &LoginThrottle{Store: NewMemoryStore(), AccountPolicy: testPolicy, IPPolicy: testPolicy}
+	now := time.Now()
+
+	for i := 0; i < testPolicy.LockoutThreshold; i++ {
+		// Skips the backoff delays
+		now = now.Add(testPolicy.MaxDelay)
+		attempt, err := throttle.Reserve("User@Droppy.app", "10.0.0.1", now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := attempt.Fail(now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var tooManyErr errors2.TooManyAttemptsError
+	if _, err := throttle.Reserve("user@droppy.app", "10.0.0.2", now); !errors.As(err, &tooManyErr) || tooManyErr.RetryAfter != time.Minute {
+		t.Errorf("locked out account: got %v, want to retry in a minute", err)
+	}
+
+	if _, err := throttle.Reserve("other@droppy.app", "10.0.0.1", now); !errors.As(err, &tooManyErr) {
+		t.Errorf("locked out IP address: got %v, want an error", err)
+	}
+
+	lockouts, err := throttle.GetLockouts(10)
+	if err != nil || len(lockouts) != 2 {
+		t.Errorf("got %d lockouts (%v), want the account and the IP address", len(lockouts), err)
+	}
+
+	now = now.Add(time.Minute)
+	attempt, err := throttle.Reserve("user@droppy.app", "10.0.0.2", now)
+	if err != nil {
+		t.Fatalf("after the lockout: got %v, want no error", err)
+	}
+	if count, err := attempt.Succeed(now); err != nil || count != 1 {
+		t.Errorf("got %d lockouts since the last login (%v), want 1", count, err)
+	}
+
+	state, _ := throttle.Store.GetState(accountKey("user@droppy.app"))
+	if state.Failures != 0 || state.Lockouts != 0 || state.Pending != 0 {
+		t.Errorf("got %+v after a successful login, want an empty state", state)
+	}
+}
+
+func TestLoginThrottleConcurrentAttempts(t *testing.T) {
+	throttle := &LoginThrottle{Store: NewMemoryStore(), AccountPolicy: testPolicy, IPPolicy: testPolicy}
+	now := time.Now()
+
+	// Attempts whose passwords are still being checked
+	var attempts []*Attempt
+	for i := 0; i < testPolicy.FreeAttempts; i++ {
+		attempt, err := throttle.Reserve("user@droppy.app", "10.0.0.1", now)
+		if err != nil {
+			t.Fatalf("free attempt %d: got %v, want no error", i+1, err)
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	var tooManyErr errors2.TooManyAttemptsError
+	if _, err := throttle.Reserve("user@droppy.app", "10.0.0.2", now); !errors.As(err, &tooManyErr) {
+		t.Errorf("attempt beyond the free ones while others are pending: got %v, want an error", err)
+	}
+
+	ipState, _ := throttle.Store.GetState(ScopeIP + ":10.0.0.2")
+	if ipState.Pending != 0 {
+		t.Errorf("a rejected attempt must release its reservations, got %+v", ipState)
+	}
+
+	for _, attempt := range attempts {
+		if err := attempt.Fail(now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := throttle.Reserve("user@droppy.app", "10.0.0.2", now); err != nil {
+		t.Errorf("next attempt once the others failed: got %v, want no error", err)
+	}
+	if _, err := throttle.Reserve("user@droppy.app", "10.0.0.3", now); !errors.As(err, &tooManyErr) {
+		t.Errorf("attempts beyond the free ones run one at a time: got %v, want an error", err)
+	}
+}
+
+func TestLoginThrottleAllowEmail(t *testing.T) {
+	throttle := &LoginThrottle{
+		Store:         NewMemoryStore(),
+		EmailPolicy:   EmailPolicy{Limit: 2, Window: time.Hour},
+		EmailIPPolicy: EmailPolicy{Limit: 3, Window: time.Hour},
+	}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if allowed, err := throttle.AllowEmail("password-reset", "User@Droppy.app", "10.0.0.1", now); err != nil || !allowed {
+			t.Fatalf("email %d: got %t (%v), want it sent", i+1, allowed, err)
+		}
+	}
+	if allowed, _ := throttle.AllowEmail("password-reset", "user@droppy.app", "10.0.0.2", now); allowed {
+		t.Errorf("third email to the same address sent, want it throttled")
+	}
+	if allowed, _ := throttle.AllowEmail("verification", "user@droppy.app", "10.0.0.2", now); !allowed {
+		t.Errorf("verification email throttled by the password reset ones")
+	}
+	if allowed, _ := throttle.AllowEmail("password-reset", "other@droppy.app", "10.0.0.1", now); !allowed {
+		t.Errorf("email to another address throttled before the IP address limit")
+	}
+	if allowed, _ := throttle.AllowEmail("password-reset", "third@droppy.app", "10.0.0.1", now); allowed {
+		t.Errorf("fourth email from the same IP address sent, want it throttled")
+	}
+	if allowed, _ := throttle.AllowEmail("password-reset", "user@droppy.app", "10.0.0.3", now.Add(time.Hour)); !allowed {
+		t.Errorf("email throttled once the window is over")
+	}
+}
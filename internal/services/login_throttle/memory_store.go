@@ -0,0 +1,115 @@
+package login_throttle
+
+import (
+	"go-api/pkg/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	memoryStoreMaxKeys     = 100000
+	memoryStoreMaxLockouts = 1000
+)
+
+type memoryLockout struct {
+	id          uint
+	key         string
+	scope       string
+	failures    int
+	lockedUntil time.Time
+	createdAt   time.Time
+}
+
+func (l *memoryLockout) GetID() uint         { return l.id }
+func (l *memoryLockout) GetKey() string      { return l.key }
+func (l *memoryLockout) GetScope() string    { return l.scope }
+func (l *memoryLockout) GetFailures() int    { return l.failures }
+func (l *memoryLockout) GetLockedUntil() int { return int(l.lockedUntil.Unix()) }
+func (l *memoryLockout) GetCreatedAt() int   { return int(l.createdAt.Unix()) }
+
+// MemoryStore keeps the attempts inside the process, when a single API instance is running
+type MemoryStore struct {
+	mu       sync.Mutex
+	states   map[string]model.LoginAttemptState
+	lockouts []*memoryLockout
+	lastID   uint
+}
+
+var _ model.LoginAttemptRepository = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]model.LoginAttemptState)}
+}
+
+func (s *MemoryStore) GetState(key string) (model.LoginAttemptState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.states[key], nil
+}
+
+func (s *MemoryStore) UpdateState(key string, update func(state model.LoginAttemptState) model.LoginAttemptState) (model.LoginAttemptState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.states[key]; !exists && len(s.states) >= memoryStoreMaxKeys {
+		s.prune(time.Now())
+	}
+
+	state := update(s.states[key])
+	s.states[key] = state
+	return state, nil
+}
+
+func (s *MemoryStore) DeleteState(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, key)
+	return nil
+}
+
+func (s *MemoryStore) CreateLockout(key string, scope string, failures int, lockedUntil time.Time) (model.LoginLockoutModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastID++
+	lockout := &memoryLockout{id: s.lastID, key: key, scope: scope, failures: failures, lockedUntil: lockedUntil, createdAt: time.Now()}
+	s.lockouts = append(s.lockouts, lockout)
+	if len(s.lockouts) > memoryStoreMaxLockouts {
+		s.lockouts = s.lockouts[len(s.lockouts)-memoryStoreMaxLockouts:]
+	}
+	return lockout, nil
+}
+
+func (s *MemoryStore) GetLockouts(limit int) ([]model.LoginLockoutModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lockouts := make([]model.LoginLockoutModel, 0, limit)
+	for i := len(s.lockouts) - 1; i >= 0 && len(lockouts) < limit; i-- {
+		lockouts = append(lockouts, s.lockouts[i])
+	}
+	return lockouts, nil
+}
+
+// prune forgets the keys which are not blocked anymore, the oldest failures first, until a tenth of the room is free
+func (s *MemoryStore) prune(now time.Time) {
+	type entry struct {
+		key           string
+		lastFailureAt time.Time
+	}
+	var prunable []entry
+	for key, state := range s.states {
+		if state.BlockedUntil.Before(now) && state.Lockouts == 0 && state.Pending == 0 {
+			prunable = append(prunable, entry{key, state.LastFailureAt})
+		}
+	}
+	sort.Slice(prunable, func(i, j int) bool { return prunable[i].lastFailureAt.Before(prunable[j].lastFailureAt) })
+
+	for _, e := range prunable {
+		if len(s.states) < memoryStoreMaxKeys*9/10 {
+			return
+		}
+		delete(s.states, e.key)
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"go-api/internal/storage/firebase"
 	"go-api/internal/storage/postgres"
 	"log"
-	"math/rand"
 	"time"
 )
 
@@ -90,30 +89,6 @@ func (s *PushNotificationService) SendNotificationsToAllUser(dropType string) {
 	s.SendDropNotification(validTokens, dropType)
 }
 
-func (s *PushNotificationService) GenerateRandomNotification(dropType string) {
-	startHour := 8
-	endHour := 21
-
-	// Generate a random hour and minute within the specified range
-	randomHour := rand.Intn(endHour-startHour) + startHour
-	randomMinute := rand.Intn(60)
-
-	// Calculate the duration until the random time
-	now := time.Now()
-	randomTime := time.Date(now.Year(), now.Month(), now.Day(), randomHour, randomMinute, 0, 0, now.Location())
-	if randomTime.Before(now) {
-		randomTime = randomTime.Add(24 * time.Hour) // Schedule for the next day if the time has already passed
-	}
-
-	duration := randomTime.Sub(now)
-	log.Printf("Info: Notification scheduled for: %v\n", randomTime)
-
-	// Sleep until the random time and then send the notification
-	time.Sleep(duration)
-
-	s.SendNotificationsToAllUser(dropType)
-}
-
 func (s *PushNotificationService) SendNotification(notifType string, fcmTokens []string) error {
 	if len(fcmTokens) == 0 {
 		log.Println("Error: No FCM tokens to send notifications to")
@@ -3,6 +3,7 @@ package report
 import (
 	"errors"
 	"go-api/internal/repositories"
+	"go-api/internal/services/visibility"
 	"go-api/pkg/errors2"
 	"go-api/pkg/model"
 )
@@ -28,10 +29,16 @@ func (s *ReportService) CreateReport(userId uint, args model.ReportCreationParam
 }
 
 func (s *ReportService) ReportDrop(userId uint, dropId uint, description string) (model.ReportModel, error) {
-	if _, err := s.Repo.DropRepository.GetDropById(dropId); err != nil {
+	drop, err := s.Repo.DropRepository.GetDropById(dropId)
+	if err != nil {
 		return nil, errors2.NotFoundError{Entity: "Drop"}
 	}
 
+	vs := &visibility.VisibilityService{Repo: s.Repo}
+	if err := vs.CheckDrop(userId, drop); err != nil {
+		return nil, err
+	}
+
 	if _, err := s.Repo.ReportRepository.GetActiveReportByDropAndUser(dropId, userId); err == nil {
 		return nil, errors2.AlreadyReportedError{Entity: "Drop"}
 	}
@@ -45,10 +52,16 @@ func (s *ReportService) ReportDrop(userId uint, dropId uint, description string)
 }
 
 func (s *ReportService) ReportComment(userId uint, commentId uint, description string) (model.ReportModel, error) {
-	if _, err := s.Repo.CommentRepository.GetById(commentId); err != nil {
+	comment, err := s.Repo.CommentRepository.GetById(commentId)
+	if err != nil {
 		return nil, errors2.NotFoundError{Entity: "Comment"}
 	}
 
+	vs := &visibility.VisibilityService{Repo: s.Repo}
+	if err := vs.CheckComment(userId, comment); err != nil {
+		return nil, err
+	}
+
 	if _, err := s.Repo.ReportRepository.GetActiveReportByCommentAndUser(commentId, userId); err == nil {
 		return nil, errors2.AlreadyReportedError{Entity: "Comment"}
 	}
@@ -62,10 +75,16 @@ func (s *ReportService) ReportComment(userId uint, commentId uint, description s
 }
 
 func (s *ReportService) ReportResponse(userId uint, responseId uint, description string) (model.ReportModel, error) {
-	if _, err := s.Repo.CommentResponseRepository.GetById(responseId); err != nil {
+	response, err := s.Repo.CommentResponseRepository.GetById(responseId)
+	if err != nil {
 		return nil, errors2.NotFoundError{Entity: "Response"}
 	}
 
+	vs := &visibility.VisibilityService{Repo: s.Repo}
+	if err := vs.CheckResponse(userId, response); err != nil {
+		return nil, err
+	}
+
 	if _, err := s.Repo.ReportRepository.GetActiveReportByResponseAndUser(responseId, userId); err == nil {
 		return nil, errors2.AlreadyReportedError{Entity: "Response"}
 	}
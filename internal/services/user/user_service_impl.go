@@ -2,6 +2,7 @@ package user
 
 import (
 	"go-api/internal/repositories"
+	followservice "go-api/internal/services/follow"
 	"go-api/pkg/file"
 	"go-api/pkg/model"
 	"go-api/pkg/validation"
@@ -9,6 +10,8 @@ import (
 
 type UserService struct {
 	Repo *repositories.Repositories
+	// OnFollowRequestTransition is given the follow requests accepted when the user goes public
+	OnFollowRequestTransition func(follow model.FollowModel, transition model.FollowRequestTransition)
 }
 
 func NewUserService(repo *repositories.Repositories) *UserService {
@@ -55,5 +58,12 @@ func (s *UserService) UpdateUser(userId uint, userToPatch model.UserPatchParam)
 		return nil, err
 	}
 
+	if userToPatch.IsPrivate != nil && !*userToPatch.IsPrivate {
+		fs := &followservice.FollowService{Repo: s.Repo, OnRequestTransition: s.OnFollowRequestTransition}
+		if _, err := fs.AcceptPendingRequests(userId); err != nil {
+			return nil, err
+		}
+	}
+
 	return updatedUser, nil
 }
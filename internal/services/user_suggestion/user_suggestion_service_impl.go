@@ -0,0 +1,103 @@
+package user_suggestion
+
+import (
+	"errors"
+	"fmt"
+	"go-api/internal/repositories"
+	"go-api/pkg/model"
+	"go-api/pkg/pagination"
+)
+
+const (
+	defaultSuggestionsLimit = 20
+	maxSuggestionsLimit     = 50
+)
+
+// suggestionWeights favours people followed by friends over people sharing groups or tastes
+var suggestionWeights = model.UserSuggestionWeights{
+	MutualFollows:  3,
+	SharedGroups:   2,
+	SharedContents: 1,
+}
+
+type UserSuggestionService struct {
+	Repo *repositories.Repositories
+}
+
+func (s *UserSuggestionService) GetSuggestions(userId uint, limit int) ([]model.UserSuggestion, error) {
+	isActiveUser, err := s.Repo.UserRepository.IsActiveUser(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !isActiveUser {
+		return nil, errors.New("User is not active")
+	}
+
+	limit = pagination.ClampLimit(limit, defaultSuggestionsLimit, maxSuggestionsLimit)
+
+	signals, err := s.Repo.UserSuggestionRepository.GetSuggestionSignals(userId, suggestionWeights, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]model.UserSuggestion, 0, len(signals))
+	if len(signals) == 0 {
+		return suggestions, nil
+	}
+
+	userIds := make([]uint, 0, len(signals))
+	for _, signal := range signals {
+		userIds = append(userIds, signal.UserID)
+	}
+
+	users, err := s.Repo.UserRepository.GetUsersFromUserIds(userIds)
+
+	if err != nil {
+		return nil, err
+	}
+
+	usersById := make(map[uint]model.UserModel, len(users))
+	for _, user := range users {
+		usersById[user.GetID()] = user
+	}
+
+	// Keeps the ranking of the repository
+	for _, signal := range signals {
+		user, ok := usersById[signal.UserID]
+		if !ok {
+			continue
+		}
+		suggestions = append(suggestions, model.UserSuggestion{
+			User:    user,
+			Signals: signal,
+			Reasons: SuggestionReasons(signal),
+		})
+	}
+
+	return suggestions, nil
+}
+
+// SuggestionReasons explains a suggestion, strongest signal first
+func SuggestionReasons(signals model.UserSuggestionSignals) []string {
+	reasons := make([]string, 0, 3)
+	if signals.MutualFollows > 0 {
+		reasons = append(reasons, pluralize(signals.MutualFollows, "mutual friend", "mutual friends"))
+	}
+	if signals.SharedGroups > 0 {
+		reasons = append(reasons, pluralize(signals.SharedGroups, "group in common", "groups in common"))
+	}
+	if signals.SharedContents > 0 {
+		reasons = append(reasons, pluralize(signals.SharedContents, "drop in common", "drops in common"))
+	}
+	return reasons
+}
+
+func pluralize(count int, singular string, plural string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, singular)
+	}
+	return fmt.Sprintf("%d %s", count, plural)
+}
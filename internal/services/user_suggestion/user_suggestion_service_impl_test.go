@@ -0,0 +1,39 @@
+package user_suggestion
+
+import (
+	"go-api/pkg/model"
+	"reflect"
+	"testing"
+)
+
+func TestSuggestionReasons(t *testing.T) {
+	tests := []struct {
+		name    string
+		signals model.UserSuggestionSignals
+		want    []string
+	}{
+		{
+			name:    "no signal",
+			signals: model.UserSuggestionSignals{},
+			want:    []string{},
+		},
+		{
+			name:    "singular",
+			signals: model.UserSuggestionSignals{MutualFollows: 1, SharedGroups: 1, SharedContents: 1},
+			want:    []string{"1 mutual friend", "1 group in common", "1 drop in common"},
+		},
+		{
+			name:    "plural and missing signal",
+			signals: model.UserSuggestionSignals{MutualFollows: 3, SharedContents: 4},
+			want:    []string{"3 mutual friends", "4 drops in common"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SuggestionReasons(tt.signals); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SuggestionReasons() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package visibility
+
+import (
+	"go-api/internal/storage/postgres"
+	"go-api/pkg/errors2"
+)
+
+const activeUserStatus = 1
+
+// DropFacts is everything the policy needs to know about a drop and the user trying to see it
+type DropFacts struct {
+	ViewerID        uint
+	AuthorID        uint
+	AuthorStatus    int
+	AuthorIsPrivate bool
+	DropStatus      uint
+	// Audience is one of the postgres.DropAudiences, empty for drops created before audiences existed
+	Audience string
+	// IsBlocked tells whether the viewer or the author blocked the other
+	IsBlocked           bool
+	ViewerFollowsAuthor bool
+	ViewerIsCloseFriend bool
+	// ViewerSharesDropGroup tells whether the viewer is an active member of one of the groups the drop is shared with
+	ViewerSharesDropGroup bool
+}
+
+// CheckDropVisibility returns nil when the viewer can see the drop, and interact with it.
+// Drops the viewer must not know about give a NotFoundError, drops of private users a NotAllowedError.
+func CheckDropVisibility(facts DropFacts) error {
+	if facts.ViewerID != 0 && facts.ViewerID == facts.AuthorID {
+		return nil
+	}
+
+	notFound := errors2.NotFoundError{Entity: "Drop"}
+
+	if facts.DropStatus != new(postgres.DropStatusActive).ToInt() || facts.AuthorStatus != activeUserStatus || facts.IsBlocked {
+		return notFound
+	}
+
+	canSeePrivateDrops := !facts.AuthorIsPrivate || facts.ViewerFollowsAuthor
+
+	switch facts.Audience {
+	case new(postgres.DropAudienceCloseFriends).ToString():
+		if !facts.ViewerIsCloseFriend || !canSeePrivateDrops {
+			return notFound
+		}
+	case new(postgres.DropAudienceGroups).ToString():
+		if !facts.ViewerSharesDropGroup {
+			return notFound
+		}
+	default:
+		// Group members see the drops shared with their groups even when they do not follow the author
+		if !canSeePrivateDrops && !facts.ViewerSharesDropGroup {
+			return errors2.NotAllowedError{Reason: "This user is private"}
+		}
+	}
+
+	return nil
+}
+
+// AuthorFacts is what the policy needs to know about the author of a comment or a response
+type AuthorFacts struct {
+	ViewerID     uint
+	AuthorID     uint
+	AuthorStatus int
+	IsBlocked    bool
+}
+
+// CheckCommentVisibility returns nil when the viewer can see a comment or a response, once the drop is known to be visible
+func CheckCommentVisibility(facts AuthorFacts, entity string) error {
+	if facts.ViewerID != 0 && facts.ViewerID == facts.AuthorID {
+		return nil
+	}
+
+	if facts.AuthorStatus != activeUserStatus || facts.IsBlocked {
+		return errors2.NotFoundError{Entity: entity}
+	}
+
+	return nil
+}
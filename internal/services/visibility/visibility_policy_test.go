@@ -0,0 +1,135 @@
+package visibility
+
+import (
+	"errors"
+	"go-api/pkg/errors2"
+	"testing"
+)
+
+type expectedVisibility int
+
+const (
+	visible expectedVisibility = iota
+	notFound
+	notAllowed
+)
+
+func assertVisibility(t *testing.T, err error, want expectedVisibility) {
+	t.Helper()
+
+	var notFoundErr errors2.NotFoundError
+	var notAllowedErr errors2.NotAllowedError
+
+	switch want {
+	case visible:
+		if err != nil {
+			t.Errorf("got %v, want visible", err)
+		}
+	case notFound:
+		if !errors.As(err, &notFoundErr) {
+			t.Errorf("got %v, want a NotFoundError", err)
+		}
+	case notAllowed:
+		if !errors.As(err, &notAllowedErr) {
+			t.Errorf("got %v, want a NotAllowedError", err)
+		}
+	}
+}
+
+func TestCheckDropVisibility(t *testing.T) {
+	publicDrop := DropFacts{
+		ViewerID:     1,
+		AuthorID:     2,
+		AuthorStatus: 1,
+		DropStatus:   1,
+		Audience:     "followers",
+	}
+
+	tests := []struct {
+		name   string
+		update func(facts *DropFacts)
+		want   expectedVisibility
+	}{
+		{name: "public drop", update: func(facts *DropFacts) {}, want: visible},
+		{name: "drop created before audiences", update: func(facts *DropFacts) { facts.Audience = "" }, want: visible},
+		{name: "anonymous viewer of a public drop", update: func(facts *DropFacts) { facts.ViewerID = 0 }, want: visible},
+		{name: "private author followed", update: func(facts *DropFacts) {
+			facts.AuthorIsPrivate = true
+			facts.ViewerFollowsAuthor = true
+		}, want: visible},
+		{name: "private author not followed", update: func(facts *DropFacts) { facts.AuthorIsPrivate = true }, want: notAllowed},
+		{name: "private author sharing a group", update: func(facts *DropFacts) {
+			facts.AuthorIsPrivate = true
+			facts.ViewerSharesDropGroup = true
+		}, want: visible},
+		{name: "own drop of a private account", update: func(facts *DropFacts) {
+			facts.AuthorID = facts.ViewerID
+			facts.AuthorIsPrivate = true
+		}, want: visible},
+		{name: "own deleted drop", update: func(facts *DropFacts) {
+			facts.AuthorID = facts.ViewerID
+			facts.DropStatus = 0
+		}, want: visible},
+		{name: "deleted drop", update: func(facts *DropFacts) { facts.DropStatus = 0 }, want: notFound},
+		{name: "banned author", update: func(facts *DropFacts) { facts.AuthorStatus = -1 }, want: notFound},
+		{name: "unknown author", update: func(facts *DropFacts) { facts.AuthorStatus = 0 }, want: notFound},
+		{name: "blocked", update: func(facts *DropFacts) { facts.IsBlocked = true }, want: notFound},
+		{name: "blocked while following", update: func(facts *DropFacts) {
+			facts.IsBlocked = true
+			facts.ViewerFollowsAuthor = true
+		}, want: notFound},
+		{name: "close friends drop seen by a close friend", update: func(facts *DropFacts) {
+			facts.Audience = "close_friends"
+			facts.ViewerIsCloseFriend = true
+		}, want: visible},
+		{name: "close friends drop seen by a follower", update: func(facts *DropFacts) {
+			facts.Audience = "close_friends"
+			facts.ViewerFollowsAuthor = true
+		}, want: notFound},
+		{name: "close friends drop of a private author no longer followed", update: func(facts *DropFacts) {
+			facts.Audience = "close_friends"
+			facts.AuthorIsPrivate = true
+			facts.ViewerIsCloseFriend = true
+		}, want: notFound},
+		{name: "groups drop seen by a member", update: func(facts *DropFacts) {
+			facts.Audience = "groups"
+			facts.ViewerSharesDropGroup = true
+		}, want: visible},
+		{name: "groups drop seen by a follower", update: func(facts *DropFacts) {
+			facts.Audience = "groups"
+			facts.ViewerFollowsAuthor = true
+		}, want: notFound},
+		{name: "groups drop of a private author seen by a member", update: func(facts *DropFacts) {
+			facts.Audience = "groups"
+			facts.AuthorIsPrivate = true
+			facts.ViewerSharesDropGroup = true
+		}, want: visible},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			facts := publicDrop
+			tt.update(&facts)
+			assertVisibility(t, CheckDropVisibility(facts), tt.want)
+		})
+	}
+}
+
+func TestCheckCommentVisibility(t *testing.T) {
+	tests := []struct {
+		name  string
+		facts AuthorFacts
+		want  expectedVisibility
+	}{
+		{name: "active author", facts: AuthorFacts{ViewerID: 1, AuthorID: 2, AuthorStatus: 1}, want: visible},
+		{name: "own comment", facts: AuthorFacts{ViewerID: 1, AuthorID: 1, AuthorStatus: -1}, want: visible},
+		{name: "banned author", facts: AuthorFacts{ViewerID: 1, AuthorID: 2, AuthorStatus: -1}, want: notFound},
+		{name: "blocked author", facts: AuthorFacts{ViewerID: 1, AuthorID: 2, AuthorStatus: 1, IsBlocked: true}, want: notFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertVisibility(t, CheckCommentVisibility(tt.facts, "Comment"), tt.want)
+		})
+	}
+}
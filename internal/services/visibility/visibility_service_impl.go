@@ -0,0 +1,423 @@
+package visibility
+
+import (
+	"errors"
+	"go-api/internal/repositories"
+	"go-api/internal/storage/postgres"
+	"go-api/pkg/errors2"
+	"go-api/pkg/model"
+	"slices"
+)
+
+type VisibilityService struct {
+	Repo *repositories.Repositories
+}
+
+// viewer holds the relations of a user needed to check many drops with few queries
+type viewer struct {
+	id            uint
+	following     map[uint]bool
+	closeFriendOf map[uint]bool
+	groupIds      map[uint]bool
+	blocked       map[uint]bool
+	authors       map[uint]model.UserModel
+}
+
+func (s *VisibilityService) loadViewer(viewerId uint) (*viewer, error) {
+	v := &viewer{
+		id:            viewerId,
+		following:     map[uint]bool{},
+		closeFriendOf: map[uint]bool{},
+		groupIds:      map[uint]bool{},
+		blocked:       map[uint]bool{},
+		authors:       map[uint]model.UserModel{},
+	}
+
+	if viewerId == 0 {
+		return v, nil
+	}
+
+	follows, err := s.Repo.FollowRepository.GetFollowing(viewerId)
+	if err != nil {
+		return nil, err
+	}
+	for _, follow := range follows {
+		v.following[follow.GetFollowedID()] = true
+	}
+
+	authorIds, err := s.Repo.CloseFriendRepository.GetUserIdsHavingCloseFriend(viewerId)
+	if err != nil {
+		return nil, err
+	}
+	for _, authorId := range authorIds {
+		v.closeFriendOf[authorId] = true
+	}
+
+	memberships, err := s.Repo.GroupMemberRepository.GetByMemberID(viewerId)
+	if err != nil {
+		return nil, err
+	}
+	for _, membership := range memberships {
+		v.groupIds[membership.GetGroupID()] = true
+	}
+
+	blockedIds, err := s.Repo.BlockRepository.GetBlockRelatedUserIds(viewerId)
+	if err != nil {
+		return nil, err
+	}
+	for _, blockedId := range blockedIds {
+		v.blocked[blockedId] = true
+	}
+
+	return v, nil
+}
+
+// author returns the preloaded author when there is one, the stored one otherwise
+func (s *VisibilityService) author(v *viewer, authorId uint, preloaded model.UserModel) (model.UserModel, error) {
+	if nil != preloaded && preloaded.GetID() == authorId {
+		return preloaded, nil
+	}
+
+	if cached, ok := v.authors[authorId]; ok {
+		return cached, nil
+	}
+
+	author, err := s.Repo.UserRepository.GetById(authorId)
+	if err != nil {
+		return nil, err
+	}
+	v.authors[authorId] = author
+	return author, nil
+}
+
+func (s *VisibilityService) dropFacts(v *viewer, drop model.DropModel) (DropFacts, error) {
+	authorId := drop.GetCreatedById()
+	facts := DropFacts{
+		ViewerID:            v.id,
+		AuthorID:            authorId,
+		DropStatus:          drop.GetStatus(),
+		Audience:            drop.GetAudience(),
+		IsBlocked:           v.blocked[authorId],
+		ViewerFollowsAuthor: v.following[authorId],
+		ViewerIsCloseFriend: v.closeFriendOf[authorId],
+	}
+
+	author, err := s.author(v, authorId, drop.GetCreatedBy())
+	if err != nil {
+		return facts, err
+	}
+	if nil != author {
+		facts.AuthorStatus = author.GetStatus()
+		facts.AuthorIsPrivate = author.IsPrivateUser()
+	}
+
+	needsDropGroups := facts.Audience == new(postgres.DropAudienceGroups).ToString() ||
+		(facts.AuthorIsPrivate && !facts.ViewerFollowsAuthor)
+	if needsDropGroups && len(v.groupIds) > 0 && v.id != authorId {
+		dropGroupIds, err := s.Repo.GroupDropRepository.GetGroupIdsByDropId(drop.GetID())
+		if err != nil {
+			return facts, err
+		}
+		facts.ViewerSharesDropGroup = slices.ContainsFunc(dropGroupIds, func(groupId uint) bool {
+			return v.groupIds[groupId]
+		})
+	}
+
+	return facts, nil
+}
+
+// CheckDrop returns nil when the user can see the drop, a NotFoundError or a NotAllowedError otherwise
+func (s *VisibilityService) CheckDrop(viewerId uint, drop model.DropModel) error {
+	if nil == drop {
+		return errors2.NotFoundError{Entity: "Drop"}
+	}
+
+	v, err := s.loadViewer(viewerId)
+	if err != nil {
+		return err
+	}
+
+	facts, err := s.dropFacts(v, drop)
+	if err != nil {
+		return err
+	}
+
+	return CheckDropVisibility(facts)
+}
+
+// VisibleDrop returns the drop without the comments and responses the user cannot see, or an error when they cannot see the drop
+func (s *VisibilityService) VisibleDrop(viewerId uint, drop model.DropModel) (model.DropModel, error) {
+	if nil == drop {
+		return nil, errors2.NotFoundError{Entity: "Drop"}
+	}
+
+	v, err := s.loadViewer(viewerId)
+	if err != nil {
+		return nil, err
+	}
+
+	facts, err := s.dropFacts(v, drop)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := CheckDropVisibility(facts); err != nil {
+		return nil, err
+	}
+
+	return s.withoutHiddenContent(v, drop)
+}
+
+// FilterDrops keeps the drops the user can see, without the comments and responses they cannot see
+func (s *VisibilityService) FilterDrops(viewerId uint, drops []model.DropModel) ([]model.DropModel, error) {
+	if len(drops) == 0 {
+		return drops, nil
+	}
+
+	v, err := s.loadViewer(viewerId)
+	if err != nil {
+		return nil, err
+	}
+
+	visibleDrops := make([]model.DropModel, 0, len(drops))
+	for _, drop := range drops {
+		facts, err := s.dropFacts(v, drop)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := CheckDropVisibility(facts); err != nil {
+			if isHiddenError(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		drop, err = s.withoutHiddenContent(v, drop)
+		if err != nil {
+			return nil, err
+		}
+		visibleDrops = append(visibleDrops, drop)
+	}
+	return visibleDrops, nil
+}
+
+// VisibleDropByViewer returns the drop as each of the viewers can see it, without the comments and responses hidden from them.
+// Viewers who cannot see the drop or who muted its author are left out. The relations are loaded once for all the viewers.
+func (s *VisibilityService) VisibleDropByViewer(viewerIds []uint, drop model.DropModel) (map[uint]model.DropModel, error) {
+	visibleDrops := make(map[uint]model.DropModel, len(viewerIds))
+	if nil == drop || len(viewerIds) == 0 {
+		return visibleDrops, nil
+	}
+
+	authorId := drop.GetCreatedById()
+	author, err := s.author(&viewer{authors: map[uint]model.UserModel{}}, authorId, drop.GetCreatedBy())
+	if err != nil {
+		return nil, err
+	}
+
+	followers, err := s.Repo.FollowRepository.GetFollowers(authorId)
+	if err != nil {
+		return nil, err
+	}
+	followerIds := make(map[uint]bool, len(followers))
+	for _, follower := range followers {
+		followerIds[follower.GetFollowerID()] = true
+	}
+
+	closeFriendIds := map[uint]bool{}
+	if drop.GetAudience() == new(postgres.DropAudienceCloseFriends).ToString() {
+		closeFriends, err := s.Repo.CloseFriendRepository.GetCloseFriends(authorId)
+		if err != nil {
+			return nil, err
+		}
+		for _, closeFriend := range closeFriends {
+			closeFriendIds[closeFriend.GetFriendID()] = true
+		}
+	}
+
+	dropGroupMemberIds := map[uint]bool{}
+	if drop.GetAudience() == new(postgres.DropAudienceGroups).ToString() || (nil != author && author.IsPrivateUser()) {
+		dropGroupIds, err := s.Repo.GroupDropRepository.GetGroupIdsByDropId(drop.GetID())
+		if err != nil {
+			return nil, err
+		}
+		for _, groupId := range dropGroupIds {
+			members, err := s.Repo.GroupMemberRepository.GetByGroupID(groupId)
+			if err != nil {
+				return nil, err
+			}
+			for _, member := range members {
+				dropGroupMemberIds[member.GetMemberID()] = true
+			}
+		}
+	}
+
+	contentAuthorIds := []uint{authorId}
+	inactiveAuthorIds := map[uint]bool{}
+	addContentAuthor := func(contentAuthor model.UserModel) {
+		if nil == contentAuthor {
+			return
+		}
+		contentAuthorIds = append(contentAuthorIds, contentAuthor.GetID())
+		if contentAuthor.GetStatus() != activeUserStatus {
+			inactiveAuthorIds[contentAuthor.GetID()] = true
+		}
+	}
+	for _, comment := range drop.GetComments() {
+		addContentAuthor(comment.GetCreatedBy())
+		for _, response := range comment.GetResponses() {
+			addContentAuthor(response.GetCreatedBy())
+		}
+	}
+
+	hiddenUserIdsByViewer, err := s.Repo.BlockRepository.GetHiddenUserIdsAmong(viewerIds, contentAuthorIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, viewerId := range viewerIds {
+		hiddenAuthorIds := map[uint]bool{}
+		for _, hiddenUserId := range hiddenUserIdsByViewer[viewerId] {
+			hiddenAuthorIds[hiddenUserId] = true
+		}
+
+		facts := DropFacts{
+			ViewerID:   viewerId,
+			AuthorID:   authorId,
+			DropStatus: drop.GetStatus(),
+			Audience:   drop.GetAudience(),
+			// Muting the author hides the drop from the feed like a block does
+			IsBlocked:             hiddenAuthorIds[authorId],
+			ViewerFollowsAuthor:   followerIds[viewerId],
+			ViewerIsCloseFriend:   closeFriendIds[viewerId],
+			ViewerSharesDropGroup: dropGroupMemberIds[viewerId],
+		}
+		if nil != author {
+			facts.AuthorStatus = author.GetStatus()
+			facts.AuthorIsPrivate = author.IsPrivateUser()
+		}
+
+		if err := CheckDropVisibility(facts); err != nil {
+			if isHiddenError(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		for inactiveAuthorId := range inactiveAuthorIds {
+			if inactiveAuthorId != viewerId {
+				hiddenAuthorIds[inactiveAuthorId] = true
+			}
+		}
+		visibleDrops[viewerId] = drop.WithoutContentFrom(hiddenAuthorIds)
+	}
+
+	return visibleDrops, nil
+}
+
+// withoutHiddenContent removes the comments and responses of banned, deleted or blocked authors from the drop
+func (s *VisibilityService) withoutHiddenContent(v *viewer, drop model.DropModel) (model.DropModel, error) {
+	hiddenAuthorIds := map[uint]bool{}
+	for _, comment := range drop.GetComments() {
+		if err := s.hideAuthor(v, comment.GetCreatedBy(), "Comment", hiddenAuthorIds); err != nil {
+			return nil, err
+		}
+		for _, response := range comment.GetResponses() {
+			if err := s.hideAuthor(v, response.GetCreatedBy(), "Response", hiddenAuthorIds); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return drop.WithoutContentFrom(hiddenAuthorIds), nil
+}
+
+func (s *VisibilityService) hideAuthor(v *viewer, author model.UserModel, entity string, hiddenAuthorIds map[uint]bool) error {
+	if nil == author || hiddenAuthorIds[author.GetID()] {
+		return nil
+	}
+
+	if err := s.checkAuthor(v, author, entity); err != nil {
+		if !isHiddenError(err) {
+			return err
+		}
+		hiddenAuthorIds[author.GetID()] = true
+	}
+	return nil
+}
+
+// CheckComment returns nil when the user can see the comment and its drop
+func (s *VisibilityService) CheckComment(viewerId uint, comment model.CommentModel) error {
+	if nil == comment {
+		return errors2.NotFoundError{Entity: "Comment"}
+	}
+
+	v, err := s.loadViewer(viewerId)
+	if err != nil {
+		return err
+	}
+
+	return s.checkComment(v, comment)
+}
+
+func (s *VisibilityService) checkComment(v *viewer, comment model.CommentModel) error {
+	drop, err := s.Repo.DropRepository.GetDropById(comment.GetDrop().GetID())
+	if err != nil {
+		return errors2.NotFoundError{Entity: "Drop"}
+	}
+
+	dropFacts, err := s.dropFacts(v, drop)
+	if err != nil {
+		return err
+	}
+
+	if err := CheckDropVisibility(dropFacts); err != nil {
+		return err
+	}
+
+	return s.checkAuthor(v, comment.GetCreatedBy(), "Comment")
+}
+
+// CheckResponse returns nil when the user can see the response, its comment and its drop
+func (s *VisibilityService) CheckResponse(viewerId uint, response model.CommentResponseModel) error {
+	if nil == response {
+		return errors2.NotFoundError{Entity: "Response"}
+	}
+
+	v, err := s.loadViewer(viewerId)
+	if err != nil {
+		return err
+	}
+
+	comment, err := s.Repo.CommentRepository.GetById(response.GetComment().GetID())
+	if err != nil {
+		return errors2.NotFoundError{Entity: "Comment"}
+	}
+
+	if err := s.checkComment(v, comment); err != nil {
+		return err
+	}
+
+	return s.checkAuthor(v, response.GetCreatedBy(), "Response")
+}
+
+// checkAuthor checks the preloaded author of a comment or a response
+func (s *VisibilityService) checkAuthor(v *viewer, author model.UserModel, entity string) error {
+	if nil == author {
+		return errors2.NotFoundError{Entity: entity}
+	}
+
+	return CheckCommentVisibility(AuthorFacts{
+		ViewerID:     v.id,
+		AuthorID:     author.GetID(),
+		AuthorStatus: author.GetStatus(),
+		IsBlocked:    v.blocked[author.GetID()],
+	}, entity)
+}
+
+func isHiddenError(err error) bool {
+	var notFoundErr errors2.NotFoundError
+	var notAllowedErr errors2.NotAllowedError
+	return errors.As(err, &notFoundErr) || errors.As(err, &notAllowedErr)
+}
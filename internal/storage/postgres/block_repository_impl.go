@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"go-api/pkg/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ model.BlockModel = (*Block)(nil)
+
+type Block struct {
+	gorm.Model
+	BlockerID uint `gorm:"not null;uniqueIndex:idx_block_blocker_blocked"`
+	BlockedID uint `gorm:"not null;uniqueIndex:idx_block_blocker_blocked;index"`
+	Blocker   User `gorm:"foreignKey:BlockerID;references:ID"`
+	Blocked   User `gorm:"foreignKey:BlockedID;references:ID"`
+}
+
+func (b *Block) GetID() uint { return b.ID }
+
+func (b *Block) GetBlockerID() uint { return b.BlockerID }
+
+func (b *Block) GetBlockedID() uint { return b.BlockedID }
+
+func (b *Block) GetBlocked() model.UserModel { return &b.Blocked }
+
+func (b *Block) GetCreatedAt() int { return int(b.CreatedAt.Unix()) }
+
+type repoBlockPrivate struct {
+	db *gorm.DB
+}
+
+func NewBlockRepo(db *gorm.DB) model.BlockRepository {
+	return &repoBlockPrivate{db: db}
+}
+
+func (r *repoBlockPrivate) Create(blockerID uint, blockedID uint) (model.BlockModel, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		block := Block{BlockerID: blockerID, BlockedID: blockedID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&block).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where(
+			"(follower_id = ? AND followed_id = ?) OR (follower_id = ? AND followed_id = ?)",
+			blockerID, blockedID, blockedID, blockerID,
+		).Delete(&Follow{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Where(
+			"(user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)",
+			blockerID, blockedID, blockedID, blockerID,
+		).Delete(&CloseFriend{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var block Block
+	if err := r.db.Preload("Blocked").Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).First(&block).Error; err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// Delete removes the row for good so the user can be blocked again
+func (r *repoBlockPrivate) Delete(blockerID uint, blockedID uint) error {
+	return r.db.Unscoped().Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).Delete(&Block{}).Error
+}
+
+func (r *repoBlockPrivate) GetBlockedUsers(blockerID uint) ([]model.BlockModel, error) {
+	var blocks []*Block
+	if err := r.db.Preload("Blocked").Where("blocker_id = ?", blockerID).Order("created_at DESC").Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+
+	models := make([]model.BlockModel, len(blocks))
+	for i, v := range blocks {
+		models[i] = model.BlockModel(v)
+	}
+	return models, nil
+}
+
+func (r *repoBlockPrivate) IsBlockedBetween(userID uint, otherUserID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&Block{}).Where(
+		"(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)",
+		userID, otherUserID, otherUserID, userID,
+	).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *repoBlockPrivate) GetBlockRelatedUserIds(userID uint) ([]uint, error) {
+	var userIds []uint
+	err := r.db.Raw(`
+		SELECT blocked_id FROM blocks WHERE blocker_id = @userId AND deleted_at IS NULL
+		UNION
+		SELECT blocker_id FROM blocks WHERE blocked_id = @userId AND deleted_at IS NULL`,
+		map[string]interface{}{"userId": userID},
+	).Scan(&userIds).Error
+	if err != nil {
+		return nil, err
+	}
+	return userIds, nil
+}
+
+func (r *repoBlockPrivate) GetHiddenUserIds(userID uint) ([]uint, error) {
+	var userIds []uint
+	err := r.db.Raw(`
+		SELECT blocked_id FROM blocks WHERE blocker_id = @userId AND deleted_at IS NULL
+		UNION
+		SELECT blocker_id FROM blocks WHERE blocked_id = @userId AND deleted_at IS NULL
+		UNION
+		SELECT muted_id FROM mutes WHERE muter_id = @userId AND deleted_at IS NULL`,
+		map[string]interface{}{"userId": userID},
+	).Scan(&userIds).Error
+	if err != nil {
+		return nil, err
+	}
+	return userIds, nil
+}
+
+func (r *repoBlockPrivate) GetHiddenUserIdsAmong(userIDs []uint, otherUserIDs []uint) (map[uint][]uint, error) {
+	var rows []struct {
+		UserID   uint
+		HiddenID uint
+	}
+	err := r.db.Raw(`
+		SELECT blocker_id AS user_id, blocked_id AS hidden_id FROM blocks WHERE blocker_id IN ? AND blocked_id IN ? AND deleted_at IS NULL
+		UNION
+		SELECT blocked_id, blocker_id FROM blocks WHERE blocked_id IN ? AND blocker_id IN ? AND deleted_at IS NULL
+		UNION
+		SELECT muter_id, muted_id FROM mutes WHERE muter_id IN ? AND muted_id IN ? AND deleted_at IS NULL`,
+		userIDs, otherUserIDs, userIDs, otherUserIDs, userIDs, otherUserIDs,
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	hiddenUserIds := make(map[uint][]uint)
+	for _, row := range rows {
+		hiddenUserIds[row.UserID] = append(hiddenUserIds[row.UserID], row.HiddenID)
+	}
+	return hiddenUserIds, nil
+}
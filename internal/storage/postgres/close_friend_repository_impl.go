@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"go-api/pkg/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ model.CloseFriendModel = (*CloseFriend)(nil)
+
+type CloseFriend struct {
+	gorm.Model
+	UserID   uint `gorm:"not null;uniqueIndex:idx_close_friend_user_friend"`
+	FriendID uint `gorm:"not null;uniqueIndex:idx_close_friend_user_friend;index"`
+	User     User `gorm:"foreignKey:UserID;references:ID"`
+	Friend   User `gorm:"foreignKey:FriendID;references:ID"`
+}
+
+func (c *CloseFriend) GetID() uint { return c.ID }
+
+func (c *CloseFriend) GetUserID() uint { return c.UserID }
+
+func (c *CloseFriend) GetFriendID() uint { return c.FriendID }
+
+func (c *CloseFriend) GetFriend() model.UserModel { return &c.Friend }
+
+func (c *CloseFriend) GetCreatedAt() int { return int(c.CreatedAt.Unix()) }
+
+type repoCloseFriendPrivate struct {
+	db *gorm.DB
+}
+
+func NewCloseFriendRepo(db *gorm.DB) model.CloseFriendRepository {
+	return &repoCloseFriendPrivate{db: db}
+}
+
+func (r *repoCloseFriendPrivate) Create(userID uint, friendID uint) (model.CloseFriendModel, error) {
+	closeFriend := CloseFriend{UserID: userID, FriendID: friendID}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&closeFriend).Error; err != nil {
+		return nil, err
+	}
+
+	if err := r.db.Preload("Friend").Where("user_id = ? AND friend_id = ?", userID, friendID).First(&closeFriend).Error; err != nil {
+		return nil, err
+	}
+	return &closeFriend, nil
+}
+
+// Delete removes the row for good so the friend can be added again
+func (r *repoCloseFriendPrivate) Delete(userID uint, friendID uint) error {
+	return r.db.Unscoped().Where("user_id = ? AND friend_id = ?", userID, friendID).Delete(&CloseFriend{}).Error
+}
+
+func (r *repoCloseFriendPrivate) GetCloseFriends(userID uint) ([]model.CloseFriendModel, error) {
+	var closeFriends []*CloseFriend
+	if err := r.db.Preload("Friend").Where("user_id = ?", userID).Order("created_at DESC").Find(&closeFriends).Error; err != nil {
+		return nil, err
+	}
+
+	models := make([]model.CloseFriendModel, len(closeFriends))
+	for i, v := range closeFriends {
+		models[i] = model.CloseFriendModel(v)
+	}
+	return models, nil
+}
+
+func (r *repoCloseFriendPrivate) GetUserIdsHavingCloseFriend(friendID uint) ([]uint, error) {
+	var userIds []uint
+	if err := r.db.Model(&CloseFriend{}).Where("friend_id = ?", friendID).Pluck("user_id", &userIds).Error; err != nil {
+		return nil, err
+	}
+	return userIds, nil
+}
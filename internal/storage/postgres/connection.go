@@ -10,14 +10,18 @@ import (
 
 var DB *gorm.DB
 
-func Init() {
+// DSN builds the connection string from DB_USER, DB_PASSWORD, DB_NAME and DB_HOST
+func DSN() string {
 	dbUser := os.Getenv("DB_USER")
 	dbPassword := os.Getenv("DB_PASSWORD")
 	dbName := os.Getenv("DB_NAME")
 	dbHost := os.Getenv("DB_HOST")
-	dsn := "user=" + dbUser + " host=" + dbHost + " dbname=" + dbName + " password=" + dbPassword + " sslmode=disable"
+	return "user=" + dbUser + " host=" + dbHost + " dbname=" + dbName + " password=" + dbPassword + " sslmode=disable"
+}
+
+func Init() {
 	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
+	DB, err = gorm.Open(postgres.Open(DSN()), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 	if err != nil {
@@ -35,6 +39,7 @@ func AutoMigrate() {
 	sqlDB.AutoMigrate(
 		&User{},
 		&AuthToken{},
+		&AuthSession{},
 		&Follow{},
 		&Drop{},
 		&DropNotification{},
@@ -45,6 +50,24 @@ func AutoMigrate() {
 		&CommentResponse{},
 		&Like{},
 		&Report{},
+		&RealtimeEvent{},
+		&DropView{},
+		&Block{},
+		&Mute{},
+		&CloseFriend{},
+		&UserToken{},
+		&LoginAttempt{},
+		&LoginLockout{},
+		&TwoFactor{},
+		&RecoveryCode{},
+		&UsedTwoFactorToken{},
 	)
+
+	// Refresh tokens used to be stored in plain text, AutoMigrate never drops columns so it is done here
+	if sqlDB.Migrator().HasColumn(&AuthToken{}, "token") {
+		if err := sqlDB.Migrator().DropColumn(&AuthToken{}, "token"); err != nil {
+			log.Printf("Error: Error dropping auth_tokens.token: %v\n", err)
+		}
+	}
 	log.Println("Info: Migrations done")
 }
@@ -1,21 +1,73 @@
 package postgres
 
 import (
+	"errors"
 	"go-api/pkg/model"
 	"gorm.io/gorm"
+	"time"
 )
 
 type DropNotification struct {
 	gorm.Model
 	Type string
+	// Kind optionally narrows the type, e.g. a Spotify album
+	Kind        string
+	ScheduledAt *time.Time `gorm:"index"`
+	FiredAt     *time.Time
+	// Status defaults to fired so notifications sent before the scheduler existed stay current
+	Status int `gorm:"not null;default:1;index"`
+	// ResponseWindow is the number of minutes users have to drop on time once the notification is fired
+	ResponseWindow int `gorm:"not null;default:10"`
 }
 
 func (d *DropNotification) GetID() uint { return d.ID }
 
 func (d *DropNotification) GetType() string { return d.Type }
 
+func (d *DropNotification) GetKind() string { return d.Kind }
+
 func (d *DropNotification) GetCreatedAt() string { return d.CreatedAt.String() }
 
+func (d *DropNotification) GetScheduledAt() int {
+	if d.ScheduledAt == nil {
+		return 0
+	}
+	return int(d.ScheduledAt.Unix())
+}
+
+func (d *DropNotification) GetFiredAt() int {
+	if d.FiredAt == nil {
+		return 0
+	}
+	return int(d.FiredAt.Unix())
+}
+
+func (d *DropNotification) GetStatus() int { return d.Status }
+
+func (d *DropNotification) GetResponseWindow() int { return d.ResponseWindow }
+
+// GetDeadline returns the end of the response window, or 0 while the notification has not been fired
+func (d *DropNotification) GetDeadline() int {
+	if d.FiredAt == nil {
+		return 0
+	}
+	return int(d.FiredAt.Add(time.Duration(d.ResponseWindow) * time.Minute).Unix())
+}
+
+type DropNotificationStatusFired struct{}
+
+func (d *DropNotificationStatusFired) ToInt() int { return 1 }
+
+type DropNotificationStatusPending struct{}
+
+func (d *DropNotificationStatusPending) ToInt() int { return 2 }
+
+type DropNotificationStatusCancelled struct{}
+
+func (d *DropNotificationStatusCancelled) ToInt() int { return -1 }
+
+var _ model.DropNotificationModel = (*DropNotification)(nil)
+
 type repoDropNotifPrivate struct {
 	db *gorm.DB
 }
@@ -24,11 +76,33 @@ func NewDropNotifRepo(db *gorm.DB) model.DropNotificationRepository {
 	return &repoDropNotifPrivate{db: db}
 }
 
-func (r *repoDropNotifPrivate) Create(notificationType string) (model.DropNotificationModel, error) {
+func (r *repoDropNotifPrivate) Create(notificationType string, kind string, responseWindow int) (model.DropNotificationModel, error) {
+	now := time.Now()
+	notification := &DropNotification{
+		Type:           notificationType,
+		Kind:           kind,
+		ScheduledAt:    &now,
+		FiredAt:        &now,
+		Status:         new(DropNotificationStatusFired).ToInt(),
+		ResponseWindow: responseWindow,
+	}
+	if err := r.db.Create(notification).Error; err != nil {
+		return nil, err
+	}
+	return notification, nil
+}
+
+func (r *repoDropNotifPrivate) Schedule(notificationType string, kind string, scheduledAt time.Time, responseWindow int) (model.DropNotificationModel, error) {
 	notification := &DropNotification{
-		Type: notificationType,
+		Type:           notificationType,
+		Kind:           kind,
+		ScheduledAt:    &scheduledAt,
+		Status:         new(DropNotificationStatusPending).ToInt(),
+		ResponseWindow: responseWindow,
+	}
+	if err := r.db.Create(notification).Error; err != nil {
+		return nil, err
 	}
-	r.db.Create(notification)
 	return notification, nil
 }
 
@@ -40,6 +114,126 @@ func (r *repoDropNotifPrivate) GetNotificationByID(notificationId uint) (model.D
 
 func (r *repoDropNotifPrivate) GetCurrentDropNotification() (model.DropNotificationModel, error) {
 	var notification DropNotification
-	r.db.Order("id desc").First(&notification)
+	r.db.
+		Where("status = ?", new(DropNotificationStatusFired).ToInt()).
+		Order("COALESCE(fired_at, created_at) desc, id desc").
+		First(&notification)
 	return &notification, nil
 }
+
+func (r *repoDropNotifPrivate) GetLatestPlannedDropNotification() (model.DropNotificationModel, error) {
+	var notification DropNotification
+	if err := r.db.
+		Where("status <> ?", new(DropNotificationStatusCancelled).ToInt()).
+		Order("COALESCE(scheduled_at, created_at) desc, id desc").
+		First(&notification).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &notification, nil
+}
+
+func (r *repoDropNotifPrivate) GetUpcomingDropNotifications() ([]model.DropNotificationModel, error) {
+	var notifications []DropNotification
+	if err := r.db.
+		Where("status = ?", new(DropNotificationStatusPending).ToInt()).
+		Order("scheduled_at asc").
+		Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	var result []model.DropNotificationModel
+	for i := range notifications {
+		result = append(result, &notifications[i])
+	}
+	return result, nil
+}
+
+// PlanIfNoneBetween also counts cancelled notifications, so cancelling a planned drop leaves the day without one
+func (r *repoDropNotifPrivate) PlanIfNoneBetween(notificationType string, scheduledAt time.Time, responseWindow int, from time.Time, to time.Time) (model.DropNotificationModel, error) {
+	var planned *DropNotification
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Serialize planning between API replicas so a day is never planned twice
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('drop_notification_planning'))").Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&DropNotification{}).
+			Where("scheduled_at >= ? AND scheduled_at < ?", from, to).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		planned = &DropNotification{
+			Type:           notificationType,
+			ScheduledAt:    &scheduledAt,
+			Status:         new(DropNotificationStatusPending).ToInt(),
+			ResponseWindow: responseWindow,
+		}
+		return tx.Create(planned).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if planned == nil {
+		return nil, nil
+	}
+	return planned, nil
+}
+
+func (r *repoDropNotifPrivate) GetDueDropNotifications(now time.Time) ([]model.DropNotificationModel, error) {
+	var notifications []DropNotification
+	if err := r.db.
+		Where("status = ? AND scheduled_at <= ?", new(DropNotificationStatusPending).ToInt(), now).
+		Order("scheduled_at asc").
+		Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	var result []model.DropNotificationModel
+	for i := range notifications {
+		result = append(result, &notifications[i])
+	}
+	return result, nil
+}
+
+// MarkAsFired only succeeds for the first caller, which makes firing safe across restarts and replicas.
+func (r *repoDropNotifPrivate) MarkAsFired(notificationId uint, firedAt time.Time) (bool, error) {
+	result := r.db.Model(&DropNotification{}).
+		Where("id = ? AND status = ?", notificationId, new(DropNotificationStatusPending).ToInt()).
+		Updates(map[string]interface{}{
+			"status":   new(DropNotificationStatusFired).ToInt(),
+			"fired_at": firedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *repoDropNotifPrivate) UpdatePending(notificationId uint, updates map[string]interface{}) (model.DropNotificationModel, error) {
+	result := r.db.Model(&DropNotification{}).
+		Where("id = ? AND status = ?", notificationId, new(DropNotificationStatusPending).ToInt()).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return r.GetNotificationByID(notificationId)
+}
+
+func (r *repoDropNotifPrivate) Cancel(notificationId uint) (bool, error) {
+	result := r.db.Model(&DropNotification{}).
+		Where("id = ? AND status = ?", notificationId, new(DropNotificationStatusPending).ToInt()).
+		Update("status", new(DropNotificationStatusCancelled).ToInt())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"go-api/pkg/model"
 	"gorm.io/gorm"
+	"time"
 )
 
 type Drop struct {
@@ -25,6 +26,17 @@ type Drop struct {
 	PicturePath        string
 	Comments           []Comment `gorm:"foreignKey:DropId;references:ID"`
 	TotalLikes         int       `gorm:"-"`
+	IsLate             bool      `gorm:"default:false"`
+	// LateBy is the number of seconds the drop was posted after the notification response window
+	LateBy int `gorm:"default:0"`
+	// Content metadata below comes from the drop type provider when the drop is created
+	// ContentDuration is expressed in seconds
+	ContentDuration    int
+	ContentReleaseYear int
+	ContentGenres      []string `gorm:"serializer:json"`
+	ContentLink        string
+	// Audience restricts who can see the drop besides its author, see DropAudiences
+	Audience string `gorm:"not null;default:followers"`
 }
 
 func (d *Drop) GetID() uint { return d.ID }
@@ -63,8 +75,33 @@ func (d *Drop) GetComments() []model.CommentModel {
 	return result
 }
 
+func (d *Drop) WithoutContentFrom(userIds map[uint]bool) model.DropModel {
+	if len(userIds) == 0 {
+		return d
+	}
+
+	drop := *d
+	drop.Comments = make([]Comment, 0, len(d.Comments))
+	for _, comment := range d.Comments {
+		if userIds[comment.CreatedById] {
+			continue
+		}
+		responses := make([]CommentResponse, 0, len(comment.Responses))
+		for _, response := range comment.Responses {
+			if !userIds[response.CreatedById] {
+				responses = append(responses, response)
+			}
+		}
+		comment.Responses = responses
+		drop.Comments = append(drop.Comments, comment)
+	}
+	return &drop
+}
+
 func (d *Drop) GetTotalLikes() int { return d.TotalLikes }
 
+func (d *Drop) GetAudience() string { return d.Audience }
+
 func (d *Drop) GetContentTitle() string { return d.ContentTitle }
 
 func (d *Drop) GetContentSubtitle() string { return d.ContentSubtitle }
@@ -73,6 +110,46 @@ func (d *Drop) GetContentPicturePath() string { return d.ContentPicturePath }
 
 func (d *Drop) GetLocation() string { return d.Location }
 
+func (d *Drop) GetIsLate() bool { return d.IsLate }
+
+func (d *Drop) GetLateBy() int { return d.LateBy }
+
+func (d *Drop) GetContentDuration() int { return d.ContentDuration }
+
+func (d *Drop) GetContentReleaseYear() int { return d.ContentReleaseYear }
+
+func (d *Drop) GetContentGenres() []string { return d.ContentGenres }
+
+func (d *Drop) GetContentLink() string { return d.ContentLink }
+
+func (d *Drop) GetFeedCursor() model.DropFeedCursor {
+	return model.DropFeedCursor{
+		DropNotificationID: d.DropNotificationID,
+		CreatedAt:          d.CreatedAt.UnixMicro(),
+		ID:                 d.ID,
+	}
+}
+
+type DropAudienceFollowers struct{}
+
+func (d *DropAudienceFollowers) ToString() string { return "followers" }
+
+type DropAudienceCloseFriends struct{}
+
+func (d *DropAudienceCloseFriends) ToString() string { return "close_friends" }
+
+type DropAudienceGroups struct{}
+
+func (d *DropAudienceGroups) ToString() string { return "groups" }
+
+func DropAudiences() []string {
+	return []string{
+		new(DropAudienceFollowers).ToString(),
+		new(DropAudienceCloseFriends).ToString(),
+		new(DropAudienceGroups).ToString(),
+	}
+}
+
 type DropStatusActive struct{}
 
 func (d *DropStatusActive) ToInt() uint { return 1 }
@@ -110,7 +187,13 @@ func (r *repoDropPrivate) Create(
 	lat float64,
 	lng float64,
 	location string,
-
+	isLate bool,
+	lateBy int,
+	contentDuration int,
+	contentReleaseYear int,
+	contentGenres []string,
+	contentLink string,
+	audience string,
 ) (model.DropModel, error) {
 	drop := &Drop{
 		Type:               contentType,
@@ -127,6 +210,13 @@ func (r *repoDropPrivate) Create(
 		PicturePath:        picturePath,
 		Lat:                lat,
 		Lng:                lng,
+		IsLate:             isLate,
+		LateBy:             lateBy,
+		ContentDuration:    contentDuration,
+		ContentReleaseYear: contentReleaseYear,
+		ContentGenres:      contentGenres,
+		ContentLink:        contentLink,
+		Audience:           audience,
 	}
 	if err := r.db.Create(drop).Error; err != nil {
 		return nil, err
@@ -191,9 +281,11 @@ func (r *repoDropPrivate) GetUserDrops(userId uint) ([]model.DropModel, error) {
 		Preload("Comments", func(db *gorm.DB) *gorm.DB {
 			return db.Order("created_at DESC")
 		}).
+		Preload("Comments.CreatedBy").
 		Preload("Comments.Responses", func(db *gorm.DB) *gorm.DB {
 			return db.Order("created_at DESC")
 		}).
+		Preload("Comments.Responses.CreatedBy").
 		Where("created_by_id = ?", userId).Find(&drops).Error; err != nil {
 		return nil, err
 	}
@@ -253,6 +345,119 @@ func (r *repoDropPrivate) GetDropsByUserIdsAndDropNotificationId(userIds []uint,
 	return result, nil
 }
 
+// GetDropsFeedPage returns drops newest notification first, using the cursor as a keyset
+// so drops created while paginating never shift the next pages.
+func (r *repoDropPrivate) GetDropsFeedPage(filter model.DropFeedFilter) ([]model.DropModel, error) {
+	query := r.db.
+		Preload("CreatedBy").
+		Preload("Comments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at DESC")
+		}).
+		Preload("Comments.CreatedBy").
+		Preload("Comments.Responses", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at DESC")
+		}).
+		Preload("Comments.Responses.CreatedBy").
+		Where("created_by_id IN ?", filter.UserIds)
+
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+
+	if filter.ExcludedDropNotificationID != 0 {
+		query = query.Where("drop_notification_id <> ?", filter.ExcludedDropNotificationID)
+	}
+
+	if filter.After != nil {
+		query = query.Where(
+			"(drop_notification_id, created_at, id) < (?, ?, ?)",
+			filter.After.DropNotificationID,
+			time.UnixMicro(filter.After.CreatedAt),
+			filter.After.ID,
+		)
+	}
+
+	var drops []Drop
+	if err := query.
+		Order("drop_notification_id desc, created_at desc, id desc").
+		Limit(filter.Limit).
+		Find(&drops).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range drops {
+		var totalLikes int64
+		if err := r.db.Model(&Like{}).Where("drop_id = ?", drops[i].ID).Count(&totalLikes).Error; err != nil {
+			return nil, err
+		}
+		drops[i].TotalLikes = int(totalLikes)
+	}
+
+	var result []model.DropModel
+	for i := range drops {
+		result = append(result, &drops[i])
+	}
+	return result, nil
+}
+
+func (r *repoDropPrivate) GetDiscoverCandidates(userId uint, dropNotificationId uint, limit int) ([]model.DropModel, error) {
+	var drops []Drop
+	if err := r.db.
+		Preload("CreatedBy").
+		Preload("Comments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at DESC")
+		}).
+		Preload("Comments.CreatedBy").
+		Preload("Comments.Responses", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at DESC")
+		}).
+		Preload("Comments.Responses.CreatedBy").
+		Joins("JOIN users AS author ON author.id = drops.created_by_id AND author.status = ? AND author.is_private = ? AND author.deleted_at IS NULL", 1, false).
+		Where("drops.drop_notification_id = ? AND drops.status = ? AND drops.created_by_id <> ?", dropNotificationId, new(DropStatusActive).ToInt(), userId).
+		Where("drops.audience = ?", new(DropAudienceFollowers).ToString()).
+		Where("NOT EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = ? AND follows.followed_id = drops.created_by_id AND follows.status = ? AND follows.deleted_at IS NULL)", userId, new(FollowAcceptedStatus).ToInt()).
+		Where("NOT EXISTS (SELECT 1 FROM drop_views WHERE drop_views.user_id = ? AND drop_views.drop_id = drops.id AND drop_views.deleted_at IS NULL)", userId).
+		Order("drops.created_at desc").
+		Limit(limit).
+		Find(&drops).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range drops {
+		var totalLikes int64
+		if err := r.db.Model(&Like{}).Where("drop_id = ?", drops[i].ID).Count(&totalLikes).Error; err != nil {
+			return nil, err
+		}
+		drops[i].TotalLikes = int(totalLikes)
+	}
+
+	var result []model.DropModel
+	for i := range drops {
+		result = append(result, &drops[i])
+	}
+	return result, nil
+}
+
+func (r *repoDropPrivate) CountDropsByContent(dropNotificationId uint) (map[string]int, error) {
+	var rows []struct {
+		Content string
+		Total   int
+	}
+	if err := r.db.Model(&Drop{}).
+		Select("content, COUNT(*) AS total").
+		Where("drop_notification_id = ? AND status = ?", dropNotificationId, new(DropStatusActive).ToInt()).
+		Group("content").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Content] = row.Total
+	}
+	return counts, nil
+}
+
 func (r *repoDropPrivate) HasUserDropped(dropNotificationId uint, userId uint) (bool, error) {
 	var count int64
 	if err := r.db.Model(&Drop{}).Where("drop_notification_id = ? AND created_by_id = ?", dropNotificationId, userId).Count(&count).Error; err != nil {
@@ -261,6 +466,14 @@ func (r *repoDropPrivate) HasUserDropped(dropNotificationId uint, userId uint) (
 	return count > 0, nil
 }
 
+func (r *repoDropPrivate) GetUserIdsHavingDropped(dropNotificationId uint, userIds []uint) ([]uint, error) {
+	var droppedUserIds []uint
+	if err := r.db.Model(&Drop{}).Where("drop_notification_id = ? AND created_by_id IN ?", dropNotificationId, userIds).Distinct().Pluck("created_by_id", &droppedUserIds).Error; err != nil {
+		return nil, err
+	}
+	return droppedUserIds, nil
+}
+
 func (r *repoDropPrivate) GetUserPinnedDrops(userId uint) ([]model.DropModel, error) {
 	var drops []Drop
 	if err := r.db.
@@ -0,0 +1,35 @@
+package postgres
+
+import (
+	"go-api/pkg/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DropView records the drops shown to a user outside of their own feed, e.g. in the discover feed
+type DropView struct {
+	gorm.Model
+	UserID uint `gorm:"not null;uniqueIndex:idx_drop_view_user_drop"`
+	DropID uint `gorm:"not null;uniqueIndex:idx_drop_view_user_drop"`
+}
+
+type repoDropViewPrivate struct {
+	db *gorm.DB
+}
+
+func NewDropViewRepo(db *gorm.DB) model.DropViewRepository {
+	return &repoDropViewPrivate{db: db}
+}
+
+func (r *repoDropViewPrivate) MarkAsSeen(userId uint, dropIds []uint) error {
+	if len(dropIds) == 0 {
+		return nil
+	}
+
+	views := make([]DropView, 0, len(dropIds))
+	for _, dropId := range dropIds {
+		views = append(views, DropView{UserID: userId, DropID: dropId})
+	}
+
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&views).Error
+}
@@ -3,6 +3,8 @@ package postgres
 import (
 	"go-api/pkg/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
 )
 
 type Follow struct {
@@ -56,6 +58,30 @@ func (f *FollowAcceptedStatus) ToInt() uint {
 	return 1
 }
 
+// FollowRejectedStatus is kept on the soft deleted request so suggestions do not offer the user again
+type FollowRejectedStatus struct {
+}
+
+func (f *FollowRejectedStatus) ToInt() uint {
+	return 2
+}
+
+// FollowExpiredStatus is kept on the soft deleted requests nobody answered in time
+type FollowExpiredStatus struct {
+}
+
+func (f *FollowExpiredStatus) ToInt() uint {
+	return 3
+}
+
+// FollowRemovedStatus is kept on the soft deleted follows of followers removed by the followed user
+type FollowRemovedStatus struct {
+}
+
+func (f *FollowRemovedStatus) ToInt() uint {
+	return 4
+}
+
 var _ model.FollowModel = (*Follow)(nil)
 
 type repoFollowPrivate struct {
@@ -91,7 +117,12 @@ func (r *repoFollowPrivate) AcceptRequest(followId uint) error {
 }
 
 func (r *repoFollowPrivate) RejectRequest(followId uint) error {
-	return r.Delete(followId)
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Follow{}).Where("id = ?", followId).Update("status", new(FollowRejectedStatus).ToInt()).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Follow{}, followId).Error
+	})
 }
 
 func (r *repoFollowPrivate) Delete(followId uint) error {
@@ -256,3 +287,129 @@ func (r *repoFollowPrivate) GetPendingFollowByID(followID uint) (model.FollowMod
 
 	return &follow, nil
 }
+
+func (r *repoFollowPrivate) GetSentRequests(userID uint) ([]model.FollowModel, error) {
+	var follows []Follow
+	result := r.db.
+		Preload("Follower").
+		Preload("Followed").
+		Where("follower_id = ? AND status = ?", userID, new(FollowPendingStatus).ToInt()).
+		Order("created_at desc").
+		Find(&follows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	var models []model.FollowModel
+	for _, follow := range follows {
+		models = append(models, &follow)
+	}
+	return models, nil
+}
+
+func (r *repoFollowPrivate) AcceptPendingRequests(followedID uint) ([]model.FollowModel, error) {
+	return r.updateFollows(
+		func(db *gorm.DB) *gorm.DB { return db.Where("followed_id = ?", followedID) },
+		new(FollowPendingStatus).ToInt(),
+		new(FollowAcceptedStatus).ToInt(),
+		false,
+		nil,
+	)
+}
+
+// followRequestExpiryLockKey is the advisory lock electing the API instance which expires the follow requests
+const followRequestExpiryLockKey = 19001
+
+// ExpirePendingRequests returns nothing while another API instance is expiring the requests,
+// so that every replica running the expiry does not publish the same transitions
+func (r *repoFollowPrivate) ExpirePendingRequests(sentBefore time.Time) ([]model.FollowModel, error) {
+	var follows []model.FollowModel
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", followRequestExpiryLockKey).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		var err error
+		follows, err = (&repoFollowPrivate{db: tx}).updateFollows(
+			func(db *gorm.DB) *gorm.DB { return db.Where("created_at < ?", sentBefore) },
+			new(FollowPendingStatus).ToInt(),
+			new(FollowExpiredStatus).ToInt(),
+			true,
+			nil,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return follows, nil
+}
+
+// RemoveFollowers also takes the removed followers out of the followed user's close friends
+func (r *repoFollowPrivate) RemoveFollowers(followedID uint, followerIDs []uint) ([]model.FollowModel, error) {
+	return r.updateFollows(
+		func(db *gorm.DB) *gorm.DB {
+			return db.Where("followed_id = ? AND follower_id IN ?", followedID, followerIDs)
+		},
+		new(FollowAcceptedStatus).ToInt(),
+		new(FollowRemovedStatus).ToInt(),
+		true,
+		func(tx *gorm.DB, follows []Follow) error {
+			removedIDs := make([]uint, 0, len(follows))
+			for _, follow := range follows {
+				removedIDs = append(removedIDs, follow.FollowerID)
+			}
+			return tx.Unscoped().Where("user_id = ? AND friend_id IN ?", followedID, removedIDs).Delete(&CloseFriend{}).Error
+		},
+	)
+}
+
+// updateFollows moves the follows matched by scope from fromStatus to status, soft deleting them if asked.
+// The status is checked by the UPDATE itself, so the follows changed concurrently are left alone and only the rows actually changed are returned.
+// andThen runs in the same transaction once the follows are updated, when there are some.
+func (r *repoFollowPrivate) updateFollows(scope func(db *gorm.DB) *gorm.DB, fromStatus uint, status uint, remove bool, andThen func(tx *gorm.DB, follows []Follow) error) ([]model.FollowModel, error) {
+	var follows []Follow
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		values := map[string]interface{}{"status": status}
+		if remove {
+			values["deleted_at"] = time.Now()
+		}
+
+		var updated []Follow
+		err := scope(tx.Model(&updated)).
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+			Where("status = ?", fromStatus).
+			Updates(values).Error
+		if err != nil {
+			return err
+		}
+		if len(updated) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(updated))
+		for _, follow := range updated {
+			ids = append(ids, follow.ID)
+		}
+		if err := tx.Unscoped().Preload("Follower").Preload("Followed").Find(&follows, ids).Error; err != nil {
+			return err
+		}
+
+		if andThen != nil {
+			return andThen(tx, follows)
+		}
+		return nil
+	})
+	if err != nil || len(follows) == 0 {
+		return nil, err
+	}
+
+	models := make([]model.FollowModel, 0, len(follows))
+	for i := range follows {
+		models = append(models, &follows[i])
+	}
+	return models, nil
+}
@@ -81,3 +81,11 @@ func (r *repoLikePrivate) LikeExists(dropId uint, userId uint) (bool, error) {
 	}
 	return count > 0, nil
 }
+
+func (r *repoLikePrivate) GetLikingUserIds(dropId uint, userIds []uint) ([]uint, error) {
+	var likingUserIds []uint
+	if err := r.db.Model(&Like{}).Where("drop_id = ? AND user_id IN ?", dropId, userIds).Pluck("user_id", &likingUserIds).Error; err != nil {
+		return nil, err
+	}
+	return likingUserIds, nil
+}
@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"errors"
+	"go-api/pkg/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type LoginAttempt struct {
+	gorm.Model
+	Key           string `gorm:"uniqueIndex;not null"`
+	Failures      int
+	LastFailureAt time.Time
+	BlockedUntil  time.Time
+	Lockouts      int
+	Pending       int
+	PendingSince  time.Time
+}
+
+func (a *LoginAttempt) toState() model.LoginAttemptState {
+	return model.LoginAttemptState{
+		Failures:      a.Failures,
+		LastFailureAt: a.LastFailureAt,
+		BlockedUntil:  a.BlockedUntil,
+		Lockouts:      a.Lockouts,
+		Pending:       a.Pending,
+		PendingSince:  a.PendingSince,
+	}
+}
+
+type LoginLockout struct {
+	gorm.Model
+	Key         string `gorm:"index"`
+	Scope       string
+	Failures    int
+	LockedUntil time.Time
+}
+
+func (l *LoginLockout) GetID() uint {
+	return l.ID
+}
+
+func (l *LoginLockout) GetKey() string {
+	return l.Key
+}
+
+func (l *LoginLockout) GetScope() string {
+	return l.Scope
+}
+
+func (l *LoginLockout) GetFailures() int {
+	return l.Failures
+}
+
+func (l *LoginLockout) GetLockedUntil() int {
+	return int(l.LockedUntil.Unix())
+}
+
+func (l *LoginLockout) GetCreatedAt() int {
+	return int(l.CreatedAt.Unix())
+}
+
+var _ model.LoginLockoutModel = (*LoginLockout)(nil)
+
+type repoLoginAttemptPrivate struct {
+	db *gorm.DB
+}
+
+var _ model.LoginAttemptRepository = (*repoLoginAttemptPrivate)(nil)
+
+func NewLoginAttemptRepo(db *gorm.DB) model.LoginAttemptRepository {
+	return &repoLoginAttemptPrivate{db: db}
+}
+
+func (r *repoLoginAttemptPrivate) GetState(key string) (model.LoginAttemptState, error) {
+	var attempt LoginAttempt
+	err := r.db.Where("key = ?", key).First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.LoginAttemptState{}, nil
+	}
+	if err != nil {
+		return model.LoginAttemptState{}, err
+	}
+	return attempt.toState(), nil
+}
+
+func (r *repoLoginAttemptPrivate) UpdateState(key string, update func(state model.LoginAttemptState) model.LoginAttemptState) (model.LoginAttemptState, error) {
+	var newState model.LoginAttemptState
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&LoginAttempt{Key: key}).Error; err != nil {
+			return err
+		}
+
+		var attempt LoginAttempt
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&attempt).Error; err != nil {
+			return err
+		}
+
+		newState = update(attempt.toState())
+
+		return tx.Model(&attempt).Updates(map[string]interface{}{
+			"failures":        newState.Failures,
+			"last_failure_at": newState.LastFailureAt,
+			"blocked_until":   newState.BlockedUntil,
+			"lockouts":        newState.Lockouts,
+			"pending":         newState.Pending,
+			"pending_since":   newState.PendingSince,
+		}).Error
+	})
+
+	return newState, err
+}
+
+// DeleteState removes the row for good, the unique key could not be inserted again otherwise
+func (r *repoLoginAttemptPrivate) DeleteState(key string) error {
+	return r.db.Unscoped().Where("key = ?", key).Delete(&LoginAttempt{}).Error
+}
+
+func (r *repoLoginAttemptPrivate) CreateLockout(key string, scope string, failures int, lockedUntil time.Time) (model.LoginLockoutModel, error) {
+	lockout := LoginLockout{Key: key, Scope: scope, Failures: failures, LockedUntil: lockedUntil}
+	if err := r.db.Create(&lockout).Error; err != nil {
+		return nil, err
+	}
+	return &lockout, nil
+}
+
+func (r *repoLoginAttemptPrivate) GetLockouts(limit int) ([]model.LoginLockoutModel, error) {
+	var lockouts []LoginLockout
+	if err := r.db.Order("created_at desc").Limit(limit).Find(&lockouts).Error; err != nil {
+		return nil, err
+	}
+	var models []model.LoginLockoutModel
+	for i := range lockouts {
+		models = append(models, &lockouts[i])
+	}
+	return models, nil
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"go-api/pkg/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ model.MuteModel = (*Mute)(nil)
+
+type Mute struct {
+	gorm.Model
+	MuterID uint `gorm:"not null;uniqueIndex:idx_mute_muter_muted"`
+	MutedID uint `gorm:"not null;uniqueIndex:idx_mute_muter_muted"`
+	Muter   User `gorm:"foreignKey:MuterID;references:ID"`
+	Muted   User `gorm:"foreignKey:MutedID;references:ID"`
+}
+
+func (m *Mute) GetID() uint { return m.ID }
+
+func (m *Mute) GetMuterID() uint { return m.MuterID }
+
+func (m *Mute) GetMutedID() uint { return m.MutedID }
+
+func (m *Mute) GetMuted() model.UserModel { return &m.Muted }
+
+func (m *Mute) GetCreatedAt() int { return int(m.CreatedAt.Unix()) }
+
+type repoMutePrivate struct {
+	db *gorm.DB
+}
+
+func NewMuteRepo(db *gorm.DB) model.MuteRepository {
+	return &repoMutePrivate{db: db}
+}
+
+func (r *repoMutePrivate) Create(muterID uint, mutedID uint) (model.MuteModel, error) {
+	mute := Mute{MuterID: muterID, MutedID: mutedID}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&mute).Error; err != nil {
+		return nil, err
+	}
+
+	if err := r.db.Preload("Muted").Where("muter_id = ? AND muted_id = ?", muterID, mutedID).First(&mute).Error; err != nil {
+		return nil, err
+	}
+	return &mute, nil
+}
+
+// Delete removes the row for good so the user can be muted again
+func (r *repoMutePrivate) Delete(muterID uint, mutedID uint) error {
+	return r.db.Unscoped().Where("muter_id = ? AND muted_id = ?", muterID, mutedID).Delete(&Mute{}).Error
+}
+
+func (r *repoMutePrivate) GetMutedUsers(muterID uint) ([]model.MuteModel, error) {
+	var mutes []*Mute
+	if err := r.db.Preload("Muted").Where("muter_id = ?", muterID).Order("created_at DESC").Find(&mutes).Error; err != nil {
+		return nil, err
+	}
+
+	models := make([]model.MuteModel, len(mutes))
+	for i, v := range mutes {
+		models[i] = model.MuteModel(v)
+	}
+	return models, nil
+}
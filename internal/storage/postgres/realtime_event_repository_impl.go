@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"errors"
+	"go-api/pkg/model"
+	"gorm.io/gorm"
+	"strconv"
+	"time"
+)
+
+// RealtimeEvent keeps the events published through the Postgres broker.
+// NOTIFY payloads are limited to 8000 bytes, so only the event ID goes through NOTIFY.
+type RealtimeEvent struct {
+	ID      uint   `gorm:"primarykey"`
+	Channel string `gorm:"not null;index:idx_realtime_event_user_channel"`
+	// UserID is 0 for events sent to every user connected on the channel
+	UserID    uint      `gorm:"not null;index:idx_realtime_event_user_channel"`
+	Payload   []byte    `gorm:"type:bytea;not null"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+func (e *RealtimeEvent) GetID() uint { return e.ID }
+
+func (e *RealtimeEvent) GetChannel() string { return e.Channel }
+
+func (e *RealtimeEvent) GetUserID() uint { return e.UserID }
+
+func (e *RealtimeEvent) GetPayload() []byte { return e.Payload }
+
+func (e *RealtimeEvent) GetCreatedAt() int { return int(e.CreatedAt.Unix()) }
+
+var _ model.RealtimeEventModel = (*RealtimeEvent)(nil)
+
+type repoRealtimeEventPrivate struct {
+	db *gorm.DB
+}
+
+func NewRealtimeEventRepo(db *gorm.DB) model.RealtimeEventRepository {
+	return &repoRealtimeEventPrivate{db: db}
+}
+
+func (r *repoRealtimeEventPrivate) Create(notifyChannel string, channel string, userId uint, payload []byte) (model.RealtimeEventModel, error) {
+	event := &RealtimeEvent{
+		Channel: channel,
+		UserID:  userId,
+		Payload: payload,
+	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		// Notifications are only delivered on commit, listeners never miss the row
+		return tx.Exec("SELECT pg_notify(?, ?)", notifyChannel, strconv.FormatUint(uint64(event.ID), 10)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (r *repoRealtimeEventPrivate) GetEventByID(eventId uint) (model.RealtimeEventModel, error) {
+	var event RealtimeEvent
+	if err := r.db.First(&event, eventId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *repoRealtimeEventPrivate) GetUserEventsSince(channel string, userId uint, afterId uint) ([]model.RealtimeEventModel, error) {
+	var events []RealtimeEvent
+	if err := r.db.
+		Where("channel = ? AND user_id IN ? AND id > ?", channel, []uint{0, userId}, afterId).
+		Order("id asc").
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	var result []model.RealtimeEventModel
+	for i := range events {
+		result = append(result, &events[i])
+	}
+	return result, nil
+}
+
+func (r *repoRealtimeEventPrivate) GetEventIDRange() (uint, uint, error) {
+	var idRange struct {
+		Oldest uint
+		Newest uint
+	}
+	err := r.db.Model(&RealtimeEvent{}).
+		Select("COALESCE(MIN(id), 0) AS oldest, COALESCE(MAX(id), 0) AS newest").
+		Scan(&idRange).Error
+	return idRange.Oldest, idRange.Newest, err
+}
+
+func (r *repoRealtimeEventPrivate) DeleteOlderThan(before time.Time) (int64, error) {
+	result := r.db.
+		Where("created_at < ? AND id < (SELECT MAX(id) FROM realtime_events)", before).
+		Delete(&RealtimeEvent{})
+	return result.RowsAffected, result.Error
+}
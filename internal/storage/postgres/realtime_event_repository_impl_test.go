@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	gormpostgres "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// openTestDB connects with the DB_* variables and skips the test when no database is configured
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	if os.Getenv("DB_HOST") == "" {
+		t.Skip("DB_HOST is not set, skipping Postgres test")
+	}
+
+	db, err := gorm.Open(gormpostgres.Open(DSN()), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	return db
+}
+
+func TestRealtimeEventRepoCreate(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&RealtimeEvent{}); err != nil {
+		t.Fatalf("AutoMigrate() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, DSN())
+	if err != nil {
+		t.Fatalf("pgx.Connect() error = %v", err)
+	}
+	defer conn.Close(context.Background())
+
+	notifyChannel := "droppy_realtime_test"
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		t.Fatalf("LISTEN error = %v", err)
+	}
+
+	repo := NewRealtimeEventRepo(db)
+	event, err := repo.Create(notifyChannel, "feed", 42, []byte(`{"id":1}`))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	defer db.Delete(&RealtimeEvent{}, event.GetID())
+
+	notification, err := conn.WaitForNotification(ctx)
+	if err != nil {
+		t.Fatalf("WaitForNotification() error = %v", err)
+	}
+	if want := strconv.FormatUint(uint64(event.GetID()), 10); notification.Payload != want {
+		t.Errorf("notification payload = %s, want %s", notification.Payload, want)
+	}
+
+	storedEvent, err := repo.GetEventByID(event.GetID())
+	if err != nil {
+		t.Fatalf("GetEventByID() error = %v", err)
+	}
+	if storedEvent == nil || storedEvent.GetUserID() != 42 || string(storedEvent.GetPayload()) != `{"id":1}` {
+		t.Errorf("GetEventByID() = %+v, want the created event", storedEvent)
+	}
+}
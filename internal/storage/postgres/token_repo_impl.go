@@ -4,32 +4,87 @@ import (
 	"context"
 	"go-api/pkg/model"
 	"gorm.io/gorm"
+	"time"
 )
 
 type AuthToken struct {
 	gorm.Model
-	Token  string `json:"token,omitempty"`
-	UserID uint   `json:"userId,omitempty"`
-	Expiry int    `json:"expiry,omitempty"`
+	TokenHash string `gorm:"uniqueIndex" json:"-"`
+	UserID    uint   `json:"userId,omitempty"`
+	SessionID uint   `gorm:"index" json:"sessionId,omitempty"`
+	Expiry    int    `json:"expiry,omitempty"`
+	// UsedAt is set once the token has been exchanged, presenting it again revokes its session
+	UsedAt *time.Time `json:"-"`
 }
 
 func (s *AuthToken) GetID() uint { return s.ID }
 
-func (s *AuthToken) GetToken() string {
-	return s.Token
+func (s *AuthToken) GetTokenHash() string {
+	return s.TokenHash
 }
 
 func (s *AuthToken) GetUserID() uint {
 	return s.UserID
 }
 
+func (s *AuthToken) GetSessionID() uint {
+	return s.SessionID
+}
+
 func (s *AuthToken) GetExpiry() int {
 	return s.Expiry
 }
 
+func (s *AuthToken) IsUsed() bool {
+	return s.UsedAt != nil
+}
+
 // Safe checker to know if this file already implements the model interface correctly or not
 var _ model.AuthTokenModel = (*AuthToken)(nil)
 
+type AuthSession struct {
+	gorm.Model
+	UserID     uint `gorm:"index"`
+	DeviceName string
+	IPAddress  string
+	LastUsedAt time.Time
+	RevokedAt  *time.Time
+	// TwoFactor is set when the session was opened with the second factor, the access tokens it refreshes carry the mfa claim
+	TwoFactor bool `gorm:"not null;default:false"`
+}
+
+func (s *AuthSession) GetID() uint { return s.ID }
+
+func (s *AuthSession) GetUserID() uint {
+	return s.UserID
+}
+
+func (s *AuthSession) GetDeviceName() string {
+	return s.DeviceName
+}
+
+func (s *AuthSession) GetIPAddress() string {
+	return s.IPAddress
+}
+
+func (s *AuthSession) GetCreatedAt() int {
+	return int(s.CreatedAt.Unix())
+}
+
+func (s *AuthSession) GetLastUsedAt() int {
+	return int(s.LastUsedAt.Unix())
+}
+
+func (s *AuthSession) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
+
+func (s *AuthSession) IsTwoFactor() bool {
+	return s.TwoFactor
+}
+
+var _ model.AuthSessionModel = (*AuthSession)(nil)
+
 type repoTokenPrivate struct {
 	db *gorm.DB
 }
@@ -45,34 +100,133 @@ func NewTokenRepo(db *gorm.DB) model.AuthTokenRepository {
 
 func (repo *repoTokenPrivate) Create(ctx context.Context, args model.TokenCreationParam) (model.AuthTokenModel, error) {
 	tokenObject := AuthToken{
-		Token:  args.Token,
-		UserID: args.UserID,
-		Expiry: args.Expiry,
+		TokenHash: args.TokenHash,
+		UserID:    args.UserID,
+		SessionID: args.SessionID,
+		Expiry:    args.Expiry,
 	}
 
-	result := repo.db.Create(&tokenObject)
+	result := repo.db.WithContext(ctx).Create(&tokenObject)
 
 	return &tokenObject, result.Error
 }
 
-func (repo *repoTokenPrivate) FindByRefreshToken(token string) (model.AuthTokenModel, error) {
+func (repo *repoTokenPrivate) FindByTokenHash(tokenHash string) (model.AuthTokenModel, error) {
 	tokenObject := AuthToken{}
-	result := repo.db.Where("token = ?", token).First(&tokenObject)
+	result := repo.db.Where("token_hash = ?", tokenHash).First(&tokenObject)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return &tokenObject, result.Error
 }
 
-func (repo *repoTokenPrivate) FindByUserId(userId uint) (model.AuthTokenModel, error) {
-	tokenObject := AuthToken{}
-	result := repo.db.Where("user_id = ?", userId).First(&tokenObject)
+func (repo *repoTokenPrivate) Rotate(tokenID uint, next model.TokenCreationParam) (model.AuthTokenModel, error) {
+	nextToken := AuthToken{
+		TokenHash: next.TokenHash,
+		UserID:    next.UserID,
+		SessionID: next.SessionID,
+		Expiry:    next.Expiry,
+	}
+
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		// Only one of two concurrent refreshes with the same token can mark it as used
+		result := tx.Model(&AuthToken{}).Where("id = ? AND used_at IS NULL", tokenID).Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return model.ErrRefreshTokenAlreadyUsed
+		}
+
+		if err := tx.Create(&nextToken).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&AuthSession{}).Where("id = ?", next.SessionID).Update("last_used_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &nextToken, nil
+}
+
+func (repo *repoTokenPrivate) CreateSession(args model.SessionCreationParam) (model.AuthSessionModel, error) {
+	session := AuthSession{
+		UserID:     args.UserID,
+		DeviceName: args.DeviceName,
+		IPAddress:  args.IPAddress,
+		LastUsedAt: time.Now(),
+		TwoFactor:  args.TwoFactor,
+	}
+
+	if err := repo.db.Create(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (repo *repoTokenPrivate) GetSession(sessionID uint) (model.AuthSessionModel, error) {
+	var session AuthSession
+	result := repo.db.Where("id = ?", sessionID).Find(&session)
 	if result.Error != nil {
 		return nil, result.Error
 	}
-	return &tokenObject, result.Error
+	if session.ID == 0 {
+		return nil, nil
+	}
+	return &session, nil
 }
 
-func (repo *repoTokenPrivate) Delete(recordId uint) error {
-	return repo.db.Delete(&AuthToken{}, recordId).Error
+// GetActiveSessions returns the sessions which still have a refresh token to use
+func (repo *repoTokenPrivate) GetActiveSessions(userID uint) ([]model.AuthSessionModel, error) {
+	var sessions []AuthSession
+	result := repo.db.
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Where("EXISTS (SELECT 1 FROM auth_tokens WHERE auth_tokens.session_id = auth_sessions.id AND auth_tokens.used_at IS NULL AND auth_tokens.expiry > ? AND auth_tokens.deleted_at IS NULL)", time.Now().Unix()).
+		Order("last_used_at desc").
+		Find(&sessions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	var models []model.AuthSessionModel
+	for _, session := range sessions {
+		models = append(models, &session)
+	}
+	return models, nil
+}
+
+func (repo *repoTokenPrivate) RevokeSession(sessionID uint) error {
+	return repo.revokeSessions(repo.db.Where("id = ?", sessionID))
+}
+
+func (repo *repoTokenPrivate) RevokeUserSessions(userID uint) error {
+	return repo.revokeSessions(repo.db.Where("user_id = ?", userID))
+}
+
+func (repo *repoTokenPrivate) RevokeOtherSessions(userID uint, keptSessionID uint) error {
+	return repo.revokeSessions(repo.db.Where("user_id = ? AND id <> ?", userID, keptSessionID))
+}
+
+func (repo *repoTokenPrivate) MarkSessionTwoFactor(sessionID uint) error {
+	return repo.db.Model(&AuthSession{}).Where("id = ?", sessionID).Update("two_factor", true).Error
+}
+
+// revokeSessions revokes the sessions matched by query and deletes their refresh tokens
+func (repo *repoTokenPrivate) revokeSessions(query *gorm.DB) error {
+	var sessionIds []uint
+	if err := query.Model(&AuthSession{}).Where("revoked_at IS NULL").Pluck("id", &sessionIds).Error; err != nil {
+		return err
+	}
+	if len(sessionIds) == 0 {
+		return nil
+	}
+
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&AuthSession{}).Where("id IN ?", sessionIds).Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Where("session_id IN ?", sessionIds).Delete(&AuthToken{}).Error
+	})
 }
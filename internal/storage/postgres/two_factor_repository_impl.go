@@ -0,0 +1,192 @@
+package postgres
+
+import (
+	"errors"
+	"go-api/pkg/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// TwoFactor holds the TOTP secret of a user, it is pending until EnabledAt is set
+type TwoFactor struct {
+	gorm.Model
+	UserID       uint   `gorm:"uniqueIndex;not null"`
+	Secret       string `gorm:"not null"`
+	EnabledAt    *time.Time
+	LastUsedStep int64
+}
+
+func (t *TwoFactor) GetUserID() uint {
+	return t.UserID
+}
+
+func (t *TwoFactor) GetSecret() string {
+	return t.Secret
+}
+
+func (t *TwoFactor) IsEnabled() bool {
+	return t.EnabledAt != nil
+}
+
+var _ model.TwoFactorModel = (*TwoFactor)(nil)
+
+// RecoveryCode is a single use code replacing the authenticator app, only its argon2 hash is stored
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint   `gorm:"index;not null"`
+	CodeHash string `gorm:"not null"`
+	UsedAt   *time.Time
+}
+
+func (r *RecoveryCode) GetID() uint {
+	return r.ID
+}
+
+func (r *RecoveryCode) GetCodeHash() string {
+	return r.CodeHash
+}
+
+var _ model.RecoveryCodeModel = (*RecoveryCode)(nil)
+
+// UsedTwoFactorToken is the ID of a two-factor token already exchanged, kept until the token expires
+type UsedTwoFactorToken struct {
+	TokenID   string    `gorm:"primaryKey"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+}
+
+type repoTwoFactorPrivate struct {
+	db *gorm.DB
+}
+
+var _ model.TwoFactorRepository = (*repoTwoFactorPrivate)(nil)
+
+func NewTwoFactorRepo(db *gorm.DB) model.TwoFactorRepository {
+	return &repoTwoFactorPrivate{db: db}
+}
+
+func (r *repoTwoFactorPrivate) Get(userID uint) (model.TwoFactorModel, error) {
+	twoFactor := TwoFactor{}
+	err := r.db.Where("user_id = ?", userID).First(&twoFactor).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &twoFactor, nil
+}
+
+func (r *repoTwoFactorPrivate) SavePending(userID uint, secret string) (model.TwoFactorModel, error) {
+	twoFactor := TwoFactor{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ?", userID).First(&twoFactor).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			twoFactor = TwoFactor{UserID: userID, Secret: secret}
+			return tx.Create(&twoFactor).Error
+		}
+		if err != nil {
+			return err
+		}
+		if twoFactor.IsEnabled() {
+			return model.ErrTwoFactorAlreadyEnabled
+		}
+
+		twoFactor.Secret = secret
+		return tx.Save(&twoFactor).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &twoFactor, nil
+}
+
+func (r *repoTwoFactorPrivate) Enable(userID uint, step int64, recoveryCodeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&TwoFactor{}).
+			Where("user_id = ? AND enabled_at IS NULL", userID).
+			Updates(map[string]interface{}{"enabled_at": time.Now(), "last_used_step": step})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return model.ErrTwoFactorAlreadyEnabled
+		}
+		return replaceRecoveryCodes(tx, userID, recoveryCodeHashes)
+	})
+}
+
+func (r *repoTwoFactorPrivate) Disable(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", userID).Delete(&TwoFactor{}).Error
+	})
+}
+
+func (r *repoTwoFactorPrivate) UseStep(userID uint, step int64) (bool, error) {
+	result := r.db.Model(&TwoFactor{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repoTwoFactorPrivate) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, codeHashes []string) error {
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+		return err
+	}
+
+	codes := make([]RecoveryCode, 0, len(codeHashes))
+	for _, codeHash := range codeHashes {
+		codes = append(codes, RecoveryCode{UserID: userID, CodeHash: codeHash})
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
+
+func (r *repoTwoFactorPrivate) GetUnusedRecoveryCodes(userID uint) ([]model.RecoveryCodeModel, error) {
+	var codes []RecoveryCode
+	if err := r.db.Where("user_id = ? AND used_at IS NULL", userID).Find(&codes).Error; err != nil {
+		return nil, err
+	}
+
+	models := make([]model.RecoveryCodeModel, 0, len(codes))
+	for i := range codes {
+		models = append(models, &codes[i])
+	}
+	return models, nil
+}
+
+func (r *repoTwoFactorPrivate) UseRecoveryCode(id uint) (bool, error) {
+	result := r.db.Model(&RecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repoTwoFactorPrivate) UseToken(tokenID string, expiresAt time.Time) (bool, error) {
+	if err := r.db.Where("expires_at < ?", time.Now()).Delete(&UsedTwoFactorToken{}).Error; err != nil {
+		return false, err
+	}
+
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&UsedTwoFactorToken{TokenID: tokenID, ExpiresAt: expiresAt})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
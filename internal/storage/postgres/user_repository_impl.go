@@ -19,7 +19,6 @@ type User struct {
 	Username    string `gorm:"unique;not null"`
 	Bio         string `gorm:"size:1000"`
 	Avatar      string
-	VerifyToken string
 	Status      int
 	IsPrivate   bool `gorm:"default:false"`
 	Role        string
@@ -93,7 +92,8 @@ func (repo *repoUserPrivate) Create(args model.UserCreationParam) (model.UserMod
 		Password: hashedPassword,
 		Username: args.Username,
 		Role:     args.Role,
-		Status:   1,
+		// The account is activated once the email address is verified
+		Status: 0,
 	}
 
 	result := repo.db.Create(&userObject)
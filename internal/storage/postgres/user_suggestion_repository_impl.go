@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"go-api/pkg/model"
+	"gorm.io/gorm"
+)
+
+type repoUserSuggestionPrivate struct {
+	db *gorm.DB
+}
+
+func NewUserSuggestionRepo(db *gorm.DB) model.UserSuggestionRepository {
+	return &repoUserSuggestionPrivate{db: db}
+}
+
+// Each signal lists candidates on its own, they are then summed per user
+const userSuggestionSignalsQuery = `
+WITH signals AS (
+	SELECT friend_follow.followed_id AS user_id, COUNT(DISTINCT my_follow.followed_id) AS mutual_follows, 0 AS shared_groups, 0 AS shared_contents
+	FROM follows AS my_follow
+	JOIN follows AS friend_follow ON friend_follow.follower_id = my_follow.followed_id AND friend_follow.status = @accepted AND friend_follow.deleted_at IS NULL
+	WHERE my_follow.follower_id = @userId AND my_follow.status = @accepted AND my_follow.deleted_at IS NULL
+	GROUP BY friend_follow.followed_id
+	UNION ALL
+	SELECT other_member.member_id, 0, COUNT(DISTINCT other_member.group_id), 0
+	FROM group_members AS my_membership
+	JOIN group_members AS other_member ON other_member.group_id = my_membership.group_id AND other_member.status = @activeMember AND other_member.deleted_at IS NULL
+	WHERE my_membership.member_id = @userId AND my_membership.status = @activeMember AND my_membership.deleted_at IS NULL
+	GROUP BY other_member.member_id
+	UNION ALL
+	SELECT other_drop.created_by_id, 0, 0, COUNT(DISTINCT other_drop.content)
+	FROM drops AS my_drop
+	JOIN drops AS other_drop ON other_drop.type = my_drop.type AND other_drop.content = my_drop.content AND other_drop.status = @activeDrop AND other_drop.deleted_at IS NULL
+	WHERE my_drop.created_by_id = @userId AND my_drop.status = @activeDrop AND my_drop.deleted_at IS NULL
+	GROUP BY other_drop.created_by_id
+)
+SELECT signals.user_id,
+	SUM(signals.mutual_follows) AS mutual_follows,
+	SUM(signals.shared_groups) AS shared_groups,
+	SUM(signals.shared_contents) AS shared_contents
+FROM signals
+JOIN users ON users.id = signals.user_id AND users.status = @activeUser AND users.deleted_at IS NULL
+WHERE signals.user_id <> @userId
+	AND NOT EXISTS (
+		SELECT 1 FROM follows
+		WHERE follows.follower_id = @userId AND follows.followed_id = signals.user_id AND follows.deleted_at IS NULL
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM blocks
+		WHERE blocks.deleted_at IS NULL
+			AND ((blocks.blocker_id = @userId AND blocks.blocked_id = signals.user_id) OR (blocks.blocker_id = signals.user_id AND blocks.blocked_id = @userId))
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM mutes
+		WHERE mutes.muter_id = @userId AND mutes.muted_id = signals.user_id AND mutes.deleted_at IS NULL
+	)
+	AND NOT (users.is_private AND EXISTS (
+		SELECT 1 FROM follows
+		WHERE follows.follower_id = @userId AND follows.followed_id = signals.user_id AND follows.status = @rejected
+	))
+GROUP BY signals.user_id
+ORDER BY SUM(signals.mutual_follows) * @mutualWeight + SUM(signals.shared_groups) * @groupWeight + SUM(signals.shared_contents) * @contentWeight DESC, signals.user_id DESC
+LIMIT @limit`
+
+func (r *repoUserSuggestionPrivate) GetSuggestionSignals(userId uint, weights model.UserSuggestionWeights, limit int) ([]model.UserSuggestionSignals, error) {
+	var signals []model.UserSuggestionSignals
+	err := r.db.Raw(userSuggestionSignalsQuery, map[string]interface{}{
+		"userId":        userId,
+		"accepted":      new(FollowAcceptedStatus).ToInt(),
+		"rejected":      new(FollowRejectedStatus).ToInt(),
+		"activeMember":  new(GroupMemberStatusActive).ToIntGroupMemberStatus(),
+		"activeDrop":    new(DropStatusActive).ToInt(),
+		"activeUser":    1,
+		"mutualWeight":  weights.MutualFollows,
+		"groupWeight":   weights.SharedGroups,
+		"contentWeight": weights.SharedContents,
+		"limit":         limit,
+	}).Scan(&signals).Error
+	if err != nil {
+		return nil, err
+	}
+	return signals, nil
+}
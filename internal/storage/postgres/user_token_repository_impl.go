@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"go-api/pkg/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// UserToken is a single use token sent by email, only its hash is stored
+type UserToken struct {
+	gorm.Model
+	UserID    uint   `gorm:"index"`
+	Purpose   string `gorm:"not null"`
+	TokenHash string `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+func (t *UserToken) GetID() uint {
+	return t.ID
+}
+
+func (t *UserToken) GetUserID() uint {
+	return t.UserID
+}
+
+func (t *UserToken) GetPurpose() model.UserTokenPurpose {
+	return model.UserTokenPurpose(t.Purpose)
+}
+
+func (t *UserToken) GetExpiresAt() int {
+	return int(t.ExpiresAt.Unix())
+}
+
+var _ model.UserTokenModel = (*UserToken)(nil)
+
+type repoUserTokenPrivate struct {
+	db *gorm.DB
+}
+
+var _ model.UserTokenRepository = (*repoUserTokenPrivate)(nil)
+
+func NewUserTokenRepo(db *gorm.DB) model.UserTokenRepository {
+	return &repoUserTokenPrivate{db: db}
+}
+
+func (r *repoUserTokenPrivate) Create(userID uint, purpose model.UserTokenPurpose, tokenHash string, expiresAt time.Time) (model.UserTokenModel, error) {
+	token := UserToken{
+		UserID:    userID,
+		Purpose:   string(purpose),
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, string(purpose)).Delete(&UserToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&token).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r *repoUserTokenPrivate) Consume(purpose model.UserTokenPurpose, tokenHash string) (model.UserTokenModel, error) {
+	var tokens []UserToken
+	// Updating with RETURNING makes sure a token is consumed only once, even by concurrent requests
+	result := r.db.Model(&tokens).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", tokenHash, string(purpose), time.Now()).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	return &tokens[0], nil
+}
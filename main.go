@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -9,10 +10,18 @@ import (
 	_ "go-api/docs"
 	"go-api/internal/http/controllers"
 	"go-api/internal/http/middlewares"
+	"go-api/internal/realtime"
+	"go-api/internal/repositories"
+	dropschedulerservice "go-api/internal/services/drop_scheduler"
+	followservice "go-api/internal/services/follow"
+	loginthrottle "go-api/internal/services/login_throttle"
 	"go-api/internal/storage/postgres"
 	"go-api/pkg/environment"
+	"go-api/pkg/mailer"
 	"log"
 	"os"
+	"strings"
+	"time"
 )
 
 // @title Droppy API
@@ -46,7 +55,15 @@ func main() {
 	log.Println("Info: ENV is: " + environment.GetEnv())
 	postgres.Init()
 	postgres.AutoMigrate()
+	controllers.RealtimeHub = realtime.NewHub(realtime.NewBrokerFromEnv(), realtime.NewHubConfigFromEnv())
+	controllers.Mailer = mailer.NewMailerFromEnv()
+	controllers.LoginThrottle = loginthrottle.NewLoginThrottleFromEnv()
 	r := gin.Default()
+	// X-Forwarded-For is only read from TRUSTED_PROXIES, otherwise clients could pick the IP address the login throttle sees
+	if err := r.SetTrustedProxies(trustedProxiesFromEnv()); err != nil {
+		log.Printf("Error: Invalid TRUSTED_PROXIES %q, trusting no proxy: %v\n", os.Getenv("TRUSTED_PROXIES"), err)
+		_ = r.SetTrustedProxies(nil)
+	}
 	config := cors.DefaultConfig()
 	config.AddAllowHeaders("Authorization")
 	config.AllowCredentials = true
@@ -67,6 +84,21 @@ func main() {
 			auth.POST("/", controllers.Login)
 			auth.POST("", controllers.Login)
 			auth.POST("/oauth_token", controllers.FirebaseLogin)
+			auth.POST("/verify-email", controllers.VerifyEmail)
+			auth.POST("/verify-email/resend", controllers.ResendEmailVerification)
+			auth.POST("/password-reset", controllers.RequestPasswordReset)
+			auth.POST("/password-reset/confirm", controllers.ResetPassword)
+			auth.PUT("/password", middlewares.CurrentUserMiddleware(true), controllers.ChangePassword)
+			auth.POST("/logout", middlewares.CurrentUserMiddleware(true), controllers.Logout)
+			auth.GET("/sessions", middlewares.CurrentUserMiddleware(true), controllers.GetSessions)
+			auth.DELETE("/sessions", middlewares.CurrentUserMiddleware(true), controllers.RevokeAllSessions)
+			auth.DELETE("/sessions/:id", middlewares.CurrentUserMiddleware(true), controllers.RevokeSession)
+			auth.POST("/2fa", controllers.LoginWithTwoFactor)
+			auth.POST("/2fa/setup", middlewares.CurrentUserMiddleware(true), controllers.SetupTwoFactor)
+			auth.POST("/2fa/enable", middlewares.CurrentUserMiddleware(true), controllers.EnableTwoFactor)
+			auth.POST("/2fa/disable", middlewares.CurrentUserMiddleware(true), controllers.DisableTwoFactor)
+			auth.POST("/2fa/recovery-codes", middlewares.CurrentUserMiddleware(true), controllers.RegenerateRecoveryCodes)
+			auth.POST("/stream-ticket", middlewares.CurrentUserMiddleware(true), controllers.CreateStreamTicket)
 		}
 
 		user := v1.Group("/users")
@@ -74,10 +106,24 @@ func main() {
 			user.GET("/:id", middlewares.CurrentUserMiddleware(true), controllers.GetUserById)
 			user.POST("/", controllers.Create)
 			user.POST("", controllers.Create)
-			user.GET("/search", controllers.SearchUsers)
+			user.GET("/search", middlewares.CurrentUserMiddleware(false), controllers.SearchUsers)
+			user.GET("/suggestions", middlewares.CurrentUserMiddleware(true), controllers.GetUserSuggestions)
+			user.GET("/blocked", middlewares.CurrentUserMiddleware(true), controllers.GetBlockedUsers)
+			user.GET("/muted", middlewares.CurrentUserMiddleware(true), controllers.GetMutedUsers)
+			user.GET("/close-friends", middlewares.CurrentUserMiddleware(true), controllers.GetCloseFriends)
+			user.POST("/close-friends", middlewares.CurrentUserMiddleware(true), controllers.AddCloseFriend)
+			user.DELETE("/close-friends/:id", middlewares.CurrentUserMiddleware(true), controllers.RemoveCloseFriend)
+			user.DELETE("/followers/:id", middlewares.CurrentUserMiddleware(true), controllers.RemoveFollower)
+			user.POST("/followers/remove", middlewares.CurrentUserMiddleware(true), controllers.RemoveFollowers)
+			user.POST("/:id/block", middlewares.CurrentUserMiddleware(true), controllers.BlockUser)
+			user.DELETE("/:id/block", middlewares.CurrentUserMiddleware(true), controllers.UnblockUser)
+			user.POST("/:id/mute", middlewares.CurrentUserMiddleware(true), controllers.MuteUser)
+			user.DELETE("/:id/mute", middlewares.CurrentUserMiddleware(true), controllers.UnmuteUser)
 			user.PATCH("/:id", middlewares.CurrentUserMiddleware(true), controllers.PatchUserById)
 			user.GET("/my-feed/ws", middlewares.CurrentUserMiddleware(true), controllers.GetCurrentUserFeedWS)
+			user.GET("/my-feed/sse", middlewares.StreamAuthMiddleware(), controllers.GetCurrentUserFeedSSE)
 			user.GET("/my-feed", middlewares.CurrentUserMiddleware(true), controllers.GetCurrentUserFeed)
+			user.GET("/my-feed/history", middlewares.CurrentUserMiddleware(true), controllers.GetCurrentUserFeedHistory)
 			user.GET("/:id/drops", middlewares.CurrentUserMiddleware(true), controllers.DropsByUserId)
 
 			user.GET("/:id/following", middlewares.CurrentUserMiddleware(true), controllers.GetUserFollowing)
@@ -88,8 +134,13 @@ func main() {
 		{
 			follow.POST("/", middlewares.CurrentUserMiddleware(true), controllers.FollowUser)
 			follow.GET("/pending", middlewares.CurrentUserMiddleware(true), controllers.GetMyPendingRequestsWS)
+			follow.GET("/pending/sse", middlewares.StreamAuthMiddleware(), controllers.GetMyPendingRequestsSSE)
 			follow.POST("/accept/:id", middlewares.CurrentUserMiddleware(true), controllers.AcceptRequest)
 			follow.POST("/reject/:id", middlewares.CurrentUserMiddleware(true), controllers.RejectRequest)
+			follow.GET("/sent", middlewares.CurrentUserMiddleware(true), controllers.GetMySentRequests)
+			follow.DELETE("/sent/:id", middlewares.CurrentUserMiddleware(true), controllers.CancelRequest)
+			follow.GET("/events", middlewares.CurrentUserMiddleware(true), controllers.GetFollowRequestEventsWS)
+			follow.GET("/events/sse", middlewares.StreamAuthMiddleware(), controllers.GetFollowRequestEventsSSE)
 			follow.DELETE("/:id", middlewares.CurrentUserMiddleware(true), controllers.DeleteFollow)
 		}
 
@@ -97,6 +148,8 @@ func main() {
 		{
 			drop.POST("/", middlewares.CurrentUserMiddleware(true), controllers.CreateDrop)
 			drop.GET("/has-user-dropped", middlewares.CurrentUserMiddleware(true), controllers.HasUserDroppedTodayWS)
+			drop.GET("/has-user-dropped/sse", middlewares.StreamAuthMiddleware(), controllers.HasUserDroppedTodaySSE)
+			drop.GET("/discover", middlewares.CurrentUserMiddleware(true), controllers.GetDiscoverFeed)
 			drop.GET("/:id", middlewares.CurrentUserMiddleware(true), controllers.GetOneDrop)
 			drop.PATCH("/:id", middlewares.CurrentUserMiddleware(true), controllers.PatchDrop)
 			drop.DELETE("/:id", middlewares.CurrentUserMiddleware(true), controllers.DeleteDrop)
@@ -166,7 +219,12 @@ func main() {
 			admin.GET("/reports", middlewares.AdminRequired(), controllers.GetAllReports)
 			admin.PUT("/reports/:id", middlewares.AdminRequired(), controllers.AdminManageReport)
 			admin.POST("/drops/schedule", middlewares.AdminRequired(), controllers.AdminScheduleDrop)
+			admin.GET("/drops/types", middlewares.AdminRequired(), controllers.AdminGetDropTypes)
+			admin.GET("/drops/scheduled", middlewares.AdminRequired(), controllers.AdminGetScheduledDrops)
+			admin.PATCH("/drops/scheduled/:id", middlewares.AdminRequired(), controllers.AdminRescheduleDrop)
+			admin.DELETE("/drops/scheduled/:id", middlewares.AdminRequired(), controllers.AdminCancelScheduledDrop)
 			admin.POST("/drops/send-now", middlewares.AdminRequired(), controllers.AdminSendDropNow)
+			admin.GET("/login-lockouts", middlewares.AdminRequired(), controllers.AdminGetLoginLockouts)
 			admin.GET("/logs", middlewares.AdminRequired(), func(c *gin.Context) {
 				c.Writer.Header().Set("Content-Disposition", "attachment; filename=app.log")
 				c.Writer.Header().Set("Content-Type", "application/octet-stream")
@@ -180,15 +238,19 @@ func main() {
 
 	r.Static("/assets", "./assets")
 
-	/*c := cron.New()
-	_, err = c.AddFunc("0 0 * * *", drop_notif.GenerateRandomNotification)
+	dropScheduler := dropschedulerservice.NewDropSchedulerService(
+		repositories.Setup(),
+		dropschedulerservice.NewDropSchedulerConfigFromEnv(),
+		controllers.BroadcastDropNotification,
+	)
+	go dropScheduler.Run(context.Background())
 
-	if err != nil {
-		log2.Errorf("Error adding cron job: %v", err)
+	followRequestExpiry := &followservice.FollowService{
+		Repo:                repositories.Setup(),
+		OnRequestTransition: controllers.PublishFollowRequestTransition,
+		RequestExpiry:       followservice.FollowRequestExpiryFromEnv(),
 	}
-
-	c.Start()
-	fmt.Println("Scheduler started...")*/
+	go followRequestExpiry.RunRequestExpiry(context.Background(), time.Hour)
 
 	err = r.Run(":3000")
 
@@ -196,3 +258,14 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// trustedProxiesFromEnv reads the comma separated IP addresses or CIDRs of TRUSTED_PROXIES, none by default
+func trustedProxiesFromEnv() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
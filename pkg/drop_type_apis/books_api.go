@@ -0,0 +1,193 @@
+package drop_type_apis
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	_ DropTypeAPI = &BooksAPI{}
+)
+
+func init() {
+	Register(Provider{
+		Type:        BookType,
+		DisplayName: "Livres",
+		New:         func() DropTypeAPI { return &BooksAPI{} },
+	})
+}
+
+const (
+	openLibraryBaseURL   = "https://openlibrary.org"
+	openLibraryCoversURL = "https://covers.openlibrary.org"
+
+	maxResolvedBookAuthors = 3
+	maxResolvedBookGenres  = 5
+)
+
+var openLibraryWorkIDRegexp = regexp.MustCompile(`^OL\d+W$`)
+
+// BooksAPI searches the Open Library catalog.
+// HTTPClient, BaseURL and CoversURL can be set before Init to target another server.
+type BooksAPI struct {
+	HTTPClient *http.Client
+	BaseURL    string
+	CoversURL  string
+}
+
+func (b *BooksAPI) Search(search string) ([]ApiSearchResponse, error) {
+	query := url.Values{}
+	query.Set("q", search)
+	query.Set("limit", "20")
+	query.Set("fields", "key,title,author_name,cover_i,first_publish_year")
+
+	resp, err := b.HTTPClient.Get(b.BaseURL + "/search.json?" + query.Encode())
+	if err != nil {
+		log.Println("Error: Error trying to get books from Open Library API:", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponseStatus(resp); err != nil {
+		log.Printf("Error: Open Library API answered with status %d\n", resp.StatusCode)
+		return nil, err
+	}
+
+	var result OpenLibrarySearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Docs) == 0 {
+		return nil, nil
+	}
+
+	var results []ApiSearchResponse
+	for _, item := range result.Docs {
+		workID := strings.TrimPrefix(item.Key, "/works/")
+		if workID == "" {
+			continue
+		}
+
+		var picturePath string
+		if item.CoverID != 0 {
+			picturePath = fmt.Sprintf("%s/b/id/%d-L.jpg", b.CoversURL, item.CoverID)
+		}
+
+		results = append(results, ApiSearchResponse{
+			Search:      search,
+			PicturePath: picturePath,
+			Title:       item.Title,
+			Subtitle:    strings.Join(item.AuthorNames, ", "),
+			Content:     workID,
+		})
+	}
+
+	return results, nil
+}
+
+// Resolve fetches the Open Library work whose ID was returned by Search, along with its first authors
+func (b *BooksAPI) Resolve(content string, title string) (ContentMetadata, error) {
+	if !openLibraryWorkIDRegexp.MatchString(content) {
+		return ContentMetadata{}, ErrContentNotFound
+	}
+
+	var work OpenLibraryWorkResponse
+	if err := b.getJSON("/works/"+content+".json", &work); err != nil {
+		return ContentMetadata{}, err
+	}
+
+	var authorNames []string
+	for _, author := range work.Authors {
+		if len(authorNames) == maxResolvedBookAuthors {
+			break
+		}
+
+		var authorResponse OpenLibraryAuthorResponse
+		if err := b.getJSON(author.Author.Key+".json", &authorResponse); err != nil {
+			log.Printf("Error: Error getting author %s from Open Library API: %v\n", author.Author.Key, err)
+			continue
+		}
+		authorNames = append(authorNames, authorResponse.Name)
+	}
+
+	var picturePath string
+	if len(work.Covers) > 0 && work.Covers[0] > 0 {
+		picturePath = fmt.Sprintf("%s/b/id/%d-L.jpg", b.CoversURL, work.Covers[0])
+	}
+
+	genres := work.Subjects
+	if len(genres) > maxResolvedBookGenres {
+		genres = genres[:maxResolvedBookGenres]
+	}
+
+	return ContentMetadata{
+		Content:     content,
+		Title:       work.Title,
+		Subtitle:    strings.Join(authorNames, ", "),
+		PicturePath: picturePath,
+		ReleaseYear: releaseYear(work.FirstPublishDate),
+		Genres:      genres,
+		Link:        openLibraryBaseURL + "/works/" + content,
+	}, nil
+}
+
+func (b *BooksAPI) getJSON(path string, target interface{}) error {
+	resp, err := b.HTTPClient.Get(b.BaseURL + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponseStatus(resp); err != nil {
+		return err
+	}
+
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+func (b *BooksAPI) Init(config ProviderConfig) error {
+	if b.HTTPClient == nil {
+		b.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if b.BaseURL == "" {
+		b.BaseURL = openLibraryBaseURL
+	}
+	if b.CoversURL == "" {
+		b.CoversURL = openLibraryCoversURL
+	}
+	return nil
+}
+
+type OpenLibrarySearchResponse struct {
+	NumFound int `json:"numFound"`
+	Docs     []struct {
+		Key              string   `json:"key"`
+		Title            string   `json:"title"`
+		AuthorNames      []string `json:"author_name"`
+		CoverID          int      `json:"cover_i"`
+		FirstPublishYear int      `json:"first_publish_year"`
+	} `json:"docs"`
+}
+
+type OpenLibraryWorkResponse struct {
+	Title            string   `json:"title"`
+	Covers           []int    `json:"covers"`
+	Subjects         []string `json:"subjects"`
+	FirstPublishDate string   `json:"first_publish_date"`
+	Authors          []struct {
+		Author struct {
+			Key string `json:"key"`
+		} `json:"author"`
+	} `json:"authors"`
+}
+
+type OpenLibraryAuthorResponse struct {
+	Name string `json:"name"`
+}
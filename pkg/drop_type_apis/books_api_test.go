@@ -0,0 +1,121 @@
+package drop_type_apis
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func newOpenLibraryFixtureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search.json", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("q"); got != "tolkien" {
+			t.Errorf("search query = %q, want %q", got, "tolkien")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeFile(w, r, "testdata/open_library_search.json")
+	})
+	mux.HandleFunc("/works/OL27448W.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeFile(w, r, "testdata/open_library_work.json")
+	})
+	mux.HandleFunc("/authors/OL26320A.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name": "J.R.R. Tolkien"}`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBooksAPI_Search(t *testing.T) {
+	server := newOpenLibraryFixtureServer(t)
+
+	api := &BooksAPI{
+		HTTPClient: server.Client(),
+		BaseURL:    server.URL,
+		CoversURL:  "https://covers.example.com",
+	}
+	api.Init(ProviderConfig{})
+
+	want := []ApiSearchResponse{
+		{
+			Search:      "tolkien",
+			PicturePath: "https://covers.example.com/b/id/14625765-L.jpg",
+			Title:       "The Lord of the Rings",
+			Subtitle:    "J.R.R. Tolkien",
+			Content:     "OL27448W",
+		},
+		{
+			Search:      "tolkien",
+			PicturePath: "",
+			Title:       "Good Omens",
+			Subtitle:    "Terry Pratchett, Neil Gaiman",
+			Content:     "OL15358691W",
+		},
+	}
+
+	got, err := api.Search("tolkien")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() = %+v, want %+v", got, want)
+	}
+}
+
+func TestBooksAPI_Resolve(t *testing.T) {
+	server := newOpenLibraryFixtureServer(t)
+
+	api := &BooksAPI{
+		HTTPClient: server.Client(),
+		BaseURL:    server.URL,
+		CoversURL:  "https://covers.example.com",
+	}
+	api.Init(ProviderConfig{})
+
+	want := ContentMetadata{
+		Content:     "OL27448W",
+		Title:       "The Lord of the Rings",
+		Subtitle:    "J.R.R. Tolkien",
+		PicturePath: "https://covers.example.com/b/id/14625765-L.jpg",
+		ReleaseYear: 1954,
+		Genres:      []string{"Fantasy", "Fiction", "Middle Earth (Imaginary place)", "Quests (Expeditions)", "Wizards"},
+		Link:        "https://openlibrary.org/works/OL27448W",
+	}
+
+	got, err := api.Resolve("OL27448W", "The Lord of the Rings")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resolve() = %+v, want %+v", got, want)
+	}
+
+	if _, err := api.Resolve("OL404W", "Unknown"); !errors.Is(err, ErrContentNotFound) {
+		t.Errorf("Resolve() of an unknown work error = %v, want %v", err, ErrContentNotFound)
+	}
+
+	if _, err := api.Resolve("../authors/OL26320A", ""); !errors.Is(err, ErrContentNotFound) {
+		t.Errorf("Resolve() of an invalid work ID error = %v, want %v", err, ErrContentNotFound)
+	}
+}
+
+func TestBooksAPI_SearchServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	api := &BooksAPI{HTTPClient: server.Client(), BaseURL: server.URL}
+	api.Init(ProviderConfig{})
+
+	if got, err := api.Search("tolkien"); err == nil {
+		t.Errorf("Search() = %+v, want an error", got)
+	}
+}
@@ -0,0 +1,80 @@
+package drop_type_apis
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// searchCache is an in-process LRU of search results whose entries expire after ttl
+type searchCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	capacity int
+	order    *list.List
+	items    map[string]*list.Element
+}
+
+type searchCacheEntry struct {
+	key       string
+	results   []ApiSearchResponse
+	expiresAt time.Time
+}
+
+func newSearchCache(ttl time.Duration, capacity int) *searchCache {
+	return &searchCache{
+		ttl:      ttl,
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (c *searchCache) Get(key string, now time.Time) ([]ApiSearchResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*searchCacheEntry)
+	if !now.Before(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.items, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.results, true
+}
+
+func (c *searchCache) Set(key string, results []ApiSearchResponse, now time.Time) {
+	if c.capacity <= 0 || c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		entry := element.Value.(*searchCacheEntry)
+		entry.results = results
+		entry.expiresAt = now.Add(c.ttl)
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&searchCacheEntry{
+		key:       key,
+		results:   results,
+		expiresAt: now.Add(c.ttl),
+	})
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*searchCacheEntry).key)
+	}
+}
@@ -0,0 +1,37 @@
+package drop_type_apis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSearchCache(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	cache := newSearchCache(time.Minute, 2)
+
+	cache.Set("spotify||daft punk", []ApiSearchResponse{{Title: "Around the World"}}, now)
+	cache.Set("films||dune", []ApiSearchResponse{{Title: "Dune"}}, now)
+
+	if _, ok := cache.Get("spotify||daft punk", now.Add(30*time.Second)); !ok {
+		t.Fatal("Get() should return an entry that has not expired")
+	}
+
+	// "films||dune" is now the least recently used entry
+	cache.Set("books||tolkien", nil, now)
+
+	if _, ok := cache.Get("films||dune", now); ok {
+		t.Error("Get() should not return the least recently used entry once capacity is exceeded")
+	}
+	if _, ok := cache.Get("books||tolkien", now); !ok {
+		t.Error("Get() should return cached empty results")
+	}
+	if _, ok := cache.Get("spotify||daft punk", now.Add(time.Minute)); ok {
+		t.Error("Get() should not return an expired entry")
+	}
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	if got := NormalizeQuery("  Daft   PUNK \t"); got != "Daft PUNK" {
+		t.Errorf("NormalizeQuery() = %q, want %q", got, "Daft PUNK")
+	}
+}
@@ -1,8 +1,22 @@
 package drop_type_apis
 
+import (
+	"strconv"
+	"strings"
+)
+
 type DropTypeAPI interface {
-	Search(search string) []ApiSearchResponse
-	Init()
+	Search(search string) ([]ApiSearchResponse, error)
+	// Resolve looks a content up by the ID returned in ApiSearchResponse.Content.
+	// title is the title picked by the user, for providers which cannot look their IDs up directly.
+	Resolve(content string, title string) (ContentMetadata, error)
+	// Init returns an error wrapping ErrProviderNotConfigured when the credentials are missing, the provider is initialized again later
+	Init(config ProviderConfig) error
+}
+
+// KindSearchAPI is implemented by providers able to restrict a search to one kind of content, e.g. Spotify albums
+type KindSearchAPI interface {
+	SearchKind(search string, kind string) ([]ApiSearchResponse, error)
 }
 
 type ApiSearch interface {
@@ -14,7 +28,9 @@ type ApiSearch interface {
 }
 
 type ApiSearchResponse struct {
-	Search      string
+	Search string
+	// Kind tells apart the results of providers returning several kinds of content
+	Kind        string `json:",omitempty"`
 	PicturePath string
 	Title       string
 	Subtitle    string
@@ -40,3 +56,33 @@ func (a ApiSearchResponse) GetContentPicturePath() string {
 func (a ApiSearchResponse) GetContent() string {
 	return a.Content
 }
+
+// ContentMetadata is the validated description of a content, as known by its provider
+type ContentMetadata struct {
+	Content     string
+	Kind        string
+	Title       string
+	Subtitle    string
+	PicturePath string
+	// Duration is expressed in seconds, 0 when unknown
+	Duration    int
+	ReleaseYear int
+	Genres      []string
+	// Link is the canonical deep link to the content on the provider
+	Link string
+}
+
+// releaseYear reads the year of dates such as "2006", "2006-05-12" or "May 12, 2006", 0 when unknown
+func releaseYear(date string) int {
+	date = strings.TrimSpace(date)
+	if len(date) < 4 {
+		return 0
+	}
+	if year, err := strconv.Atoi(date[:4]); err == nil {
+		return year
+	}
+	if year, err := strconv.Atoi(date[len(date)-4:]); err == nil {
+		return year
+	}
+	return 0
+}
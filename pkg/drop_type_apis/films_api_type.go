@@ -5,40 +5,60 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"os"
+	neturl "net/url"
 	"strconv"
+	"strings"
 )
 
+func init() {
+	Register(Provider{
+		Type:        FilmType,
+		DisplayName: "Films & séries",
+		ConfigKeys:  []string{"TMDB_API_KEY"},
+		New:         func() DropTypeAPI { return &FilmsAPI{} },
+	})
+}
+
 type FilmsAPI struct {
+	ApiKey string
 }
 
-func (f *FilmsAPI) Search(search string) []ApiSearchResponse {
-	apiKey := os.Getenv("TMDB_API_KEY")
+func (f *FilmsAPI) Search(search string) ([]ApiSearchResponse, error) {
+	apiKey := f.ApiKey
 
 	if apiKey == "" {
 		log.Printf("Error: The Movie Database API key not found in environment variable TMDB_API_KEY\n")
-		return nil
+		return nil, ErrProviderNotConfigured
 	}
-	url := fmt.Sprintf("https://api.themoviedb.org/3/search/multi?api_key=%s&query=%s&page=1", apiKey, search)
+	url := fmt.Sprintf("https://api.themoviedb.org/3/search/multi?api_key=%s&query=%s&page=1", apiKey, neturl.QueryEscape(search))
 
 	resp, err := http.Get(url)
 	if err != nil {
 		log.Println("Error: Error trying to get films from tmdb API:", err)
-		return nil
+		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if err := checkResponseStatus(resp); err != nil {
+		return nil, err
+	}
+
 	var result TMDBResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil
+		return nil, err
 	}
 
 	if len(result.Results) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	var results []ApiSearchResponse
 	for _, item := range result.Results {
+		// search/multi also returns people, which cannot be dropped
+		if item.MediaType != "movie" && item.MediaType != "tv" {
+			continue
+		}
+
 		var title string
 		if item.Title != "" {
 			title = item.Title
@@ -59,16 +79,90 @@ func (f *FilmsAPI) Search(search string) []ApiSearchResponse {
 		}
 		results = append(results, ApiSearchResponse{
 			Search:      search,
+			Kind:        item.MediaType,
 			Title:       title,
 			PicturePath: imagePath,
 			Subtitle:    item.Overview,
-			Content:     strconv.Itoa(item.Id),
+			Content:     item.MediaType + "/" + strconv.Itoa(item.Id),
 		})
 	}
-	return results
+	return results, nil
+}
+
+// Resolve accepts the "movie/<id>" or "tv/<id>" returned by Search, as TMDB IDs are only unique per media type
+func (f *FilmsAPI) Resolve(content string, title string) (ContentMetadata, error) {
+	if f.ApiKey == "" {
+		return ContentMetadata{}, ErrProviderNotConfigured
+	}
+
+	mediaType, id, found := strings.Cut(content, "/")
+	if !found || (mediaType != "movie" && mediaType != "tv") {
+		return ContentMetadata{}, ErrContentNotFound
+	}
+	if _, err := strconv.Atoi(id); err != nil {
+		return ContentMetadata{}, ErrContentNotFound
+	}
+
+	return f.getDetails(mediaType, id)
+}
+
+func (f *FilmsAPI) getDetails(mediaType string, id string) (ContentMetadata, error) {
+	url := fmt.Sprintf("https://api.themoviedb.org/3/%s/%s?api_key=%s", mediaType, id, f.ApiKey)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return ContentMetadata{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponseStatus(resp); err != nil {
+		return ContentMetadata{}, err
+	}
+
+	var details TMDBDetailsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil {
+		return ContentMetadata{}, err
+	}
+
+	metadata := ContentMetadata{
+		Content:  mediaType + "/" + id,
+		Kind:     mediaType,
+		Title:    details.Title,
+		Subtitle: details.Overview,
+		Duration: details.Runtime * 60,
+		Link:     fmt.Sprintf("https://www.themoviedb.org/%s/%s", mediaType, id),
+	}
+
+	releaseDate := details.ReleaseDate
+	if mediaType == "tv" {
+		metadata.Title = details.Name
+		releaseDate = details.FirstAirDate
+		if len(details.EpisodeRunTime) > 0 {
+			metadata.Duration = details.EpisodeRunTime[0] * 60
+		}
+	}
+
+	metadata.ReleaseYear = releaseYear(releaseDate)
+
+	if details.BackdropPath != "" {
+		metadata.PicturePath = "https://image.tmdb.org/t/p/w500" + details.BackdropPath
+	} else if details.PosterPath != "" {
+		metadata.PicturePath = "https://image.tmdb.org/t/p/w500" + details.PosterPath
+	}
+
+	for _, genre := range details.Genres {
+		metadata.Genres = append(metadata.Genres, genre.Name)
+	}
+
+	return metadata, nil
 }
 
-func (f *FilmsAPI) Init() {
+func (f *FilmsAPI) Init(config ProviderConfig) error {
+	f.ApiKey = config["TMDB_API_KEY"]
+	if f.ApiKey == "" {
+		return fmt.Errorf("%w: TMDB_API_KEY is missing", ErrProviderNotConfigured)
+	}
+	return nil
 }
 
 type TMDBResponse struct {
@@ -82,5 +176,21 @@ type TMDBResponse struct {
 		BackdropPath  string `json:"backdrop_path"`
 		PosterPath    string `json:"poster_path"`
 		Id            int    `json:"id"`
+		MediaType     string `json:"media_type"`
 	} `json:"results"`
 }
+
+type TMDBDetailsResponse struct {
+	Title          string `json:"title"`
+	Name           string `json:"name"`
+	Overview       string `json:"overview"`
+	ReleaseDate    string `json:"release_date"`
+	FirstAirDate   string `json:"first_air_date"`
+	Runtime        int    `json:"runtime"`
+	EpisodeRunTime []int  `json:"episode_run_time"`
+	BackdropPath   string `json:"backdrop_path"`
+	PosterPath     string `json:"poster_path"`
+	Genres         []struct {
+		Name string `json:"name"`
+	} `json:"genres"`
+}
@@ -0,0 +1,196 @@
+package drop_type_apis
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	_ DropTypeAPI = &GamesAPI{}
+)
+
+func init() {
+	Register(Provider{
+		Type:        GameType,
+		DisplayName: "Jeux vidéo",
+		ConfigKeys:  []string{"RAWG_API_KEY"},
+		New:         func() DropTypeAPI { return &GamesAPI{} },
+	})
+}
+
+const rawgBaseURL = "https://api.rawg.io/api"
+
+// GamesAPI searches the RAWG video game database, the content is the RAWG game ID
+type GamesAPI struct {
+	HTTPClient *http.Client
+	BaseURL    string
+	ApiKey     string
+}
+
+func (g *GamesAPI) Search(search string) ([]ApiSearchResponse, error) {
+	if g.ApiKey == "" {
+		log.Printf("Error: RAWG API key not found in environment variable RAWG_API_KEY\n")
+		return nil, ErrProviderNotConfigured
+	}
+
+	query := url.Values{}
+	query.Set("key", g.ApiKey)
+	query.Set("search", search)
+	query.Set("page_size", "20")
+
+	resp, err := g.HTTPClient.Get(g.BaseURL + "/games?" + query.Encode())
+	if err != nil {
+		log.Println("Error: Error trying to get games from RAWG API:", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponseStatus(resp); err != nil {
+		log.Printf("Error: RAWG API answered with status %d\n", resp.StatusCode)
+		return nil, err
+	}
+
+	var result RAWGSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Results) == 0 {
+		return nil, nil
+	}
+
+	var results []ApiSearchResponse
+	for _, item := range result.Results {
+		var platforms []string
+		for _, platform := range item.Platforms {
+			platforms = append(platforms, platform.Platform.Name)
+		}
+
+		results = append(results, ApiSearchResponse{
+			Search:      search,
+			PicturePath: item.BackgroundImage,
+			Title:       item.Name,
+			Subtitle:    gameSubtitle(platforms, item.Released),
+			Content:     strconv.Itoa(item.Id),
+		})
+	}
+
+	return results, nil
+}
+
+// Resolve fetches the RAWG game whose ID was returned by Search
+func (g *GamesAPI) Resolve(content string, title string) (ContentMetadata, error) {
+	if g.ApiKey == "" {
+		return ContentMetadata{}, ErrProviderNotConfigured
+	}
+
+	if _, err := strconv.Atoi(content); err != nil {
+		return ContentMetadata{}, ErrContentNotFound
+	}
+
+	query := url.Values{}
+	query.Set("key", g.ApiKey)
+
+	resp, err := g.HTTPClient.Get(g.BaseURL + "/games/" + content + "?" + query.Encode())
+	if err != nil {
+		log.Println("Error: Error trying to get game from RAWG API:", err)
+		return ContentMetadata{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponseStatus(resp); err != nil {
+		return ContentMetadata{}, err
+	}
+
+	var game RAWGGameResponse
+	if err := json.NewDecoder(resp.Body).Decode(&game); err != nil {
+		return ContentMetadata{}, err
+	}
+
+	var platforms []string
+	for _, platform := range game.Platforms {
+		platforms = append(platforms, platform.Platform.Name)
+	}
+
+	var genres []string
+	for _, genre := range game.Genres {
+		genres = append(genres, genre.Name)
+	}
+
+	return ContentMetadata{
+		Content:     strconv.Itoa(game.Id),
+		Title:       game.Name,
+		Subtitle:    gameSubtitle(platforms, game.Released),
+		PicturePath: game.BackgroundImage,
+		// RAWG only gives an average playtime in hours
+		Duration:    game.Playtime * 3600,
+		ReleaseYear: releaseYear(game.Released),
+		Genres:      genres,
+		Link:        "https://rawg.io/games/" + game.Slug,
+	}, nil
+}
+
+func (g *GamesAPI) Init(config ProviderConfig) error {
+	if g.HTTPClient == nil {
+		g.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if g.BaseURL == "" {
+		g.BaseURL = rawgBaseURL
+	}
+	if g.ApiKey == "" {
+		g.ApiKey = config["RAWG_API_KEY"]
+	}
+	return nil
+}
+
+// gameSubtitle formats platforms and release year, e.g. "PC, PlayStation 5 · 2020"
+func gameSubtitle(platforms []string, released string) string {
+	subtitle := strings.Join(platforms, ", ")
+
+	if len(released) >= 4 {
+		if subtitle != "" {
+			subtitle += " · "
+		}
+		subtitle += released[:4]
+	}
+
+	return subtitle
+}
+
+type RAWGSearchResponse struct {
+	Count   int `json:"count"`
+	Results []struct {
+		Id              int    `json:"id"`
+		Slug            string `json:"slug"`
+		Name            string `json:"name"`
+		Released        string `json:"released"`
+		BackgroundImage string `json:"background_image"`
+		Platforms       []struct {
+			Platform struct {
+				Name string `json:"name"`
+			} `json:"platform"`
+		} `json:"platforms"`
+	} `json:"results"`
+}
+
+type RAWGGameResponse struct {
+	Id              int    `json:"id"`
+	Slug            string `json:"slug"`
+	Name            string `json:"name"`
+	Released        string `json:"released"`
+	BackgroundImage string `json:"background_image"`
+	Playtime        int    `json:"playtime"`
+	Platforms       []struct {
+		Platform struct {
+			Name string `json:"name"`
+		} `json:"platform"`
+	} `json:"platforms"`
+	Genres []struct {
+		Name string `json:"name"`
+	} `json:"genres"`
+}
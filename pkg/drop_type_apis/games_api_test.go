@@ -0,0 +1,120 @@
+package drop_type_apis
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func newRAWGFixtureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/games", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("key"); got != "test-key" {
+			t.Errorf("API key = %q, want %q", got, "test-key")
+		}
+		if got := r.URL.Query().Get("search"); got != "witcher" {
+			t.Errorf("search query = %q, want %q", got, "witcher")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeFile(w, r, "testdata/rawg_search.json")
+	})
+	mux.HandleFunc("/games/3328", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("key"); got != "test-key" {
+			t.Errorf("API key = %q, want %q", got, "test-key")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeFile(w, r, "testdata/rawg_game.json")
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGamesAPI_Search(t *testing.T) {
+	server := newRAWGFixtureServer(t)
+
+	api := &GamesAPI{HTTPClient: server.Client(), BaseURL: server.URL}
+	api.Init(ProviderConfig{"RAWG_API_KEY": "test-key"})
+
+	want := []ApiSearchResponse{
+		{
+			Search:      "witcher",
+			PicturePath: "https://media.rawg.io/media/games/618/618c2031a07bbff6b4f611f10b6bcdbc.jpg",
+			Title:       "The Witcher 3: Wild Hunt",
+			Subtitle:    "PC, PlayStation 4 · 2015",
+			Content:     "3328",
+		},
+		{
+			Search:      "witcher",
+			PicturePath: "",
+			Title:       "The Witcher 4",
+			Subtitle:    "",
+			Content:     "58550",
+		},
+	}
+
+	got, err := api.Search("witcher")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() = %+v, want %+v", got, want)
+	}
+}
+
+func TestGamesAPI_Resolve(t *testing.T) {
+	server := newRAWGFixtureServer(t)
+
+	api := &GamesAPI{HTTPClient: server.Client(), BaseURL: server.URL}
+	api.Init(ProviderConfig{"RAWG_API_KEY": "test-key"})
+
+	want := ContentMetadata{
+		Content:     "3328",
+		Title:       "The Witcher 3: Wild Hunt",
+		Subtitle:    "PC, PlayStation 4 · 2015",
+		PicturePath: "https://media.rawg.io/media/games/618/618c2031a07bbff6b4f611f10b6bcdbc.jpg",
+		Duration:    46 * 3600,
+		ReleaseYear: 2015,
+		Genres:      []string{"Action", "RPG"},
+		Link:        "https://rawg.io/games/the-witcher-3-wild-hunt",
+	}
+
+	got, err := api.Resolve("3328", "The Witcher 3: Wild Hunt")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resolve() = %+v, want %+v", got, want)
+	}
+
+	if _, err := api.Resolve("404", "Unknown"); !errors.Is(err, ErrContentNotFound) {
+		t.Errorf("Resolve() of an unknown game error = %v, want %v", err, ErrContentNotFound)
+	}
+
+	if _, err := api.Resolve("../games", ""); !errors.Is(err, ErrContentNotFound) {
+		t.Errorf("Resolve() of an invalid game ID error = %v, want %v", err, ErrContentNotFound)
+	}
+}
+
+func TestGamesAPI_MissingApiKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s without an API key", r.URL.Path)
+	}))
+	defer server.Close()
+
+	api := &GamesAPI{HTTPClient: server.Client(), BaseURL: server.URL}
+	api.Init(ProviderConfig{})
+
+	if _, err := api.Search("witcher"); !errors.Is(err, ErrProviderNotConfigured) {
+		t.Errorf("Search() error = %v, want %v", err, ErrProviderNotConfigured)
+	}
+
+	if _, err := api.Resolve("3328", ""); !errors.Is(err, ErrProviderNotConfigured) {
+		t.Errorf("Resolve() error = %v, want %v", err, ErrProviderNotConfigured)
+	}
+}
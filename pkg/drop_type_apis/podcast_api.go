@@ -0,0 +1,297 @@
+package drop_type_apis
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	_ DropTypeAPI = &PodcastAPI{}
+)
+
+func init() {
+	Register(Provider{
+		Type:        PodcastType,
+		DisplayName: "Podcasts",
+		ConfigKeys:  []string{"ITUNES_COUNTRY"},
+		New:         func() DropTypeAPI { return &PodcastAPI{} },
+	})
+}
+
+const (
+	itunesSearchURL = "https://itunes.apple.com/search"
+
+	// A search reads at most maxConcurrentFeeds feeds at once, all within feedsTimeout, and feeds are cut after maxFeedSize bytes
+	maxConcurrentFeeds = 3
+	feedsTimeout       = 5 * time.Second
+	maxFeedSize        = 5 << 20
+)
+
+// PodcastAPI searches podcast episodes through the iTunes search API.
+// The episode GUID comes from iTunes when available, otherwise from the show RSS feed.
+type PodcastAPI struct {
+	HTTPClient *http.Client
+	SearchURL  string
+	Country    string
+}
+
+func (p *PodcastAPI) Search(search string) ([]ApiSearchResponse, error) {
+	episodes, err := p.searchEpisodes(search)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(episodes) == 0 {
+		return nil, nil
+	}
+
+	feeds := p.fetchFeeds(episodes)
+
+	var results []ApiSearchResponse
+	for _, item := range episodes {
+		content := p.episodeID(item, feeds)
+		if content == "" {
+			continue
+		}
+
+		results = append(results, ApiSearchResponse{
+			Search:      search,
+			PicturePath: item.picturePath(),
+			Title:       item.TrackName,
+			Subtitle:    item.CollectionName,
+			Content:     content,
+		})
+	}
+
+	return results, nil
+}
+
+// Resolve searches iTunes again with the episode title, as episodes cannot be looked up by GUID
+func (p *PodcastAPI) Resolve(content string, title string) (ContentMetadata, error) {
+	if strings.TrimSpace(content) == "" || strings.TrimSpace(title) == "" {
+		return ContentMetadata{}, ErrContentNotFound
+	}
+
+	episodes, err := p.searchEpisodes(title)
+	if err != nil {
+		return ContentMetadata{}, err
+	}
+
+	feeds := p.fetchFeeds(episodes)
+	for _, item := range episodes {
+		if p.episodeID(item, feeds) != content {
+			continue
+		}
+
+		var genres []string
+		for _, genre := range item.Genres {
+			genres = append(genres, genre.Name)
+		}
+
+		return ContentMetadata{
+			Content:     content,
+			Title:       item.TrackName,
+			Subtitle:    item.CollectionName,
+			PicturePath: item.picturePath(),
+			Duration:    item.TrackTimeMillis / 1000,
+			ReleaseYear: releaseYear(item.ReleaseDate),
+			Genres:      genres,
+			Link:        item.TrackViewUrl,
+		}, nil
+	}
+
+	return ContentMetadata{}, ErrContentNotFound
+}
+
+func (p *PodcastAPI) searchEpisodes(search string) ([]ITunesEpisode, error) {
+	query := url.Values{}
+	query.Set("term", search)
+	query.Set("media", "podcast")
+	query.Set("entity", "podcastEpisode")
+	query.Set("limit", "20")
+	if p.Country != "" {
+		query.Set("country", p.Country)
+	}
+
+	resp, err := p.HTTPClient.Get(p.SearchURL + "?" + query.Encode())
+	if err != nil {
+		log.Println("Error: Error trying to get podcasts from iTunes API:", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponseStatus(resp); err != nil {
+		log.Printf("Error: iTunes API answered with status %d\n", resp.StatusCode)
+		return nil, err
+	}
+
+	var result ITunesSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result.Results, nil
+}
+
+// episodeID returns the ID Search exposes for an episode, reading the show feed when iTunes lacks the GUID
+func (p *PodcastAPI) episodeID(item ITunesEpisode, feeds map[string]*PodcastFeed) string {
+	if item.EpisodeGuid != "" {
+		return item.EpisodeGuid
+	}
+
+	if content := feeds[item.FeedUrl].FindEpisodeID(item.TrackName); content != "" {
+		return content
+	}
+
+	return item.EpisodeUrl
+}
+
+// fetchFeeds reads the feeds of the episodes iTunes has no GUID for, once per show and a few at a time.
+// The feeds not read before the deadline are skipped, their episodes falling back to their audio URL.
+func (p *PodcastAPI) fetchFeeds(episodes []ITunesEpisode) map[string]*PodcastFeed {
+	var feedUrls []string
+	seen := make(map[string]bool)
+	for _, item := range episodes {
+		if item.EpisodeGuid == "" && item.FeedUrl != "" && !seen[item.FeedUrl] {
+			seen[item.FeedUrl] = true
+			feedUrls = append(feedUrls, item.FeedUrl)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), feedsTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	feeds := make(map[string]*PodcastFeed, len(feedUrls))
+	slots := make(chan struct{}, maxConcurrentFeeds)
+	for _, feedUrl := range feedUrls {
+		wg.Add(1)
+		go func(feedUrl string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			feed := p.fetchFeed(ctx, feedUrl)
+			mu.Lock()
+			feeds[feedUrl] = feed
+			mu.Unlock()
+		}(feedUrl)
+	}
+	wg.Wait()
+
+	return feeds
+}
+
+func (p *PodcastAPI) fetchFeed(ctx context.Context, feedUrl string) *PodcastFeed {
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedUrl, nil)
+	if err != nil {
+		return nil
+	}
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		log.Printf("Error: Error trying to get podcast feed %s: %v\n", feedUrl, err)
+		return nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	// Feeds list the latest episodes first, the items decoded before the size cap are kept
+	var feed PodcastFeed
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, maxFeedSize)).Decode(&feed); err != nil && len(feed.Channel.Items) == 0 {
+		log.Printf("Error: Error decoding podcast feed %s: %v\n", feedUrl, err)
+		return nil
+	}
+
+	return &feed
+}
+
+func (p *PodcastAPI) Init(config ProviderConfig) error {
+	if p.HTTPClient == nil {
+		p.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if p.SearchURL == "" {
+		p.SearchURL = itunesSearchURL
+	}
+	if p.Country == "" {
+		p.Country = config["ITUNES_COUNTRY"]
+	}
+	return nil
+}
+
+type ITunesSearchResponse struct {
+	ResultCount int             `json:"resultCount"`
+	Results     []ITunesEpisode `json:"results"`
+}
+
+type ITunesEpisode struct {
+	TrackName       string `json:"trackName"`
+	CollectionName  string `json:"collectionName"`
+	ArtworkUrl160   string `json:"artworkUrl160"`
+	ArtworkUrl600   string `json:"artworkUrl600"`
+	EpisodeGuid     string `json:"episodeGuid"`
+	EpisodeUrl      string `json:"episodeUrl"`
+	FeedUrl         string `json:"feedUrl"`
+	TrackViewUrl    string `json:"trackViewUrl"`
+	TrackTimeMillis int    `json:"trackTimeMillis"`
+	ReleaseDate     string `json:"releaseDate"`
+	Genres          []struct {
+		Name string `json:"name"`
+	} `json:"genres"`
+}
+
+func (e ITunesEpisode) picturePath() string {
+	if e.ArtworkUrl600 != "" {
+		return e.ArtworkUrl600
+	}
+	return e.ArtworkUrl160
+}
+
+type PodcastFeed struct {
+	Channel struct {
+		Items []struct {
+			Title     string `xml:"title"`
+			Guid      string `xml:"guid"`
+			Link      string `xml:"link"`
+			Enclosure struct {
+				Url string `xml:"url,attr"`
+			} `xml:"enclosure"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// FindEpisodeID returns the GUID of the episode with the given title, falling back to its link or audio URL
+func (f *PodcastFeed) FindEpisodeID(title string) string {
+	if f == nil {
+		return ""
+	}
+
+	for _, item := range f.Channel.Items {
+		if !strings.EqualFold(strings.TrimSpace(item.Title), strings.TrimSpace(title)) {
+			continue
+		}
+		for _, id := range []string{item.Guid, item.Link, item.Enclosure.Url} {
+			if id = strings.TrimSpace(id); id != "" {
+				return id
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,60 @@
+package drop_type_apis
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPodcastAPI_Search(t *testing.T) {
+	searchFixture, err := os.ReadFile("testdata/itunes_podcast_search.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("entity"); got != "podcastEpisode" {
+			t.Errorf("entity = %q, want %q", got, "podcastEpisode")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(strings.ReplaceAll(string(searchFixture), "{{FEED_URL}}", server.URL+"/feed.xml")))
+	})
+	mux.HandleFunc("/feed.xml", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/podcast_feed.xml")
+	})
+
+	api := &PodcastAPI{HTTPClient: server.Client(), SearchURL: server.URL + "/search"}
+	api.Init(ProviderConfig{})
+
+	want := []ApiSearchResponse{
+		{
+			Search:      "example",
+			PicturePath: "https://is1-ssl.mzstatic.com/example/600x600bb.jpg",
+			Title:       "Episode 42: The answer",
+			Subtitle:    "The Example Show",
+			Content:     "example-show-episode-42",
+		},
+		{
+			Search:      "example",
+			PicturePath: "https://is1-ssl.mzstatic.com/example/160x160bb.jpg",
+			Title:       "Episode 41: The question",
+			Subtitle:    "The Example Show",
+			Content:     "example-show-episode-41",
+		},
+	}
+
+	got, err := api.Search("example")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() = %+v, want %+v", got, want)
+	}
+}
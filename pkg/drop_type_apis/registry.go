@@ -0,0 +1,137 @@
+package drop_type_apis
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ProviderConfig holds the environment values a provider asked for through ConfigKeys
+type ProviderConfig map[string]string
+
+type Provider struct {
+	// Type is the key stored on drop notifications and drops
+	Type        string
+	DisplayName string
+	// ConfigKeys are the environment variables passed to Init
+	ConfigKeys []string
+	// Kinds lists the kinds a drop notification can ask for, providers without kinds only accept an empty one
+	Kinds []string
+	// MaxConcurrentSearches and SearchesPerMinute override the CONTENT_SEARCH_* defaults when set, SearchesPerMinute limits the resolutions too
+	MaxConcurrentSearches int
+	SearchesPerMinute     int
+	New                   func() DropTypeAPI
+}
+
+// IsEnabled reads DROP_TYPES_ENABLED and DROP_TYPES_DISABLED, two comma separated lists of types.
+// Every registered provider is enabled when DROP_TYPES_ENABLED is empty.
+func (p Provider) IsEnabled() bool {
+	enabledTypes := splitTypes(os.Getenv("DROP_TYPES_ENABLED"))
+	if len(enabledTypes) > 0 && !slices.Contains(enabledTypes, p.Type) {
+		return false
+	}
+
+	return !slices.Contains(splitTypes(os.Getenv("DROP_TYPES_DISABLED")), p.Type)
+}
+
+func (p Provider) IsValidKind(kind string) bool {
+	return kind == "" || slices.Contains(p.Kinds, kind)
+}
+
+func (p Provider) Config() ProviderConfig {
+	config := make(ProviderConfig, len(p.ConfigKeys))
+	for _, key := range p.ConfigKeys {
+		config[key] = os.Getenv(key)
+	}
+	return config
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   []Provider
+)
+
+// Register makes a provider available, providers are kept in registration order.
+// It panics if the type is registered twice, like database/sql drivers.
+func Register(provider Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	if provider.Type == "" || provider.New == nil {
+		panic("drop_type_apis: Register provider without type or constructor")
+	}
+
+	for _, registered := range providers {
+		if registered.Type == provider.Type {
+			panic("drop_type_apis: Register called twice for provider " + provider.Type)
+		}
+	}
+
+	providers = append(providers, provider)
+}
+
+// GetProviders returns the providers enabled in the current environment
+func GetProviders() []Provider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	var enabledProviders []Provider
+	for _, provider := range providers {
+		if provider.IsEnabled() {
+			enabledProviders = append(enabledProviders, provider)
+		}
+	}
+	return enabledProviders
+}
+
+// IsRegisteredDropType also accepts disabled types, drops of those types may have been posted before
+func IsRegisteredDropType(dropType string) bool {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	for _, provider := range providers {
+		if provider.Type == dropType {
+			return true
+		}
+	}
+	return false
+}
+
+func GetProvider(dropType string) (Provider, bool) {
+	for _, provider := range GetProviders() {
+		if provider.Type == dropType {
+			return provider, true
+		}
+	}
+	return Provider{}, false
+}
+
+func GetValidDropTypes() []string {
+	var validDropTypes []string
+	for _, provider := range GetProviders() {
+		validDropTypes = append(validDropTypes, provider.Type)
+	}
+	return validDropTypes
+}
+
+func IsValidDropType(dropType string) bool {
+	_, ok := GetProvider(dropType)
+	return ok
+}
+
+func IsValidDropKind(dropType string, kind string) bool {
+	provider, ok := GetProvider(dropType)
+	return ok && provider.IsValidKind(kind)
+}
+
+func splitTypes(value string) []string {
+	var types []string
+	for _, dropType := range strings.Split(value, ",") {
+		dropType = strings.ToLower(strings.TrimSpace(dropType))
+		if dropType != "" {
+			types = append(types, dropType)
+		}
+	}
+	return types
+}
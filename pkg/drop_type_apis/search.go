@@ -0,0 +1,272 @@
+package drop_type_apis
+
+import (
+	"errors"
+	"fmt"
+	"go-api/pkg/errors2"
+	"golang.org/x/time/rate"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnavailableDropType   = errors.New("this drop type is not available")
+	ErrProviderNotConfigured = errors.New("content provider is not configured")
+	ErrProviderRateLimited   = errors.New("content provider rate limit reached")
+	ErrContentNotFound       = errors.New("content not found")
+)
+
+const (
+	defaultSearchCacheTTL        = 10 * time.Minute
+	defaultSearchCacheSize       = 1000
+	defaultMaxConcurrentSearches = 5
+	defaultSearchesPerMinute     = 120
+	defaultMaxConcurrentResolves = 5
+	defaultResolvesPerMinute     = 60
+	// A provider failing to initialize is retried after initRetryBaseDelay, doubled on every failure up to initRetryMaxDelay
+	initRetryBaseDelay = 30 * time.Second
+	initRetryMaxDelay  = 10 * time.Minute
+)
+
+// providerSearcher shares one initialized provider between requests, so tokens are reused until they expire.
+// Searches and resolutions have their own budgets, a burst of drop creations must not starve the searches and the other way around.
+type providerSearcher struct {
+	provider Provider
+	api      DropTypeAPI
+	search   *requestBudget
+	resolve  *requestBudget
+}
+
+// requestBudget limits the rate and the concurrency of the requests sent to a provider
+type requestBudget struct {
+	entity  string
+	limiter *rate.Limiter
+	slots   chan struct{}
+}
+
+func newRequestBudget(entity string, perMinute int, maxConcurrent int) *requestBudget {
+	return &requestBudget{
+		entity:  entity,
+		limiter: rate.NewLimiter(rate.Limit(float64(perMinute)/60), maxConcurrent),
+		slots:   make(chan struct{}, maxConcurrent),
+	}
+}
+
+// initFailure remembers why a provider failed to initialize, not to hammer it with new attempts
+type initFailure struct {
+	err      error
+	attempts int
+	retryAt  time.Time
+}
+
+var (
+	searchersMu  sync.Mutex
+	searchers    = make(map[string]*providerSearcher)
+	initFailures = make(map[string]initFailure)
+
+	cacheOnce sync.Once
+	cache     *searchCache
+)
+
+// SearchContent searches a provider through the cache and the provider rate limits.
+// A limit being reached returns an errors2.RateLimitedError.
+func SearchContent(dropType string, kind string, search string) ([]ApiSearchResponse, error) {
+	query := NormalizeQuery(search)
+	if query == "" {
+		return nil, nil
+	}
+
+	searcher, err := getSearcher(dropType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Providers may be case sensitive, only the cache key is lowercased
+	cacheKey := dropType + "|" + kind + "|" + strings.ToLower(query)
+	if results, ok := getSearchCache().Get(cacheKey, time.Now()); ok {
+		return results, nil
+	}
+
+	if err := searcher.search.acquire(); err != nil {
+		return nil, err
+	}
+	defer searcher.search.release()
+
+	var results []ApiSearchResponse
+	if kindSearchAPI, ok := searcher.api.(KindSearchAPI); ok && kind != "" {
+		results, err = kindSearchAPI.SearchKind(query, kind)
+	} else {
+		results, err = searcher.api.Search(query)
+	}
+
+	if err != nil {
+		if errors.Is(err, ErrProviderRateLimited) {
+			return nil, errors2.RateLimitedError{Entity: searcher.provider.DisplayName}
+		}
+		return nil, fmt.Errorf("%s search failed: %w", searcher.provider.DisplayName, err)
+	}
+
+	getSearchCache().Set(cacheKey, results, time.Now())
+
+	return results, nil
+}
+
+// ResolveContent looks a content up by ID with its provider, within the provider's resolution rate limits
+func ResolveContent(dropType string, content string, title string) (ContentMetadata, error) {
+	if strings.TrimSpace(content) == "" {
+		return ContentMetadata{}, ErrContentNotFound
+	}
+
+	searcher, err := getSearcher(dropType)
+	if err != nil {
+		return ContentMetadata{}, err
+	}
+
+	if err := searcher.resolve.acquire(); err != nil {
+		return ContentMetadata{}, err
+	}
+	defer searcher.resolve.release()
+
+	metadata, err := searcher.api.Resolve(strings.TrimSpace(content), title)
+	if err != nil {
+		if errors.Is(err, ErrProviderRateLimited) {
+			return ContentMetadata{}, errors2.RateLimitedError{Entity: searcher.provider.DisplayName}
+		}
+		if errors.Is(err, ErrContentNotFound) {
+			return ContentMetadata{}, err
+		}
+		return ContentMetadata{}, fmt.Errorf("%s lookup failed: %w", searcher.provider.DisplayName, err)
+	}
+
+	return metadata, nil
+}
+
+func (b *requestBudget) acquire() error {
+	if !b.limiter.Allow() {
+		return errors2.RateLimitedError{Entity: b.entity}
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	default:
+		return errors2.RateLimitedError{Entity: b.entity}
+	}
+}
+
+func (b *requestBudget) release() {
+	<-b.slots
+}
+
+// NormalizeQuery trims the query and collapses its whitespaces, so "  Daft   Punk" and "Daft Punk" share a cache entry
+func NormalizeQuery(search string) string {
+	return strings.Join(strings.Fields(search), " ")
+}
+
+func getSearcher(dropType string) (*providerSearcher, error) {
+	provider, ok := GetProvider(dropType)
+	if !ok {
+		return nil, ErrUnavailableDropType
+	}
+
+	searchersMu.Lock()
+	defer searchersMu.Unlock()
+
+	if searcher, ok := searchers[dropType]; ok {
+		return searcher, nil
+	}
+
+	now := time.Now()
+	failure, failed := initFailures[dropType]
+	if failed && now.Before(failure.retryAt) {
+		return nil, failure.err
+	}
+
+	maxConcurrentSearches := provider.MaxConcurrentSearches
+	if maxConcurrentSearches <= 0 {
+		maxConcurrentSearches = intFromEnv("CONTENT_SEARCH_MAX_CONCURRENT", defaultMaxConcurrentSearches)
+	}
+
+	searchesPerMinute := provider.SearchesPerMinute
+	if searchesPerMinute <= 0 {
+		searchesPerMinute = intFromEnv("CONTENT_SEARCH_RATE_PER_MINUTE", defaultSearchesPerMinute)
+	}
+
+	// The provider's own limit applies to the resolutions too, its quota being per API key
+	resolvesPerMinute := provider.SearchesPerMinute
+	if resolvesPerMinute <= 0 {
+		resolvesPerMinute = intFromEnv("CONTENT_RESOLVE_RATE_PER_MINUTE", defaultResolvesPerMinute)
+	}
+
+	api := provider.New()
+	if err := api.Init(provider.Config()); err != nil {
+		failure = initFailure{err: err, attempts: failure.attempts + 1}
+		failure.retryAt = now.Add(initRetryDelay(failure.attempts))
+		initFailures[dropType] = failure
+		log.Printf("Error: Unable to initialize %s, retrying after %s: %v\n", provider.DisplayName, failure.retryAt.Format(time.RFC3339), err)
+		return nil, err
+	}
+	delete(initFailures, dropType)
+
+	searcher := &providerSearcher{
+		provider: provider,
+		api:      api,
+		search:   newRequestBudget(provider.DisplayName, searchesPerMinute, maxConcurrentSearches),
+		resolve:  newRequestBudget(provider.DisplayName, resolvesPerMinute, intFromEnv("CONTENT_RESOLVE_MAX_CONCURRENT", defaultMaxConcurrentResolves)),
+	}
+	searchers[dropType] = searcher
+
+	return searcher, nil
+}
+
+func initRetryDelay(attempts int) time.Duration {
+	delay := initRetryBaseDelay
+	for i := 1; i < attempts && delay < initRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, initRetryMaxDelay)
+}
+
+// getSearchCache reads CONTENT_SEARCH_CACHE_TTL_SECONDS and CONTENT_SEARCH_CACHE_SIZE, a size of 0 disables the cache
+func getSearchCache() *searchCache {
+	cacheOnce.Do(func() {
+		ttl := time.Duration(intFromEnv("CONTENT_SEARCH_CACHE_TTL_SECONDS", int(defaultSearchCacheTTL.Seconds()))) * time.Second
+		cache = newSearchCache(ttl, intFromEnv("CONTENT_SEARCH_CACHE_SIZE", defaultSearchCacheSize))
+	})
+	return cache
+}
+
+// checkResponseStatus turns provider error statuses into errors, 429 becoming ErrProviderRateLimited
+func checkResponseStatus(resp *http.Response) error {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return ErrProviderRateLimited
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrContentNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("provider answered with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// intFromEnv reads a positive integer, a zero limit would reject every request so it falls back to the default too
+func intFromEnv(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	intValue, err := strconv.Atoi(value)
+	if err != nil || intValue <= 0 {
+		log.Printf("Error: Invalid %s %s, falling back to %d\n", key, value, defaultValue)
+		return defaultValue
+	}
+
+	return intValue
+}
@@ -0,0 +1,74 @@
+package drop_type_apis
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type flakyAPI struct {
+	inits *int
+}
+
+func (f *flakyAPI) Search(search string) ([]ApiSearchResponse, error) { return nil, nil }
+
+func (f *flakyAPI) Resolve(content string, title string) (ContentMetadata, error) {
+	return ContentMetadata{}, nil
+}
+
+func (f *flakyAPI) Init(config ProviderConfig) error {
+	*f.inits++
+	if *f.inits == 1 {
+		return ErrProviderNotConfigured
+	}
+	return nil
+}
+
+func TestGetSearcherRetriesFailedInit(t *testing.T) {
+	inits := 0
+	Register(Provider{Type: "flaky", DisplayName: "Flaky", New: func() DropTypeAPI { return &flakyAPI{inits: &inits} }})
+
+	if _, err := getSearcher("flaky"); !errors.Is(err, ErrProviderNotConfigured) {
+		t.Fatalf("got %v, want %v", err, ErrProviderNotConfigured)
+	}
+	if _, err := getSearcher("flaky"); !errors.Is(err, ErrProviderNotConfigured) || inits != 1 {
+		t.Errorf("got %v after %d inits, want the failure kept until the retry delay", err, inits)
+	}
+
+	searchersMu.Lock()
+	failure := initFailures["flaky"]
+	failure.retryAt = time.Now()
+	initFailures["flaky"] = failure
+	searchersMu.Unlock()
+
+	if _, err := getSearcher("flaky"); err != nil || inits != 2 {
+		t.Errorf("got %v after %d inits, want the provider initialized again", err, inits)
+	}
+}
+
+func TestInitRetryDelay(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{
+		1:  initRetryBaseDelay,
+		2:  2 * initRetryBaseDelay,
+		10: initRetryMaxDelay,
+	} {
+		if got := initRetryDelay(attempts); got != want {
+			t.Errorf("initRetryDelay(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
+
+func TestIntFromEnv(t *testing.T) {
+	for value, want := range map[string]int{
+		"":    5,
+		"12":  12,
+		"0":   5,
+		"-3":  5,
+		"abc": 5,
+	} {
+		t.Setenv("CONTENT_SEARCH_MAX_CONCURRENT", value)
+		if got := intFromEnv("CONTENT_SEARCH_MAX_CONCURRENT", 5); got != want {
+			t.Errorf("intFromEnv() with %q = %d, want %d", value, got, want)
+		}
+	}
+}
@@ -1,65 +1,300 @@
 package drop_type_apis
 
 import (
+	"errors"
+	"fmt"
 	"github.com/zmb3/spotify/v2"
 	spotifyauth "github.com/zmb3/spotify/v2/auth"
 	"golang.org/x/net/context"
 	"golang.org/x/oauth2/clientcredentials"
 	"log"
-	"os"
+	"net/http"
+	"strings"
 )
 
 var (
-	_ DropTypeAPI = &SpotifyAPI{}
+	_ DropTypeAPI   = &SpotifyAPI{}
+	_ KindSearchAPI = &SpotifyAPI{}
 )
 
+var SpotifyTrackKind = "track"
+var SpotifyAlbumKind = "album"
+var SpotifyArtistKind = "artist"
+var SpotifyPlaylistKind = "playlist"
+var SpotifyEpisodeKind = "episode"
+
+var spotifySearchTypes = map[string]spotify.SearchType{
+	SpotifyTrackKind:    spotify.SearchTypeTrack,
+	SpotifyAlbumKind:    spotify.SearchTypeAlbum,
+	SpotifyArtistKind:   spotify.SearchTypeArtist,
+	SpotifyPlaylistKind: spotify.SearchTypePlaylist,
+	SpotifyEpisodeKind:  spotify.SearchTypeEpisode,
+}
+
+func init() {
+	Register(Provider{
+		Type:        SpotifyType,
+		DisplayName: "Spotify",
+		ConfigKeys:  []string{"SPOTIFY_CLIENT_ID", "SPOTIFY_CLIENT_SECRET", "SPOTIFY_MARKET"},
+		Kinds:       []string{SpotifyTrackKind, SpotifyAlbumKind, SpotifyArtistKind, SpotifyPlaylistKind, SpotifyEpisodeKind},
+		New:         func() DropTypeAPI { return &SpotifyAPI{} },
+	})
+}
+
 type SpotifyAPI struct {
 	Client *spotify.Client
+	// Market is required by Spotify to return episodes with client credentials
+	Market string
 }
 
-func (s *SpotifyAPI) Search(search string) []ApiSearchResponse {
-	result, err := s.Client.Search(context.Background(), search, spotify.SearchTypeTrack)
-	if err != nil {
-		log.Printf("Error: Failed to search for track: %v", err)
-		return nil
+// Search looks for tracks, albums, artists, playlists and episodes at once
+func (s *SpotifyAPI) Search(search string) ([]ApiSearchResponse, error) {
+	return s.search(search, spotify.SearchTypeTrack|spotify.SearchTypeAlbum|spotify.SearchTypeArtist|spotify.SearchTypePlaylist|spotify.SearchTypeEpisode, 10)
+}
+
+func (s *SpotifyAPI) SearchKind(search string, kind string) ([]ApiSearchResponse, error) {
+	searchType, ok := spotifySearchTypes[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown Spotify kind %s", kind)
+	}
+
+	return s.search(search, searchType, 20)
+}
+
+func (s *SpotifyAPI) search(search string, searchType spotify.SearchType, limit int) ([]ApiSearchResponse, error) {
+	if s.Client == nil {
+		return nil, ErrProviderNotConfigured
 	}
 
-	if len(result.Tracks.Tracks) == 0 {
-		return nil
+	options := []spotify.RequestOption{spotify.Limit(limit)}
+	if s.Market != "" {
+		options = append(options, spotify.Market(s.Market))
+	}
+
+	result, err := s.Client.Search(context.Background(), search, searchType, options...)
+	if err != nil {
+		log.Printf("Error: Failed to search on Spotify: %v", err)
+		return nil, err
 	}
 
 	var results []ApiSearchResponse
-	for _, item := range result.Tracks.Tracks {
-		results = append(results, ApiSearchResponse{
-			Search:      search,
-			PicturePath: item.Album.Images[0].URL,
-			Title:       item.Name,
-			Subtitle:    item.Artists[0].Name,
-			Content:     string(item.URI),
-		})
-	}
-	return results
+
+	if result.Tracks != nil {
+		for _, item := range result.Tracks.Tracks {
+			if item.URI == "" {
+				continue
+			}
+			results = append(results, ApiSearchResponse{
+				Search:      search,
+				Kind:        SpotifyTrackKind,
+				PicturePath: firstImageURL(item.Album.Images),
+				Title:       item.Name,
+				Subtitle:    artistNames(item.Artists),
+				Content:     string(item.URI),
+			})
+		}
+	}
+
+	if result.Albums != nil {
+		for _, item := range result.Albums.Albums {
+			if item.URI == "" {
+				continue
+			}
+			results = append(results, ApiSearchResponse{
+				Search:      search,
+				Kind:        SpotifyAlbumKind,
+				PicturePath: firstImageURL(item.Images),
+				Title:       item.Name,
+				Subtitle:    artistNames(item.Artists),
+				Content:     string(item.URI),
+			})
+		}
+	}
+
+	if result.Artists != nil {
+		for _, item := range result.Artists.Artists {
+			if item.URI == "" {
+				continue
+			}
+			results = append(results, ApiSearchResponse{
+				Search:      search,
+				Kind:        SpotifyArtistKind,
+				PicturePath: firstImageURL(item.Images),
+				Title:       item.Name,
+				Subtitle:    strings.Join(item.Genres, ", "),
+				Content:     string(item.URI),
+			})
+		}
+	}
+
+	if result.Playlists != nil {
+		// Spotify sends null items for playlists that are no longer available
+		for _, item := range result.Playlists.Playlists {
+			if item.URI == "" {
+				continue
+			}
+			results = append(results, ApiSearchResponse{
+				Search:      search,
+				Kind:        SpotifyPlaylistKind,
+				PicturePath: firstImageURL(item.Images),
+				Title:       item.Name,
+				Subtitle:    item.Owner.DisplayName,
+				Content:     string(item.URI),
+			})
+		}
+	}
+
+	if result.Episodes != nil {
+		for _, item := range result.Episodes.Episodes {
+			if item.URI == "" {
+				continue
+			}
+			picturePath := firstImageURL(item.Images)
+			if picturePath == "" {
+				picturePath = firstImageURL(item.Show.Images)
+			}
+			results = append(results, ApiSearchResponse{
+				Search:      search,
+				Kind:        SpotifyEpisodeKind,
+				PicturePath: picturePath,
+				Title:       item.Name,
+				Subtitle:    item.Show.Name,
+				Content:     string(item.URI),
+			})
+		}
+	}
+
+	return results, nil
+}
+
+// Resolve looks up a Spotify URI such as spotify:album:4aawyAB9vmqN3uQ7FjRGTy
+func (s *SpotifyAPI) Resolve(content string, title string) (ContentMetadata, error) {
+	if s.Client == nil {
+		return ContentMetadata{}, ErrProviderNotConfigured
+	}
+
+	parts := strings.Split(content, ":")
+	if len(parts) != 3 || parts[0] != "spotify" || parts[2] == "" {
+		return ContentMetadata{}, ErrContentNotFound
+	}
+	kind, id := parts[1], spotify.ID(parts[2])
+
+	ctx := context.Background()
+	var options []spotify.RequestOption
+	if s.Market != "" {
+		options = append(options, spotify.Market(s.Market))
+	}
+
+	metadata := ContentMetadata{Content: content, Kind: kind}
+
+	switch kind {
+	case SpotifyTrackKind:
+		track, err := s.Client.GetTrack(ctx, id, options...)
+		if err != nil {
+			return ContentMetadata{}, spotifyLookupError(err)
+		}
+		metadata.Title = track.Name
+		metadata.Subtitle = artistNames(track.Artists)
+		metadata.PicturePath = firstImageURL(track.Album.Images)
+		metadata.Duration = int(track.Duration) / 1000
+		metadata.ReleaseYear = releaseYear(track.Album.ReleaseDate)
+		metadata.Link = track.ExternalURLs["spotify"]
+	case SpotifyAlbumKind:
+		album, err := s.Client.GetAlbum(ctx, id, options...)
+		if err != nil {
+			return ContentMetadata{}, spotifyLookupError(err)
+		}
+		metadata.Title = album.Name
+		metadata.Subtitle = artistNames(album.Artists)
+		metadata.PicturePath = firstImageURL(album.Images)
+		metadata.ReleaseYear = releaseYear(album.ReleaseDate)
+		metadata.Genres = album.Genres
+		metadata.Link = album.ExternalURLs["spotify"]
+		for _, track := range album.Tracks.Tracks {
+			metadata.Duration += int(track.Duration) / 1000
+		}
+	case SpotifyArtistKind:
+		artist, err := s.Client.GetArtist(ctx, id)
+		if err != nil {
+			return ContentMetadata{}, spotifyLookupError(err)
+		}
+		metadata.Title = artist.Name
+		metadata.Subtitle = strings.Join(artist.Genres, ", ")
+		metadata.PicturePath = firstImageURL(artist.Images)
+		metadata.Genres = artist.Genres
+		metadata.Link = artist.ExternalURLs["spotify"]
+	case SpotifyPlaylistKind:
+		playlist, err := s.Client.GetPlaylist(ctx, id, options...)
+		if err != nil {
+			return ContentMetadata{}, spotifyLookupError(err)
+		}
+		metadata.Title = playlist.Name
+		metadata.Subtitle = playlist.Owner.DisplayName
+		metadata.PicturePath = firstImageURL(playlist.Images)
+		metadata.Link = playlist.ExternalURLs["spotify"]
+	case SpotifyEpisodeKind:
+		episode, err := s.Client.GetEpisode(ctx, string(id), options...)
+		if err != nil {
+			return ContentMetadata{}, spotifyLookupError(err)
+		}
+		metadata.Title = episode.Name
+		metadata.Subtitle = episode.Show.Name
+		metadata.PicturePath = firstImageURL(episode.Images)
+		metadata.Duration = int(episode.Duration_ms) / 1000
+		metadata.ReleaseYear = releaseYear(episode.ReleaseDate)
+		metadata.Link = episode.ExternalURLs["spotify"]
+	default:
+		return ContentMetadata{}, ErrContentNotFound
+	}
+
+	return metadata, nil
+}
+
+func spotifyLookupError(err error) error {
+	var spotifyErr spotify.Error
+	if errors.As(err, &spotifyErr) {
+		switch spotifyErr.Status {
+		case http.StatusNotFound, http.StatusBadRequest:
+			return ErrContentNotFound
+		case http.StatusTooManyRequests:
+			return ErrProviderRateLimited
+		}
+	}
+	return err
 }
 
-func (s *SpotifyAPI) Init() {
-	clientID := os.Getenv("SPOTIFY_CLIENT_ID")
-	clientSecret := os.Getenv("SPOTIFY_CLIENT_SECRET")
+func (s *SpotifyAPI) Init(config ProviderConfig) error {
+	clientID := config["SPOTIFY_CLIENT_ID"]
+	clientSecret := config["SPOTIFY_CLIENT_SECRET"]
+	s.Market = config["SPOTIFY_MARKET"]
 
 	// Set up the OAuth2 config
-	config := &clientcredentials.Config{
+	credentialsConfig := &clientcredentials.Config{
 		ClientID:     clientID,
 		ClientSecret: clientSecret,
 		TokenURL:     spotifyauth.TokenURL,
 	}
 
-	ctx := context.Background()
-	// Get a token and create a Spotify client
-	token, err := config.Token(ctx)
-	if err != nil {
-		log.Printf("Error: Failed to get token: %v", err)
-		return
+	if clientID == "" || clientSecret == "" {
+		return fmt.Errorf("%w: SPOTIFY_CLIENT_ID or SPOTIFY_CLIENT_SECRET is missing", ErrProviderNotConfigured)
 	}
 
-	httpClient := spotifyauth.New().Client(ctx, token)
-	s.Client = spotify.New(httpClient)
+	// The client caches the token and only asks Spotify for a new one once it expired
+	s.Client = spotify.New(credentialsConfig.Client(context.Background()))
+	return nil
+}
+
+func firstImageURL(images []spotify.Image) string {
+	if len(images) == 0 {
+		return ""
+	}
+	return images[0].URL
+}
+
+func artistNames(artists []spotify.SimpleArtist) string {
+	var names []string
+	for _, artist := range artists {
+		names = append(names, artist.Name)
+	}
+	return strings.Join(names, ", ")
 }
@@ -2,22 +2,47 @@ package drop_type_apis
 
 import (
 	"encoding/json"
+	"fmt"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
 	"log"
 	"net/http"
 	"net/url"
-	"os"
 	"strings"
+	"time"
 )
 
+func init() {
+	Register(Provider{
+		Type:        TwitchType,
+		DisplayName: "Twitch",
+		ConfigKeys:  []string{"TWITCH_CLIENT_ID", "TWITCH_CLIENT_SECRET"},
+		New:         func() DropTypeAPI { return &TwitchTypeApi{} },
+	})
+}
+
 type TwitchTypeApi struct {
 	ClientID string
-	Token    string
+	// TokenSource caches the app access token and only asks Twitch for a new one once it expired
+	TokenSource oauth2.TokenSource
+	HTTPClient  *http.Client
 }
 
-func (t *TwitchTypeApi) Search(search string) []ApiSearchResponse {
+func (t *TwitchTypeApi) Search(search string) ([]ApiSearchResponse, error) {
+	if t.TokenSource == nil {
+		return nil, ErrProviderNotConfigured
+	}
+
+	token, err := t.TokenSource.Token()
+	if err != nil {
+		log.Printf("Error: Error getting token from Twitch API: %s\n", err)
+		return nil, err
+	}
+
 	req, err := http.NewRequest("GET", "https://api.twitch.tv/helix/search/channels", nil)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
 	q := req.URL.Query()
@@ -25,24 +50,26 @@ func (t *TwitchTypeApi) Search(search string) []ApiSearchResponse {
 	req.URL.RawQuery = q.Encode()
 
 	req.Header.Set("Client-ID", t.ClientID)
-	req.Header.Set("Authorization", "Bearer "+t.Token)
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
 
-	client := &http.Client{}
-	req.Close = true
-	resp, err := client.Do(req)
+	resp, err := t.HTTPClient.Do(req)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if err := checkResponseStatus(resp); err != nil {
+		return nil, err
+	}
+
 	var searchResponse SearchResponse
 	if err := json.NewDecoder(resp.Body).Decode(&searchResponse); err != nil {
-		return nil
+		return nil, err
 	}
 
 	if len(searchResponse.Data) == 0 {
 		log.Printf("No results found for search query %s\n", search)
-		return nil
+		return nil, nil
 	}
 
 	var results []ApiSearchResponse
@@ -52,56 +79,118 @@ func (t *TwitchTypeApi) Search(search string) []ApiSearchResponse {
 			PicturePath: strings.Replace(item.ThumbnailUrl, "{width}", "320", -1),
 			Title:       item.DisplayName,
 			Subtitle:    item.Title,
-			Content:     "https://twitch.tv/" + item.DisplayName,
+			Content:     "https://twitch.tv/" + item.BroadcasterLogin,
 		})
 	}
 
-	return results
+	return results, nil
 }
 
-func (t *TwitchTypeApi) Init() {
-	clientID := os.Getenv("TWITCH_CLIENT_ID")
-	clientSecret := os.Getenv("TWITCH_CLIENT_SECRET")
+// Resolve looks up the channel of a twitch.tv/<login> URL returned by Search
+func (t *TwitchTypeApi) Resolve(content string, title string) (ContentMetadata, error) {
+	if t.TokenSource == nil {
+		return ContentMetadata{}, ErrProviderNotConfigured
+	}
+
+	channelUrl, err := url.Parse(content)
+	if err != nil || strings.TrimPrefix(channelUrl.Host, "www.") != "twitch.tv" {
+		return ContentMetadata{}, ErrContentNotFound
+	}
 
-	data := url.Values{}
-	data.Set("client_id", clientID)
-	data.Set("client_secret", clientSecret)
-	data.Set("grant_type", "client_credentials")
+	login := strings.Trim(channelUrl.Path, "/")
+	if login == "" || strings.Contains(login, "/") {
+		return ContentMetadata{}, ErrContentNotFound
+	}
 
-	if clientID == "" || clientSecret == "" {
-		log.Printf("Twitch API client ID or secret not found in environment variable TWITCH_CLIENT_ID or TWITCH_CLIENT_SECRET\n")
-		return
+	token, err := t.TokenSource.Token()
+	if err != nil {
+		log.Printf("Error: Error getting token from Twitch API: %s\n", err)
+		return ContentMetadata{}, err
 	}
-	resp, err := http.Post("https://id.twitch.tv/oauth2/token", "application/x-www-form-urlencoded", strings.NewReader(data.Encode()))
+
+	req, err := http.NewRequest("GET", "https://api.twitch.tv/helix/users", nil)
+	if err != nil {
+		return ContentMetadata{}, err
+	}
+
+	q := req.URL.Query()
+	q.Add("login", strings.ToLower(login))
+	req.URL.RawQuery = q.Encode()
+
+	req.Header.Set("Client-ID", t.ClientID)
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+	resp, err := t.HTTPClient.Do(req)
 	if err != nil {
-		log.Printf("Error getting token from Twitch API: %s\n", err)
-		return
+		return ContentMetadata{}, err
 	}
 	defer resp.Body.Close()
 
-	var tokenResponse TokenResponse
-	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
-		log.Printf("Error decoding token from Twitch API: %s\n", err)
-		return
+	if err := checkResponseStatus(resp); err != nil {
+		return ContentMetadata{}, err
 	}
 
-	t.ClientID = clientID
-	t.Token = tokenResponse.AccessToken
+	var usersResponse UsersResponse
+	if err := json.NewDecoder(resp.Body).Decode(&usersResponse); err != nil {
+		return ContentMetadata{}, err
+	}
+
+	if len(usersResponse.Data) == 0 {
+		return ContentMetadata{}, ErrContentNotFound
+	}
+
+	user := usersResponse.Data[0]
+	return ContentMetadata{
+		Content:     "https://twitch.tv/" + user.Login,
+		Title:       user.DisplayName,
+		Subtitle:    user.Description,
+		PicturePath: user.ProfileImageUrl,
+		Link:        "https://twitch.tv/" + user.Login,
+	}, nil
 }
 
-type TokenResponse struct {
-	AccessToken string `json:"access_token"`
-	ExpiresIn   int    `json:"expires_in"`
-	TokenType   string `json:"token_type"`
+func (t *TwitchTypeApi) Init(config ProviderConfig) error {
+	clientID := config["TWITCH_CLIENT_ID"]
+	clientSecret := config["TWITCH_CLIENT_SECRET"]
+
+	if clientID == "" || clientSecret == "" {
+		return fmt.Errorf("%w: TWITCH_CLIENT_ID or TWITCH_CLIENT_SECRET is missing", ErrProviderNotConfigured)
+	}
+
+	credentialsConfig := &clientcredentials.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     "https://id.twitch.tv/oauth2/token",
+		AuthStyle:    oauth2.AuthStyleInParams,
+	}
+
+	t.ClientID = clientID
+	t.TokenSource = credentialsConfig.TokenSource(context.Background())
+	t.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	return nil
 }
 
 type Channel struct {
-	Id           string `json:"id"`
-	DisplayName  string `json:"display_name"`
-	Title        string `json:"title"`
-	ThumbnailUrl string `json:"thumbnail_url"`
+	Id string `json:"id"`
+	// BroadcasterLogin is the name of the channel's URL, DisplayName may differ in case or be localized
+	BroadcasterLogin string `json:"broadcaster_login"`
+	DisplayName      string `json:"display_name"`
+	Title            string `json:"title"`
+	ThumbnailUrl     string `json:"thumbnail_url"`
 }
 
 type SearchResponse struct {
 	Data []Channel `json:"data"`
 }
+
+type User struct {
+	Id              string `json:"id"`
+	Login           string `json:"login"`
+	DisplayName     string `json:"display_name"`
+	Description     string `json:"description"`
+	ProfileImageUrl string `json:"profile_image_url"`
+}
+
+type UsersResponse struct {
+	Data []User `json:"data"`
+}
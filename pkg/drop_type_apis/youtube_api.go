@@ -5,60 +5,159 @@ import (
 	"golang.org/x/net/context"
 	"google.golang.org/api/option"
 	"google.golang.org/api/youtube/v3"
-	"log"
-	"os"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 )
 
 var (
 	_ DropTypeAPI = &YoutubeAPI{}
 )
 
+func init() {
+	Register(Provider{
+		Type:        YoutubeType,
+		DisplayName: "YouTube",
+		ConfigKeys:  []string{"YOUTUBE_API_KEY"},
+		// Every search costs 100 units of the daily YouTube Data API quota
+		SearchesPerMinute: 30,
+		New:               func() DropTypeAPI { return &YoutubeAPI{} },
+	})
+}
+
 type YoutubeAPI struct {
 	Client *youtube.Service
 }
 
-func (y *YoutubeAPI) Search(search string) []ApiSearchResponse {
+func (y *YoutubeAPI) Search(search string) ([]ApiSearchResponse, error) {
+	if y.Client == nil {
+		return nil, ErrProviderNotConfigured
+	}
+
 	call := y.Client.Search.List([]string{"snippet"}).Q(search).MaxResults(20).Type("video")
 
 	response, err := call.Do()
 
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
 	if len(response.Items) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	var results []ApiSearchResponse
 	for _, item := range response.Items {
+		if item.Snippet == nil || item.Id == nil {
+			continue
+		}
+		var picturePath string
+		if item.Snippet.Thumbnails != nil && item.Snippet.Thumbnails.Default != nil {
+			picturePath = item.Snippet.Thumbnails.Default.Url
+		}
 		results = append(results, ApiSearchResponse{
 			Search:      search,
-			PicturePath: item.Snippet.Thumbnails.Default.Url,
+			PicturePath: picturePath,
 			Title:       item.Snippet.Title,
 			Subtitle:    item.Snippet.ChannelTitle,
 			Content:     generateUrl(item.Id.VideoId),
 		})
 	}
 
-	return results
+	return results, nil
+}
+
+// Resolve looks up the video of a watch URL returned by Search
+func (y *YoutubeAPI) Resolve(content string, title string) (ContentMetadata, error) {
+	if y.Client == nil {
+		return ContentMetadata{}, ErrProviderNotConfigured
+	}
+
+	videoId := parseVideoId(content)
+	if videoId == "" {
+		return ContentMetadata{}, ErrContentNotFound
+	}
+
+	response, err := y.Client.Videos.List([]string{"snippet", "contentDetails"}).Id(videoId).Do()
+	if err != nil {
+		return ContentMetadata{}, err
+	}
+
+	if len(response.Items) == 0 || response.Items[0].Snippet == nil {
+		return ContentMetadata{}, ErrContentNotFound
+	}
+
+	video := response.Items[0]
+	metadata := ContentMetadata{
+		Content:     generateUrl(video.Id),
+		Title:       video.Snippet.Title,
+		Subtitle:    video.Snippet.ChannelTitle,
+		ReleaseYear: releaseYear(video.Snippet.PublishedAt),
+		Link:        generateUrl(video.Id),
+	}
+
+	if video.Snippet.Thumbnails != nil && video.Snippet.Thumbnails.Default != nil {
+		metadata.PicturePath = video.Snippet.Thumbnails.Default.Url
+	}
+
+	if video.ContentDetails != nil {
+		metadata.Duration = parseISO8601Duration(video.ContentDetails.Duration)
+	}
+
+	return metadata, nil
 }
 
-func (y *YoutubeAPI) Init() {
-	apiKey := os.Getenv("YOUTUBE_API_KEY")
+func (y *YoutubeAPI) Init(config ProviderConfig) error {
+	apiKey := config["YOUTUBE_API_KEY"]
 
 	if apiKey == "" {
-		log.Printf("Error: YouTube API key not found in environment variable YOUTUBE_API_KEY\n")
-		return
+		return fmt.Errorf("%w: YOUTUBE_API_KEY is missing", ErrProviderNotConfigured)
 	}
 
 	service, err := youtube.NewService(context.Background(), option.WithAPIKey(apiKey))
 	if err != nil {
-		log.Printf("Error: Unable to create YouTube service: %v\n", err)
+		return fmt.Errorf("unable to create YouTube service: %w", err)
 	}
 	y.Client = service
+	return nil
 }
 
 func generateUrl(videoId string) string {
 	return fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoId)
 }
+
+// parseVideoId reads the video ID of youtube.com/watch?v=<id> and youtu.be/<id> URLs
+func parseVideoId(content string) string {
+	videoUrl, err := url.Parse(content)
+	if err != nil {
+		return ""
+	}
+
+	switch strings.TrimPrefix(videoUrl.Host, "www.") {
+	case "youtube.com", "m.youtube.com":
+		return videoUrl.Query().Get("v")
+	case "youtu.be":
+		return strings.Trim(videoUrl.Path, "/")
+	}
+
+	return ""
+}
+
+// parseISO8601Duration converts YouTube durations such as PT1H2M3S into seconds
+func parseISO8601Duration(duration string) int {
+	matches := iso8601DurationRegexp.FindStringSubmatch(duration)
+	if matches == nil {
+		return 0
+	}
+
+	seconds := 0
+	for i, unit := range []int{24 * 3600, 3600, 60, 1} {
+		if value, err := strconv.Atoi(matches[i+1]); err == nil {
+			seconds += value * unit
+		}
+	}
+	return seconds
+}
+
+var iso8601DurationRegexp = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
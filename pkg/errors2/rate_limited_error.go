@@ -0,0 +1,9 @@
+package errors2
+
+type RateLimitedError struct {
+	Entity string
+}
+
+func (e RateLimitedError) Error() string {
+	return "Too many requests to " + e.Entity + ", please try again in a moment"
+}
@@ -0,0 +1,14 @@
+package errors2
+
+import (
+	"fmt"
+	"time"
+)
+
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (e TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("Too many failed attempts, please try again in %s", e.RetryAfter.Round(time.Second))
+}
@@ -1,47 +1,175 @@
 package jwt_helper
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
+	"log"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 )
 
-var secretKey = []byte(os.Getenv("JWT_SECRET"))
+// TokenType tells what a token can be used for, an access token is the only one accepted as a Bearer token
+type TokenType string
 
-func GenerateToken(userId uint, role string) (string, string, int, error) {
-	expiry := time.Now().Add(time.Hour * 6).Unix()
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256,
-		jwt.MapClaims{
-			"sub":  userId,
-			"exp":  expiry,
-			"role": role,
-		})
+const (
+	TokenTypeAccess TokenType = "access"
+	// TokenTypeTwoFactor proves the password of a user with two-factor authentication, it is only exchanged for an access token with their second factor
+	TokenTypeTwoFactor TokenType = "2fa"
+	// TokenTypeStream opens a Server-Sent Events stream from the query string, browsers' EventSource cannot send headers
+	TokenTypeStream TokenType = "stream"
+)
+
+const (
+	accessTokenLifetime    = 6 * time.Hour
+	twoFactorTokenLifetime = 5 * time.Minute
+	// StreamTicketLifetime only needs to cover the opening of the stream, it ends up in server logs as part of the URL
+	StreamTicketLifetime = time.Minute
+)
 
-	tokenString, err := jwtToken.SignedString(secretKey)
+var ErrWrongTokenType = errors.New("wrong token type")
+
+type Claims struct {
+	jwt.RegisteredClaims
+	Role      string    `json:"role,omitempty"`
+	SessionID uint      `json:"sid,omitempty"`
+	TokenType TokenType `json:"typ"`
+	// TwoFactor tells the session of the token was opened with the second factor of the user
+	TwoFactor bool `json:"mfa,omitempty"`
+}
 
+func (c *Claims) GetUserID() (uint, error) {
+	userId, err := strconv.ParseUint(c.Subject, 10, 64)
 	if err != nil {
-		return "", "", 0, err
+		return 0, fmt.Errorf("invalid token subject: %w", err)
+	}
+	return uint(userId), nil
+}
+
+var (
+	keyringOnce sync.Once
+	keyring     *Keyring
+	keyringErr  error
+)
+
+// SetKeyring replaces the keyring read from the environment
+func SetKeyring(k *Keyring) {
+	keyringOnce.Do(func() {})
+	keyring, keyringErr = k, nil
+}
+
+// getKeyring reads the environment on first use, once .env has been loaded
+func getKeyring() (*Keyring, error) {
+	keyringOnce.Do(func() {
+		keyring, keyringErr = NewKeyringFromEnv()
+		if keyringErr != nil {
+			log.Printf("Error: Invalid JWT configuration: %v\n", keyringErr)
+		}
+	})
+	return keyring, keyringErr
+}
+
+func issuer() string {
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+		return issuer
 	}
+	return "droppy-api"
+}
 
-	refreshToken := jwt.New(jwt.SigningMethodHS256)
-	refreshTokenExpiry := time.Now().AddDate(0, 0, 60).Unix()
-	rtClaims := refreshToken.Claims.(jwt.MapClaims)
-	rtClaims["sub"] = userId
-	rtClaims["exp"] = refreshTokenExpiry
-	rt, err := refreshToken.SignedString(secretKey)
+func audience() string {
+	if audience := os.Getenv("JWT_AUDIENCE"); audience != "" {
+		return audience
+	}
+	return "droppy-app"
+}
+
+// NewClaims returns the claims of a token of the given type for the user, valid from now on for lifetime
+func NewClaims(tokenType TokenType, userId uint, lifetime time.Duration) Claims {
+	now := time.Now()
+	return Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer(),
+			Subject:   strconv.FormatUint(uint64(userId), 10),
+			Audience:  jwt.ClaimStrings{audience()},
+			ExpiresAt: jwt.NewNumericDate(now.Add(lifetime)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		TokenType: tokenType,
+	}
+}
 
+// Sign signs the claims with the current key of the keyring, whose ID goes in the kid header
+func Sign(claims Claims) (string, error) {
+	k, err := getKeyring()
 	if err != nil {
-		return "", "", 0, err
+		return "", err
 	}
 
-	return tokenString, rt, int(refreshTokenExpiry), nil
+	key := k.SigningKey()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.SignKey)
 }
 
-func VerifyToken(tokenString string) (*jwt.Token, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return secretKey, nil
-	})
+// GenerateToken issues a 6 hours access token for the user's session, refresh tokens are opaque and handled by the account service
+func GenerateToken(userId uint, role string, sessionId uint, twoFactor bool) (string, error) {
+	claims := NewClaims(TokenTypeAccess, userId, accessTokenLifetime)
+	claims.Role = role
+	claims.SessionID = sessionId
+	claims.TwoFactor = twoFactor
+
+	return Sign(claims)
+}
+
+// GenerateTwoFactorToken issues the 5 minutes token a user exchanges with their second factor to complete their login,
+// its random ID lets the account service accept it only once
+func GenerateTwoFactorToken(userId uint) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	claims := NewClaims(TokenTypeTwoFactor, userId, twoFactorTokenLifetime)
+	claims.ID = hex.EncodeToString(id)
+	return Sign(claims)
+}
+
+// GenerateStreamTicket issues the short-lived ticket a user opens a Server-Sent Events stream of their session with
+func GenerateStreamTicket(userId uint, sessionId uint) (string, error) {
+	claims := NewClaims(TokenTypeStream, userId, StreamTicketLifetime)
+	claims.SessionID = sessionId
+	return Sign(claims)
+}
+
+// VerifyToken checks the signature, issuer, audience and expiry of the token, and that it is of the expected type
+func VerifyToken(tokenString string, expectedType TokenType) (*Claims, error) {
+	k, err := getKeyring()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := k.Lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", kid)
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s for key %q", token.Method.Alg(), kid)
+		}
+		return key.VerifyKey, nil
+	},
+		jwt.WithValidMethods(k.methods()),
+		jwt.WithIssuer(issuer()),
+		jwt.WithAudience(audience()),
+		jwt.WithExpirationRequired(),
+	)
 
 	if err != nil {
 		return nil, err
@@ -51,12 +179,17 @@ func VerifyToken(tokenString string) (*jwt.Token, error) {
 		return nil, fmt.Errorf("invalid token")
 	}
 
-	return token, nil
+	if claims.TokenType != expectedType {
+		return nil, ErrWrongTokenType
+	}
+
+	return claims, nil
 }
 
-func GetUserIdFromToken(tokenString string) uint {
-	token, _ := VerifyToken(tokenString)
-	claims, _ := token.Claims.(jwt.MapClaims)
-	userId := claims["sub"].(float64)
-	return uint(userId)
+func GetUserIdFromToken(tokenString string) (uint, error) {
+	claims, err := VerifyToken(tokenString, TokenTypeAccess)
+	if err != nil {
+		return 0, err
+	}
+	return claims.GetUserID()
 }
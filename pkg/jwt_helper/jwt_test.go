@@ -0,0 +1,118 @@
+package jwt_helper
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustKeyring(t *testing.T, current Key, previous ...Key) *Keyring {
+	t.Helper()
+	k, err := NewKeyring(current, previous...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestGenerateAndVerifyToken(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, key := range map[string]Key{
+		"HS256": NewHMACKey("hmac", []byte("secret")),
+		"EdDSA": NewEd25519Key("ed", privateKey),
+	} {
+		t.Run(name, func(t *testing.T) {
+			SetKeyring(mustKeyring(t, key))
+
+			token, err := GenerateToken(42, "admin", 7, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			claims, err := VerifyToken(token, TokenTypeAccess)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			userId, err := claims.GetUserID()
+			if err != nil || userId != 42 || claims.Role != "admin" || claims.SessionID != 7 || !claims.TwoFactor {
+				t.Errorf("got user %d, role %s, session %d, 2FA %t, want 42, admin, 7, true", userId, claims.Role, claims.SessionID, claims.TwoFactor)
+			}
+		})
+	}
+}
+
+func TestVerifyTokenRejectsWrongType(t *testing.T) {
+	SetKeyring(mustKeyring(t, NewHMACKey("hmac", []byte("secret"))))
+
+	token, err := Sign(NewClaims(TokenType("other"), 42, time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := VerifyToken(token, TokenTypeAccess); !errors.Is(err, ErrWrongTokenType) {
+		t.Errorf("got %v, want %v", err, ErrWrongTokenType)
+	}
+
+	twoFactorToken, err := GenerateTwoFactorToken(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyToken(twoFactorToken, TokenTypeAccess); !errors.Is(err, ErrWrongTokenType) {
+		t.Errorf("two-factor token accepted as access token: got %v, want %v", err, ErrWrongTokenType)
+	}
+	if claims, err := VerifyToken(twoFactorToken, TokenTypeTwoFactor); err != nil || claims.ID == "" {
+		t.Errorf("two-factor token without ID: %v", err)
+	}
+}
+
+func TestVerifyTokenAfterRotation(t *testing.T) {
+	oldKey := NewHMACKey("old", []byte("old secret"))
+	SetKeyring(mustKeyring(t, oldKey))
+
+	oldToken, err := GenerateToken(42, "user", 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetKeyring(mustKeyring(t, NewHMACKey("new", []byte("new secret")), Key{ID: "old", Method: oldKey.Method, VerifyKey: oldKey.VerifyKey}))
+
+	if _, err := VerifyToken(oldToken, TokenTypeAccess); err != nil {
+		t.Errorf("token signed with the previous key: %v", err)
+	}
+
+	SetKeyring(mustKeyring(t, NewHMACKey("new", []byte("new secret"))))
+
+	if _, err := VerifyToken(oldToken, TokenTypeAccess); err == nil {
+		t.Errorf("token signed with a removed key must be rejected")
+	}
+}
+
+func TestVerifyTokenRejectsOtherAudience(t *testing.T) {
+	SetKeyring(mustKeyring(t, NewHMACKey("hmac", []byte("secret"))))
+
+	t.Setenv("JWT_AUDIENCE", "other-app")
+	token, err := GenerateToken(42, "user", 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("JWT_AUDIENCE", "droppy-app")
+	if _, err := VerifyToken(token, TokenTypeAccess); err == nil {
+		t.Errorf("token for another audience must be rejected")
+	}
+}
+
+func TestGetUserIdFromInvalidToken(t *testing.T) {
+	SetKeyring(mustKeyring(t, NewHMACKey("hmac", []byte("secret"))))
+
+	if _, err := GetUserIdFromToken("not a token"); err == nil {
+		t.Errorf("an invalid token must return an error")
+	}
+}
@@ -0,0 +1,137 @@
+package jwt_helper
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"os"
+	"strings"
+)
+
+// Key signs or verifies the tokens whose kid header is its ID
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	// SignKey is nil for the keys only kept to verify the tokens issued before a rotation
+	SignKey   interface{}
+	VerifyKey interface{}
+}
+
+// NewHMACKey returns a HS256 key, used both to sign and to verify
+func NewHMACKey(id string, secret []byte) Key {
+	return Key{ID: id, Method: jwt.SigningMethodHS256, SignKey: secret, VerifyKey: secret}
+}
+
+// NewEd25519Key returns an EdDSA key, the public key is enough to verify the tokens
+func NewEd25519Key(id string, privateKey ed25519.PrivateKey) Key {
+	return Key{ID: id, Method: jwt.SigningMethodEdDSA, SignKey: privateKey, VerifyKey: privateKey.Public()}
+}
+
+// Keyring signs with its current key and verifies with any of its keys, so keys can be rotated
+// without logging every user out: the previous key stays in the keyring until its tokens expire.
+type Keyring struct {
+	current string
+	keys    map[string]Key
+}
+
+func NewKeyring(current Key, previous ...Key) (*Keyring, error) {
+	if current.ID == "" || current.SignKey == nil {
+		return nil, errors.New("the current JWT key needs an ID and a signing key")
+	}
+
+	keyring := &Keyring{current: current.ID, keys: map[string]Key{current.ID: current}}
+	for _, key := range previous {
+		if _, exists := keyring.keys[key.ID]; exists || key.ID == "" {
+			return nil, fmt.Errorf("invalid or duplicated JWT key ID %q", key.ID)
+		}
+		keyring.keys[key.ID] = key
+	}
+
+	return keyring, nil
+}
+
+func (k *Keyring) SigningKey() Key {
+	return k.keys[k.current]
+}
+
+func (k *Keyring) Lookup(id string) (Key, bool) {
+	key, ok := k.keys[id]
+	return key, ok
+}
+
+// methods lists the algorithms of the keyring, tokens signed with any other are rejected
+func (k *Keyring) methods() []string {
+	seen := make(map[string]bool)
+	var methods []string
+	for _, key := range k.keys {
+		if !seen[key.Method.Alg()] {
+			seen[key.Method.Alg()] = true
+			methods = append(methods, key.Method.Alg())
+		}
+	}
+	return methods
+}
+
+// NewKeyringFromEnv builds the keyring from the environment:
+// JWT_SIGNING_ALG is HS256 (default) or EdDSA, JWT_KEY_ID names the current key,
+// JWT_SECRET or JWT_ED25519_PRIVATE_KEY_FILE (PEM) is the current key,
+// JWT_PREVIOUS_SECRETS and JWT_PREVIOUS_ED25519_PUBLIC_KEY_FILES list the former keys as "kid:value" separated by commas.
+func NewKeyringFromEnv() (*Keyring, error) {
+	keyID := os.Getenv("JWT_KEY_ID")
+	if keyID == "" {
+		keyID = "default"
+	}
+
+	var current Key
+	switch alg := os.Getenv("JWT_SIGNING_ALG"); alg {
+	case "", jwt.SigningMethodHS256.Alg():
+		secret := os.Getenv("JWT_SECRET")
+		if secret == "" {
+			return nil, errors.New("JWT_SECRET is empty")
+		}
+		current = NewHMACKey(keyID, []byte(secret))
+	case jwt.SigningMethodEdDSA.Alg():
+		pem, err := os.ReadFile(os.Getenv("JWT_ED25519_PRIVATE_KEY_FILE"))
+		if err != nil {
+			return nil, err
+		}
+		privateKey, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, err
+		}
+		current = NewEd25519Key(keyID, privateKey.(ed25519.PrivateKey))
+	default:
+		return nil, fmt.Errorf("unsupported JWT_SIGNING_ALG %s", alg)
+	}
+
+	var previous []Key
+	for id, secret := range parseKeyList(os.Getenv("JWT_PREVIOUS_SECRETS")) {
+		previous = append(previous, Key{ID: id, Method: jwt.SigningMethodHS256, VerifyKey: []byte(secret)})
+	}
+	for id, path := range parseKeyList(os.Getenv("JWT_PREVIOUS_ED25519_PUBLIC_KEY_FILES")) {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		publicKey, err := jwt.ParseEdPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, err
+		}
+		previous = append(previous, Key{ID: id, Method: jwt.SigningMethodEdDSA, VerifyKey: publicKey})
+	}
+
+	return NewKeyring(current, previous...)
+}
+
+// parseKeyList reads "kid:value,kid2:value2"
+func parseKeyList(list string) map[string]string {
+	keys := make(map[string]string)
+	for _, entry := range strings.Split(list, ",") {
+		id, value, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if found && id != "" && value != "" {
+			keys[id] = value
+		}
+	}
+	return keys
+}
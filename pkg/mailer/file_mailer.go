@@ -0,0 +1,25 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FileMailer writes every email as a .eml file in Dir, for local development and tests
+type FileMailer struct {
+	Dir  string
+	From string
+	sent atomic.Uint64
+}
+
+func (m *FileMailer) Send(message Message) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%d.eml", time.Now().UnixNano(), m.sent.Add(1))
+	return os.WriteFile(filepath.Join(m.Dir, name), format(m.From, message), 0o644)
+}
@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"log"
+	"os"
+	"strconv"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(message Message) error
+}
+
+// NewMailerFromEnv picks the mailer from MAILER: smtp, file (MAILER_FILE_DIR) or log, the default
+func NewMailerFromEnv() Mailer {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@droppy.app"
+	}
+
+	switch os.Getenv("MAILER") {
+	case "smtp":
+		port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		if err != nil {
+			port = 587
+		}
+		return &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	case "file":
+		dir := os.Getenv("MAILER_FILE_DIR")
+		if dir == "" {
+			dir = "mails"
+		}
+		return &FileMailer{Dir: dir, From: from}
+	case "", "log":
+		return &LogMailer{}
+	default:
+		log.Printf("Error: Unknown MAILER %s, emails are only logged\n", os.Getenv("MAILER"))
+		return &LogMailer{}
+	}
+}
+
+// LogMailer only logs the emails, for local development
+type LogMailer struct {
+}
+
+func (m *LogMailer) Send(message Message) error {
+	log.Printf("Info: Email to %s: %s\n%s\n", message.To, message.Subject, message.Body)
+	return nil
+}
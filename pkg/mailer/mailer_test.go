@@ -0,0 +1,32 @@
+package mailer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailer(t *testing.T) {
+	m := &FileMailer{Dir: t.TempDir(), From: "no-reply@droppy.app"}
+
+	if err := m.Send(Message{To: "user@droppy.app", Subject: "Hello", Body: "First line\nSecond line"}); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(m.Dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("got %d emails, want 1 (%v)", len(files), err)
+	}
+
+	email, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"From: no-reply@droppy.app\r\n", "To: user@droppy.app\r\n", "Subject: Hello\r\n", "\r\n\r\nFirst line\r\nSecond line"} {
+		if !strings.Contains(string(email), want) {
+			t.Errorf("email %q does not contain %q", email, want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(message Message) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	return smtp.SendMail(fmt.Sprintf("%s:%d", m.Host, m.Port), auth, m.From, []string{message.To}, format(m.From, message))
+}
+
+// format writes the message as a plain text email
+func format(from string, message Message) []byte {
+	var email strings.Builder
+	email.WriteString("From: " + from + "\r\n")
+	email.WriteString("To: " + message.To + "\r\n")
+	email.WriteString("Subject: " + message.Subject + "\r\n")
+	email.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	email.WriteString("MIME-Version: 1.0\r\n")
+	email.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	email.WriteString("\r\n")
+	email.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	return []byte(email.String())
+}
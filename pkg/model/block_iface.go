@@ -0,0 +1,34 @@
+package model
+
+type BlockModel interface {
+	GetID() uint
+	GetBlockerID() uint
+	GetBlockedID() uint
+	GetBlocked() UserModel
+	GetCreatedAt() int
+}
+
+type BlockRepository interface {
+	// Create blocks the user and removes the follows and close friends between both users
+	Create(blockerID uint, blockedID uint) (BlockModel, error)
+	Delete(blockerID uint, blockedID uint) error
+	GetBlockedUsers(blockerID uint) ([]BlockModel, error)
+	// IsBlockedBetween tells whether one of the users blocked the other
+	IsBlockedBetween(userID uint, otherUserID uint) (bool, error)
+	// GetBlockRelatedUserIds returns the users blocked by or blocking the user
+	GetBlockRelatedUserIds(userID uint) ([]uint, error)
+	// GetHiddenUserIds returns the users whose content is hidden from the user: blocked, blocking or muted users
+	GetHiddenUserIds(userID uint) ([]uint, error)
+	// GetHiddenUserIdsAmong returns, for each of the users, the ones among otherUserIDs whose content is hidden from them
+	GetHiddenUserIdsAmong(userIDs []uint, otherUserIDs []uint) (map[uint][]uint, error)
+}
+
+type BlockService interface {
+	BlockUser(requesterID uint, userID uint) (BlockModel, error)
+	UnblockUser(requesterID uint, userID uint) error
+	GetBlockedUsers(requesterID uint) ([]BlockModel, error)
+	MuteUser(requesterID uint, userID uint) (MuteModel, error)
+	UnmuteUser(requesterID uint, userID uint) error
+	GetMutedUsers(requesterID uint) ([]MuteModel, error)
+	GetHiddenUserIds(userID uint) (map[uint]bool, error)
+}
@@ -0,0 +1,27 @@
+package model
+
+type CloseFriendModel interface {
+	GetID() uint
+	GetUserID() uint
+	GetFriendID() uint
+	GetFriend() UserModel
+	GetCreatedAt() int
+}
+
+type CloseFriendRepository interface {
+	Create(userID uint, friendID uint) (CloseFriendModel, error)
+	Delete(userID uint, friendID uint) error
+	GetCloseFriends(userID uint) ([]CloseFriendModel, error)
+	// GetUserIdsHavingCloseFriend returns the users who added the friend to their close friends
+	GetUserIdsHavingCloseFriend(friendID uint) ([]uint, error)
+}
+
+type CloseFriendService interface {
+	AddCloseFriend(userID uint, friendID uint) (CloseFriendModel, error)
+	RemoveCloseFriend(userID uint, friendID uint) error
+	GetCloseFriends(userID uint) ([]CloseFriendModel, error)
+}
+
+type CloseFriendCreationParam struct {
+	UserID uint `json:"userId" binding:"required"`
+}
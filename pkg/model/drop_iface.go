@@ -27,15 +27,32 @@ type DropModel interface {
 	GetCreatedBy() UserModel
 	GetComments() []CommentModel
 	GetTotalLikes() int
+	GetIsLate() bool
+	GetLateBy() int
+	GetContentDuration() int
+	GetContentReleaseYear() int
+	GetContentGenres() []string
+	GetContentLink() string
+	GetFeedCursor() DropFeedCursor
+	GetAudience() string
+	// WithoutContentFrom returns a copy of the drop without the comments and responses of the given users
+	WithoutContentFrom(userIds map[uint]bool) DropModel
 }
 
 type DropRepository interface {
-	Create(dropNotificationId uint, contentType string, content string, description string, contentPicturePath string, contentTitle string, contentSubtitle string, createdById uint, status uint, isPinned bool, picturePath string, lat float64, lng float64, location string) (DropModel, error)
+	Create(dropNotificationId uint, contentType string, content string, description string, contentPicturePath string, contentTitle string, contentSubtitle string, createdById uint, status uint, isPinned bool, picturePath string, lat float64, lng float64, location string, isLate bool, lateBy int, contentDuration int, contentReleaseYear int, contentGenres []string, contentLink string, audience string) (DropModel, error)
 	Delete(dropId uint) error
 	GetUserDrops(userId uint) ([]DropModel, error)
 	GetDropByDropNotificationAndUser(dropNotificationId uint, userId uint) (DropModel, error)
 	GetDropsByUserIdsAndDropNotificationId(userIds []uint, dropNotifId uint) ([]DropModel, error)
+	GetDropsFeedPage(filter DropFeedFilter) ([]DropModel, error)
+	// GetDiscoverCandidates returns the newest drops shared with all followers of the notification from public users the user does not follow,
+	// minus the ones already seen
+	GetDiscoverCandidates(userId uint, dropNotificationId uint, limit int) ([]DropModel, error)
+	CountDropsByContent(dropNotificationId uint) (map[string]int, error)
 	HasUserDropped(dropNotificationId uint, userId uint) (bool, error)
+	// GetUserIdsHavingDropped returns the users among userIds who dropped for the notification
+	GetUserIdsHavingDropped(dropNotificationId uint, userIds []uint) ([]uint, error)
 	GetDropById(dropId uint) (DropModel, error)
 	DropExists(dropId uint) (bool, error)
 	GetUserPinnedDrops(userId uint) ([]DropModel, error)
@@ -50,9 +67,12 @@ type DropRepository interface {
 
 type DropService interface {
 	CanCreateDrop(userId uint) (bool, error)
+	CanSeeFeed(userId uint) (bool, error)
 	IsValidDropCreation(args DropCreationParam) (bool, error)
 	CreateDrop(userId uint, args DropCreationParam) (DropModel, error)
 	GetUserFeed(userId uint) ([]DropModel, error)
+	GetUserFeedHistory(userId uint, args FeedHistoryParam) ([]DropModel, string, error)
+	GetDiscoverFeed(userId uint, limit int) ([]DropModel, error)
 	GetDropsByUserId(userId uint, currentUser UserModel) ([]DropModel, error)
 	HasUserDroppedToday(userId uint) (bool, error)
 	IsCurrentUserLiking(dropId uint, userId uint) (bool, error)
@@ -72,6 +92,8 @@ type DropCreationParam struct {
 	Location           string                `form:"location"`
 	Picture            *multipart.FileHeader `form:"picture" binding:"required"`
 	Groups             []uint                `form:"groups"`
+	// Audience is one of "followers" (default), "close_friends" or "groups", groups only showing the drop to members of Groups
+	Audience string `form:"audience"`
 }
 
 type FilledDropCreation struct {
@@ -86,6 +108,38 @@ type FilledDropCreation struct {
 	Lat                float64 `json:"lat"`
 	Lng                float64 `json:"lng"`
 	Location           string  `json:"location"`
+	IsLate             bool    `json:"isLate"`
+	LateBy             int     `json:"lateBy"`
+	// Content metadata validated by the drop type provider
+	ContentDuration    int      `json:"contentDuration"`
+	ContentReleaseYear int      `json:"contentReleaseYear"`
+	ContentGenres      []string `json:"contentGenres"`
+	ContentLink        string   `json:"contentLink"`
+	Audience           string   `json:"audience"`
+}
+
+type FeedHistoryParam struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit"`
+	Type   string `form:"type"`
+	UserId uint   `form:"userId"`
+}
+
+// DropFeedCursor is the position of the last drop of a feed page, drops are ordered by notification, creation date then ID
+type DropFeedCursor struct {
+	DropNotificationID uint `json:"n"`
+	// CreatedAt is expressed in microseconds, the precision of Postgres timestamps
+	CreatedAt int64 `json:"c"`
+	ID        uint  `json:"i"`
+}
+
+type DropFeedFilter struct {
+	UserIds []uint
+	Type    string
+	// ExcludedDropNotificationID hides the drops of a notification, 0 to keep them all
+	ExcludedDropNotificationID uint
+	After                      *DropFeedCursor
+	Limit                      int
 }
 
 type DropPatch struct {
@@ -1,17 +1,50 @@
 package model
 
+import "time"
+
 type DropNotificationModel interface {
 	GetID() uint
 	GetType() string
+	GetKind() string
 	GetCreatedAt() string
+	GetScheduledAt() int
+	GetFiredAt() int
+	GetStatus() int
+	GetResponseWindow() int
+	GetDeadline() int
 }
 
 type DropNotificationRepository interface {
-	Create(notificationType string) (DropNotificationModel, error)
+	Create(notificationType string, kind string, responseWindow int) (DropNotificationModel, error)
+	Schedule(notificationType string, kind string, scheduledAt time.Time, responseWindow int) (DropNotificationModel, error)
 	GetNotificationByID(notificationId uint) (DropNotificationModel, error)
 	GetCurrentDropNotification() (DropNotificationModel, error)
+	GetLatestPlannedDropNotification() (DropNotificationModel, error)
+	GetUpcomingDropNotifications() ([]DropNotificationModel, error)
+	PlanIfNoneBetween(notificationType string, scheduledAt time.Time, responseWindow int, from time.Time, to time.Time) (DropNotificationModel, error)
+	GetDueDropNotifications(now time.Time) ([]DropNotificationModel, error)
+	MarkAsFired(notificationId uint, firedAt time.Time) (bool, error)
+	UpdatePending(notificationId uint, updates map[string]interface{}) (DropNotificationModel, error)
+	Cancel(notificationId uint) (bool, error)
+}
+
+type DropNotificationService interface {
+	ScheduleDrop(args ScheduleDropParam) (DropNotificationModel, error)
+	GetUpcomingDrops() ([]DropNotificationModel, error)
+	RescheduleDrop(notificationId uint, args RescheduleDropParam) (DropNotificationModel, error)
+	CancelDrop(notificationId uint) error
 }
 
 type ScheduleDropParam struct {
-	Type string `json:"type" binding:"required"`
+	Type           string    `json:"type" binding:"required"`
+	Kind           string    `json:"kind"`
+	ScheduledAt    time.Time `json:"scheduledAt"`
+	ResponseWindow int       `json:"responseWindow"`
+}
+
+type RescheduleDropParam struct {
+	Type           string    `json:"type"`
+	Kind           string    `json:"kind"`
+	ScheduledAt    time.Time `json:"scheduledAt" binding:"required"`
+	ResponseWindow int       `json:"responseWindow"`
 }
@@ -0,0 +1,6 @@
+package model
+
+type DropViewRepository interface {
+	// MarkAsSeen records that the user was shown the drops, drops already seen are ignored
+	MarkAsSeen(userId uint, dropIds []uint) error
+}
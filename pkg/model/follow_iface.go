@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type FollowStatus interface {
 	ToInt() uint
 }
@@ -30,14 +32,42 @@ type FollowRepository interface {
 	GetUserFollowedBy(followerID uint, followedID uint) (FollowModel, error)
 	GetFollowByID(followID uint) (FollowModel, error)
 	GetPendingFollowByID(followID uint) (FollowModel, error)
+	GetSentRequests(userID uint) ([]FollowModel, error)
+	// AcceptPendingRequests accepts every request sent to the user and returns them
+	AcceptPendingRequests(followedID uint) ([]FollowModel, error)
+	// ExpirePendingRequests removes the requests sent before the given time and returns them, only one API instance at a time expires them
+	ExpirePendingRequests(sentBefore time.Time) ([]FollowModel, error)
+	// RemoveFollowers removes the accepted follows of the given followers and returns them
+	RemoveFollowers(followedID uint, followerIDs []uint) ([]FollowModel, error)
 }
 
 type FollowService interface {
 	GetUserFollowing(userID uint, requesterID uint) ([]FollowModel, error)
 	GetUserFollowers(userID uint, requesterID uint) ([]FollowModel, error)
 	DeleteFollow(requesterID uint, followID uint) error
+	GetSentRequests(requesterID uint) ([]FollowModel, error)
+	CancelRequest(requesterID uint, followID uint) (FollowModel, error)
+	AcceptPendingRequests(userID uint) ([]FollowModel, error)
+	ExpirePendingRequests(now time.Time) ([]FollowModel, error)
+	RemoveFollower(userID uint, followerID uint) error
+	RemoveFollowers(userID uint, followerIDs []uint) ([]FollowModel, error)
 }
 
+// FollowRequestTransition tells what happened to a follow request, both users get it in realtime
+type FollowRequestTransition string
+
+const (
+	FollowRequestSent     FollowRequestTransition = "requested"
+	FollowRequestAccepted FollowRequestTransition = "accepted"
+	FollowRequestRejected FollowRequestTransition = "rejected"
+	FollowRequestCanceled FollowRequestTransition = "canceled"
+	FollowRequestExpired  FollowRequestTransition = "expired"
+)
+
 type FollowCreationParam struct {
 	UserToFollowID uint `json:"userId"`
 }
+
+type FollowersRemovalParam struct {
+	UserIDs []uint `json:"userIds" binding:"required,min=1,max=100"`
+}
@@ -13,6 +13,8 @@ type LikeRepository interface {
 	DeleteLike(dropId uint, userId uint) error
 	GetDropTotalLikes(dropId uint) (int, error)
 	LikeExists(dropId uint, userId uint) (bool, error)
+	// GetLikingUserIds returns the users among userIds who like the drop
+	GetLikingUserIds(dropId uint, userIds []uint) ([]uint, error)
 }
 
 type LikeService interface {
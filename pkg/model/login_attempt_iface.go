@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// LoginAttemptState is what is known about the failed logins of an account or an IP address
+type LoginAttemptState struct {
+	Failures      int
+	LastFailureAt time.Time
+	// BlockedUntil is when the next attempt is allowed, after a backoff delay or a lockout
+	BlockedUntil time.Time
+	// Lockouts counts the lockouts since the last successful login
+	Lockouts int
+	// Pending counts the attempts reserved and not settled yet, whose credentials are being checked
+	Pending      int
+	PendingSince time.Time
+}
+
+type LoginLockoutModel interface {
+	GetID() uint
+	GetKey() string
+	GetScope() string
+	GetFailures() int
+	GetLockedUntil() int
+	GetCreatedAt() int
+}
+
+// LoginAttemptRepository stores the login attempts, keyed by account or by IP address
+type LoginAttemptRepository interface {
+	GetState(key string) (LoginAttemptState, error)
+	// UpdateState applies update to the current state of the key, concurrent updates of the same key are serialized
+	UpdateState(key string, update func(state LoginAttemptState) LoginAttemptState) (LoginAttemptState, error)
+	DeleteState(key string) error
+	CreateLockout(key string, scope string, failures int, lockedUntil time.Time) (LoginLockoutModel, error)
+	// GetLockouts returns the latest lockouts first
+	GetLockouts(limit int) ([]LoginLockoutModel, error)
+}
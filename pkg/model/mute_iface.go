@@ -0,0 +1,15 @@
+package model
+
+type MuteModel interface {
+	GetID() uint
+	GetMuterID() uint
+	GetMutedID() uint
+	GetMuted() UserModel
+	GetCreatedAt() int
+}
+
+type MuteRepository interface {
+	Create(muterID uint, mutedID uint) (MuteModel, error)
+	Delete(muterID uint, mutedID uint) error
+	GetMutedUsers(muterID uint) ([]MuteModel, error)
+}
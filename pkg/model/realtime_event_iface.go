@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+type RealtimeEventModel interface {
+	GetID() uint
+	GetChannel() string
+	GetUserID() uint
+	GetPayload() []byte
+	GetCreatedAt() int
+}
+
+type RealtimeEventRepository interface {
+	// Create stores the event and notifies the listeners of notifyChannel with its ID once committed
+	Create(notifyChannel string, channel string, userId uint, payload []byte) (RealtimeEventModel, error)
+	GetEventByID(eventId uint) (RealtimeEventModel, error)
+	// GetUserEventsSince returns the events of the user on the channel, broadcasts included, ordered by ID
+	GetUserEventsSince(channel string, userId uint, afterId uint) ([]RealtimeEventModel, error)
+	// GetEventIDRange returns the oldest and the newest stored event IDs, 0 when there are none
+	GetEventIDRange() (uint, uint, error)
+	// DeleteOlderThan always keeps the newest event so GetEventIDRange knows where IDs are at
+	DeleteOlderThan(before time.Time) (int64, error)
+}
@@ -1,23 +1,63 @@
 package model
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrRefreshTokenAlreadyUsed is returned when rotating a refresh token someone already rotated
+var ErrRefreshTokenAlreadyUsed = errors.New("refresh token already used")
 
 type TokenCreationParam struct {
-	Token  string
-	UserID uint
-	Expiry int
+	// TokenHash is the hash of the opaque refresh token, the token itself is never stored
+	TokenHash string
+	UserID    uint
+	SessionID uint
+	Expiry    int
 }
 
 type AuthTokenModel interface {
 	GetID() uint
-	GetToken() string
+	GetTokenHash() string
 	GetUserID() uint
+	GetSessionID() uint
 	GetExpiry() int
+	IsUsed() bool
+}
+
+type SessionCreationParam struct {
+	UserID     uint
+	DeviceName string
+	IPAddress  string
+	// TwoFactor tells the session was opened with the second factor of the user
+	TwoFactor bool
+}
+
+// AuthSessionModel is a device login, every refresh token it has issued belongs to the same family
+type AuthSessionModel interface {
+	GetID() uint
+	GetUserID() uint
+	GetDeviceName() string
+	GetIPAddress() string
+	GetCreatedAt() int
+	GetLastUsedAt() int
+	IsRevoked() bool
+	IsTwoFactor() bool
 }
 
 type AuthTokenRepository interface {
 	Create(ctx context.Context, args TokenCreationParam) (AuthTokenModel, error)
-	FindByRefreshToken(token string) (AuthTokenModel, error)
-	FindByUserId(uint) (AuthTokenModel, error)
-	Delete(uint) error
+	FindByTokenHash(tokenHash string) (AuthTokenModel, error)
+	// Rotate marks the token as used and creates the next one of its session,
+	// it returns ErrRefreshTokenAlreadyUsed when the token was used in the meantime
+	Rotate(tokenID uint, next TokenCreationParam) (AuthTokenModel, error)
+	CreateSession(args SessionCreationParam) (AuthSessionModel, error)
+	GetSession(sessionID uint) (AuthSessionModel, error)
+	GetActiveSessions(userID uint) ([]AuthSessionModel, error)
+	RevokeSession(sessionID uint) error
+	RevokeUserSessions(userID uint) error
+	// RevokeOtherSessions revokes every session of the user but the given one
+	RevokeOtherSessions(userID uint, keptSessionID uint) error
+	// MarkSessionTwoFactor records that the user confirmed their second factor in the session
+	MarkSessionTwoFactor(sessionID uint) error
 }
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+
+type TwoFactorModel interface {
+	GetUserID() uint
+	GetSecret() string
+	IsEnabled() bool
+}
+
+type RecoveryCodeModel interface {
+	GetID() uint
+	GetCodeHash() string
+}
+
+type TwoFactorRepository interface {
+	// Get returns nil when the user never set two-factor authentication up
+	Get(userID uint) (TwoFactorModel, error)
+	// SavePending replaces the secret the user is enrolling with, it returns ErrTwoFactorAlreadyEnabled once enabled
+	SavePending(userID uint, secret string) (TwoFactorModel, error)
+	// Enable turns the pending secret on, step being the time step of the code which confirmed it
+	Enable(userID uint, step int64, recoveryCodeHashes []string) error
+	// Disable removes the secret and the recovery codes of the user
+	Disable(userID uint) error
+	// UseStep records the time step of an accepted code, it returns false when this step or a later one was already used
+	UseStep(userID uint, step int64) (bool, error)
+	ReplaceRecoveryCodes(userID uint, codeHashes []string) error
+	GetUnusedRecoveryCodes(userID uint) ([]RecoveryCodeModel, error)
+	// UseRecoveryCode marks the code as used, it returns false when it already was
+	UseRecoveryCode(id uint) (bool, error)
+	// UseToken records the ID of a two-factor token exchanged for a session, it returns false when it already was
+	UseToken(tokenID string, expiresAt time.Time) (bool, error)
+}
+
+type TwoFactorCodeParam struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorDisableParam struct {
+	Password string `json:"password"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// TwoFactorLoginParam completes a login with either a code of the authenticator app or a recovery code
+type TwoFactorLoginParam struct {
+	TwoFactorToken string `json:"twoFactorToken" binding:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recoveryCode"`
+}
@@ -71,6 +71,24 @@ type LoginParam struct {
 	FcmToken string `json:"fcmToken"`
 }
 
+type EmailParam struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type EmailVerificationParam struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type PasswordResetParam struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type PasswordChangeParam struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required"`
+}
+
 type AdminUpdateUserRequest struct {
 	Role     string `json:"role" binding:"required"`
 	Username string `json:"username" binding:"required"`
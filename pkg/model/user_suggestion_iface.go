@@ -0,0 +1,33 @@
+package model
+
+// UserSuggestionSignals is what a suggested user has in common with the user
+type UserSuggestionSignals struct {
+	UserID uint
+	// MutualFollows is the number of followed users who follow the suggested user
+	MutualFollows int
+	SharedGroups  int
+	// SharedContents is the number of contents both users dropped
+	SharedContents int
+}
+
+type UserSuggestionWeights struct {
+	MutualFollows  int
+	SharedGroups   int
+	SharedContents int
+}
+
+type UserSuggestionRepository interface {
+	// GetSuggestionSignals returns the best users to suggest, leaving out followed, pending, blocked and muted users
+	// and private users who rejected a request of the user
+	GetSuggestionSignals(userId uint, weights UserSuggestionWeights, limit int) ([]UserSuggestionSignals, error)
+}
+
+type UserSuggestion struct {
+	User    UserModel
+	Signals UserSuggestionSignals
+	Reasons []string
+}
+
+type UserSuggestionService interface {
+	GetSuggestions(userId uint, limit int) ([]UserSuggestion, error)
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// UserTokenPurpose tells which flow a token sent by email belongs to
+type UserTokenPurpose string
+
+const (
+	UserTokenEmailVerification UserTokenPurpose = "email_verification"
+	UserTokenPasswordReset     UserTokenPurpose = "password_reset"
+)
+
+type UserTokenModel interface {
+	GetID() uint
+	GetUserID() uint
+	GetPurpose() UserTokenPurpose
+	GetExpiresAt() int
+}
+
+type UserTokenRepository interface {
+	// Create replaces the tokens the user has not used yet for the same purpose
+	Create(userID uint, purpose UserTokenPurpose, tokenHash string, expiresAt time.Time) (UserTokenModel, error)
+	// Consume marks the token as used, it returns nil when the token is unknown, already used or expired
+	Consume(purpose UserTokenPurpose, tokenHash string) (UserTokenModel, error)
+}
@@ -0,0 +1,37 @@
+package pagination
+
+import (
+	"errors"
+	"testing"
+)
+
+type position struct {
+	ID        uint  `json:"i"`
+	CreatedAt int64 `json:"c"`
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	want := position{ID: 42, CreatedAt: 1718000000123456}
+
+	cursor, err := EncodeCursor(want)
+	if err != nil {
+		t.Fatalf("EncodeCursor() error = %v", err)
+	}
+
+	var got position
+	if err := DecodeCursor(cursor, &got); err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("DecodeCursor() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	for _, cursor := range []string{"not base64 !", "bm90IGpzb24"} {
+		var got position
+		if err := DecodeCursor(cursor, &got); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) error = %v, want %v", cursor, err, ErrInvalidCursor)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package account
 
-import "context"
+import (
+	"context"
+	"go-api/pkg/model"
+)
 
 type AccountServiceIface interface {
 	Create(string, string, string) error
@@ -9,11 +12,34 @@ type AccountServiceIface interface {
 	LoginWithFirebase(string, context.Context) (*TokenInfo, error)
 	LoginWithGoogle(string) (*TokenInfo, error)
 	LoginFromRefreshToken(string) (*TokenInfo, error)
+	LoginWithTwoFactor(string, string, string) (*TokenInfo, error)
 	EmailExists(string) (bool, error)
+	GetSessions(uint) ([]model.AuthSessionModel, error)
+	RevokeSession(uint, uint) error
+	RevokeAllSessions(uint) error
 }
 
+// DeviceInfo describes the device a session is opened from
+type DeviceInfo struct {
+	Name      string
+	IPAddress string
+}
+
+// TokenInfo holds the tokens of a new session, or the token to complete the login with the second factor when TwoFactorRequired is set
 type TokenInfo struct {
-	JWTToken     string `json:"jwtToken"`
-	RefreshToken string `json:"refreshToken"`
-	Expiry       int
+	JWTToken          string `json:"jwtToken"`
+	RefreshToken      string `json:"refreshToken"`
+	Expiry            int
+	TwoFactorRequired bool   `json:"twoFactorRequired,omitempty"`
+	TwoFactorToken    string `json:"twoFactorToken,omitempty"`
+}
+
+// TwoFactorSetup is what the user adds to their authenticator app, URI being meant for a QR code
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
 }
@@ -0,0 +1,76 @@
+package totp
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// The SHA1 test vectors of RFC 6238, appendix B
+func TestHOTPMatchesRFC6238Vectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, v := range vectors {
+		if got := hotp(key, uint64(v.unix/Period), 8); got != v.code {
+			t.Errorf("code at %d = %s, want %s", v.unix, got, v.code)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+
+	code, err := Code(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != Digits {
+		t.Fatalf("code %q has %d digits, want %d", code, len(code), Digits)
+	}
+
+	step, err := Verify(secret, code, now.Add(Period*time.Second))
+	if err != nil {
+		t.Fatalf("code of the previous step rejected: %v", err)
+	}
+	if step != Step(now) {
+		t.Errorf("matched step %d, want %d", step, Step(now))
+	}
+
+	if _, err := Verify(secret, code, now.Add(3*Period*time.Second)); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("expired code: got %v, want ErrInvalidCode", err)
+	}
+	if _, err := Verify("not base32!", code, now); !errors.Is(err, ErrInvalidSecret) {
+		t.Errorf("invalid secret: got %v, want ErrInvalidSecret", err)
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri, err := url.Parse(URI("Droppy", "jane@example.com", "JBSWY3DPEHPK3PXP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/Droppy:jane@example.com" {
+		t.Errorf("unexpected URI %s", uri)
+	}
+	query := uri.Query()
+	if query.Get("secret") != "JBSWY3DPEHPK3PXP" || query.Get("issuer") != "Droppy" || query.Get("digits") != "6" {
+		t.Errorf("unexpected query %s", uri.RawQuery)
+	}
+}
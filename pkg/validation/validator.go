@@ -30,6 +30,17 @@ func ValidateUserCreation(args model.UserCreationParam) errors2.MultiFieldsError
 	return finalErrors
 }
 
+func ValidatePassword(field string, password string) errors2.MultiFieldsError {
+	finalErrors := errors2.MultiFieldsError{
+		Fields: map[string]string{},
+	}
+	if len(password) < 8 {
+		finalErrors.Fields[field] = "Password must be at least 8 characters long"
+	}
+
+	return finalErrors
+}
+
 func ValidateUserPatch(args model.UserPatchParam) errors2.MultiFieldsError {
 	finalErrors := errors2.MultiFieldsError{
 		Fields: map[string]string{},